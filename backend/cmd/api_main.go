@@ -1,15 +1,35 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/TheApostroff/skill-space/internal/api/app"
 	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/sandbox"
 )
 
 func main() {
+	// Sandboxed runs start this binary as their init process
+	sandbox.Init()
+
 	cfg := config.NewConfig()
 
+	// Database migrations: main migrate up|down [steps]|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := app.RunMigrate(cfg, os.Args[2:], os.Stdout)
+		if errors.Is(err, app.ErrMigrateUsage) {
+			fmt.Fprintln(os.Stderr, app.MigrateUsage)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
 	// Setup API routes
 	app, err := app.NewApp(cfg)
 	if err != nil {
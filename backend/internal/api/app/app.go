@@ -1,14 +1,28 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/TheApostroff/skill-space/internal/api/controllers"
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/repositories"
 	"github.com/TheApostroff/skill-space/internal/api/services"
 	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/events"
+	"github.com/TheApostroff/skill-space/internal/mail"
+	"github.com/TheApostroff/skill-space/internal/migrations"
+	"github.com/TheApostroff/skill-space/internal/realtime"
+	"github.com/TheApostroff/skill-space/internal/sandbox"
+	"github.com/TheApostroff/skill-space/internal/storage"
+	"github.com/TheApostroff/skill-space/internal/taskgen"
 	"github.com/TheApostroff/skill-space/pkg/database"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -20,10 +34,21 @@ type App struct {
 	Router *gin.Engine
 	DB     *gorm.DB
 	Config *config.Config
+
+	similarityService *services.SimilarityService
 }
 
+// shutdownTimeout is how long open requests get to finish when the server
+// is stopped
+const shutdownTimeout = 10 * time.Second
+
 // NewApp creates a new application instance
 func NewApp(cfg *config.Config) (*App, error) {
+	err := cfg.Auth.Validate()
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize database
 	db, err := database.NewPostgres(&cfg.Server)
 	if err != nil {
@@ -54,85 +79,238 @@ func (a *App) SetupMiddleware() {
 	a.Router.Use(gin.Recovery())
 }
 
-// SetupDatabase performs database migrations
+// SetupDatabase makes sure the database schema is up to date, applying
+// pending migrations first if the server is configured to
 func (a *App) SetupDatabase() error {
-	// Auto-migrate all models
-	err := a.DB.AutoMigrate(
-		&models.Course{},
-		&models.Resource{},
-		&models.Section{},
-		&models.Activity{},
-		&models.Assignment{},
-		&models.Attachment{},
-		&models.Submission{},
-		&models.GenerativeTask{},
-		&models.GenerativeTaskSubmission{},
-		&models.TestCase{},
-		&models.Grade{},
-		&models.Enrollment{},
-		&models.ForumPost{},
-		&models.APIUser{},
-	)
+	migrator, err := migrations.New(a.DB)
 	if err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	if a.Config.Server.MigrateOnStart {
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
 	}
 
-	log.Println("Database migration completed successfully")
+	pending, err := migrator.Pending()
+	if err != nil {
+		return fmt.Errorf("failed to check migrations: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is %d migrations behind, run \"migrate up\" first", len(pending))
+	}
+
+	log.Println("Database schema is up to date")
 	return nil
 }
 
 // SetupRoutes configures all routes for the application
-func (a *App) SetupRoutes() {
+func (a *App) SetupRoutes() error {
 	// Initialize repositories
 	courseRepo := repositories.NewCourseRepository(a.DB)
 	sectionRepo := repositories.NewSectionRepository(a.DB)
 	activityRepo := repositories.NewActivityRepository(a.DB)
+	activityCompletionRepo := repositories.NewActivityCompletionRepository(a.DB)
 	assignmentRepo := repositories.NewAssignmentRepository(a.DB)
 	generativeTaskRepo := repositories.NewGenerativeTaskRepository(a.DB)
 	gradeRepo := repositories.NewGradeRepository(a.DB)
+	gradingSchemeRepo := repositories.NewGradingSchemeRepository(a.DB)
 	enrollmentRepo := repositories.NewEnrollmentRepository(a.DB)
 	forumRepo := repositories.NewForumRepository(a.DB)
 	userRepo := repositories.NewUserRepository(a.DB)
+	attachmentRepo := repositories.NewAttachmentRepository(a.DB)
+	resourceRepo := repositories.NewResourceRepository(a.DB)
+	questionRepo := repositories.NewQuestionRepository(a.DB)
+	quizRepo := repositories.NewQuizRepository(a.DB)
+	peerReviewRepo := repositories.NewPeerReviewRepository(a.DB)
+	similarityRepo := repositories.NewSimilarityRepository(a.DB)
+	auditRepo := repositories.NewAuditRepository(a.DB)
+	trashRepo := repositories.NewTrashRepository(a.DB)
+	notificationRepo := repositories.NewNotificationRepository(a.DB)
+	messageRepo := repositories.NewMessageRepository(a.DB)
+	transactor := repositories.NewTransactor(a.DB)
+
+	// Domain events connect the services that raise them to those that react
+	bus := events.NewBus()
+	hub, err := realtime.NewHub(realtime.NewLocalBroker())
+	if err != nil {
+		return fmt.Errorf("failed to create realtime hub: %w", err)
+	}
 
 	// Initialize services
-	courseService := services.NewCourseService(courseRepo)
-	activityService := services.NewActivityService(sectionRepo, activityRepo)
-	assignmentService := services.NewAssignmentService(assignmentRepo)
-	generativeTaskService := services.NewGenerativeTaskService(generativeTaskRepo)
-	gradeService := services.NewGradeService(gradeRepo, enrollmentRepo)
-	forumService := services.NewForumService(forumRepo)
-	userService := services.NewUserService(userRepo)
+	authService := services.NewAuthService(userRepo, &a.Config.Auth)
+	courseService := services.NewCourseService(courseRepo, transactor, bus)
+	progressService := services.NewProgressService(activityCompletionRepo, enrollmentRepo, sectionRepo, activityRepo, transactor)
+	activityService := services.NewActivityService(courseRepo, sectionRepo, activityRepo, progressService, transactor)
+	gradeService := services.NewGradeService(gradeRepo, enrollmentRepo, gradingSchemeRepo, assignmentRepo, courseRepo, userRepo, transactor)
+	enrollmentService := services.NewEnrollmentService(enrollmentRepo, courseRepo, userRepo, transactor, bus)
+	similarityService := services.NewSimilarityService(similarityRepo, assignmentRepo, generativeTaskRepo, activityService, transactor, &a.Config.Similarity)
+	similarityService.StartWorkers()
+	a.similarityService = similarityService
+	assignmentService := services.NewAssignmentService(assignmentRepo, courseRepo, enrollmentRepo, gradeService, similarityService, transactor, bus)
+	peerReviewService := services.NewPeerReviewService(peerReviewRepo, assignmentRepo, assignmentService, transactor)
+	quizService := services.NewQuizService(questionRepo, quizRepo, courseRepo, activityRepo, sectionRepo, enrollmentRepo, assignmentService, progressService, transactor)
+	taskGenerator, err := taskgen.New(&a.Config.Generator)
+	if err != nil {
+		return fmt.Errorf("failed to create task generator: %w", err)
+	}
+	runner := sandbox.NewRunner(&a.Config.Sandbox)
+	err = runner.Check(context.Background())
+	if err != nil {
+		log.Printf("Generative task submissions will fail: %v", err)
+	}
+	generativeTaskService := services.NewGenerativeTaskService(
+		generativeTaskRepo,
+		courseRepo,
+		sectionRepo,
+		activityRepo,
+		enrollmentRepo,
+		gradeService,
+		similarityService,
+		taskGenerator,
+		runner,
+		transactor,
+		bus,
+		&a.Config.Generator,
+	)
+	fileStorage, err := storage.New(&a.Config.Storage)
+	if err != nil {
+		return fmt.Errorf("failed to create file storage: %w", err)
+	}
+	urlSecret := []byte(a.Config.Storage.URLSecret)
+	if len(urlSecret) == 0 {
+		urlSecret, err = storage.DeriveURLSecret(a.Config.Auth.JWTSecret)
+		if err != nil {
+			return fmt.Errorf("failed to derive download link secret: %w", err)
+		}
+	}
+	fileService := services.NewFileService(
+		attachmentRepo,
+		resourceRepo,
+		assignmentRepo,
+		peerReviewRepo,
+		courseRepo,
+		enrollmentRepo,
+		fileStorage,
+		storage.NewURLSigner(urlSecret, a.Config.Storage.DownloadURLTTL),
+		transactor,
+		&a.Config.Storage,
+	)
+	forumService := services.NewForumService(forumRepo, courseRepo, sectionRepo, activityRepo, enrollmentRepo, transactor, bus)
+	userService := services.NewUserService(userRepo, transactor)
+	auditService := services.NewAuditService(auditRepo)
+	trashService := services.NewTrashService(trashRepo, courseRepo, sectionRepo, activityRepo, fileStorage, &a.Config.Trash)
+	trashService.StartPurge()
+	mailer, err := mail.New(&a.Config.Notifications.SMTP)
+	if err != nil {
+		return fmt.Errorf("failed to create mailer: %w", err)
+	}
+	notificationService := services.NewNotificationService(
+		notificationRepo,
+		userRepo,
+		enrollmentRepo,
+		assignmentRepo,
+		transactor,
+		mailer,
+		bus,
+		&a.Config.Notifications,
+	)
+	notificationService.Subscribe()
+	notificationService.StartDigests()
+	notificationService.StartReminders()
+	messageService := services.NewMessageService(messageRepo, userRepo, courseRepo, enrollmentRepo, transactor)
+	streamService := services.NewStreamService(hub, bus, courseRepo, sectionRepo, activityRepo, enrollmentRepo)
+	streamService.Subscribe()
 
 	// Initialize controllers
+	authController := controllers.NewAuthController(authService)
 	courseController := controllers.NewCourseController(courseService)
 	activityController := controllers.NewActivityController(activityService)
 	assignmentController := controllers.NewAssignmentController(assignmentService)
+	peerReviewController := controllers.NewPeerReviewController(peerReviewService)
+	quizController := controllers.NewQuizController(quizService)
 	generativeTaskController := controllers.NewGenerativeTaskController(generativeTaskService)
+	similarityController := controllers.NewSimilarityController(similarityService)
 	gradeController := controllers.NewGradeController(gradeService)
+	enrollmentController := controllers.NewEnrollmentController(enrollmentService)
+	progressController := controllers.NewProgressController(progressService)
+	fileController := controllers.NewFileController(fileService)
 	forumController := controllers.NewForumController(forumService)
 	userController := controllers.NewUserController(userService)
+	auditController := controllers.NewAuditController(auditService)
+	trashController := controllers.NewTrashController(trashService)
+	notificationController := controllers.NewNotificationController(notificationService)
+	messageController := controllers.NewMessageController(messageService)
+	streamController := controllers.NewStreamController(streamService)
 
 	// Setup API routes
 	a.setupAPIRoutes(
+		authService,
+		courseService,
+		activityService,
+		assignmentService,
+		quizService,
+		similarityService,
+		trashService,
+		forumService,
+		authController,
 		courseController,
 		activityController,
 		assignmentController,
+		peerReviewController,
+		quizController,
 		generativeTaskController,
+		similarityController,
 		gradeController,
+		enrollmentController,
+		progressController,
+		fileController,
 		forumController,
 		userController,
+		auditController,
+		trashController,
+		notificationController,
+		messageController,
+		streamController,
 	)
+
+	return nil
 }
 
 // setupAPIRoutes configures all API routes
 func (a *App) setupAPIRoutes(
+	authService *services.AuthService,
+	courseService *services.CourseService,
+	activityService *services.ActivityService,
+	assignmentService *services.AssignmentService,
+	quizService *services.QuizService,
+	similarityService *services.SimilarityService,
+	trashService *services.TrashService,
+	forumService *services.ForumService,
+	authController *controllers.AuthController,
 	courseController *controllers.CourseController,
 	activityController *controllers.ActivityController,
 	assignmentController *controllers.AssignmentController,
+	peerReviewController *controllers.PeerReviewController,
+	quizController *controllers.QuizController,
 	generativeTaskController *controllers.GenerativeTaskController,
+	similarityController *controllers.SimilarityController,
 	gradeController *controllers.GradeController,
+	enrollmentController *controllers.EnrollmentController,
+	progressController *controllers.ProgressController,
+	fileController *controllers.FileController,
 	forumController *controllers.ForumController,
 	userController *controllers.UserController,
+	auditController *controllers.AuditController,
+	trashController *controllers.TrashController,
+	notificationController *controllers.NotificationController,
+	messageController *controllers.MessageController,
+	streamController *controllers.StreamController,
 ) {
 	// API routes group
 	api := a.Router.Group("/api")
@@ -146,84 +324,252 @@ func (a *App) setupAPIRoutes(
 			})
 		})
 
+		// Auth routes
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", authController.Register)
+			auth.POST("/login", authController.Login)
+			auth.GET("/me", middleware.RequireAuth(authService), authController.Me)
+			auth.POST("/stream-ticket", middleware.RequireAuth(authService), authController.StreamTicket)
+		}
+
+		// Signed download links carry their own authorization
+		api.GET("/files/:kind/:fileId", fileController.DownloadFile)
+
+		// Event streams, which browsers open without an Authorization header
+		api.GET("/stream", middleware.RequireStreamTicket(authService, "ticket"), streamController.Stream)
+	}
+
+	// Authenticated API routes
+	api = api.Group("", middleware.RequireAuth(authService))
+
+	// Permission rules shared by route groups
+	staff := middleware.RequireRoles(models.RoleProfessor, models.RoleAdmin)
+	adminOnly := middleware.RequireRoles(models.RoleAdmin)
+	studentOnly := middleware.RequireRoles(models.RoleStudent)
+	courseOwner := middleware.RequireOwner("courseId", courseService.GetInstructorID, models.RoleAdmin)
+	sectionOwner := middleware.RequireOwner("sectionId", activityService.GetSectionInstructorID, models.RoleAdmin)
+	activityOwner := middleware.RequireOwner("activityId", activityService.GetActivityInstructorID, models.RoleAdmin)
+	assignmentOwner := middleware.RequireOwner("assignmentId", assignmentService.GetInstructorID, models.RoleAdmin)
+	questionOwner := middleware.RequireOwner("questionId", quizService.GetQuestionInstructorID, models.RoleAdmin)
+	submissionOwner := middleware.RequireOwner("submissionId", similarityService.GetSubmissionInstructorID, models.RoleAdmin)
+	taskSubmissionOwner := middleware.RequireOwner("submissionId", similarityService.GetTaskSubmissionInstructorID, models.RoleAdmin)
+	deletedCourseOwner := middleware.RequireOwner("courseId", trashService.GetCourseInstructorID, models.RoleAdmin)
+	deletedActivityOwner := middleware.RequireOwner("activityId", trashService.GetActivityInstructorID, models.RoleAdmin)
+	postModerator := middleware.RequireOwner("postId", forumService.GetInstructorID, models.RoleAdmin)
+	{
 		// Course routes
 		courses := api.Group("/courses")
 		{
 			courses.GET("", courseController.GetAllCourses)
-			courses.POST("", courseController.CreateCourse)
+			courses.POST("", staff, courseController.CreateCourse)
+			courses.GET("/trash", staff, trashController.GetDeletedCourses)
 			courses.GET("/:courseId", courseController.GetCourseByID)
-			courses.PUT("/:courseId", courseController.UpdateCourse)
-			courses.DELETE("/:courseId", courseController.DeleteCourse)
+			courses.PUT("/:courseId", courseOwner, courseController.UpdateCourse)
+			courses.DELETE("/:courseId", courseOwner, courseController.DeleteCourse)
+
+			// Trash
+			courses.GET("/:courseId/trash", deletedCourseOwner, trashController.GetCourseTrash)
+			courses.POST("/:courseId/restore", deletedCourseOwner, courseController.RestoreCourse)
 
 			// Course sections
 			courses.GET("/:courseId/sections", activityController.GetCourseSections)
-			courses.POST("/:courseId/sections", activityController.CreateSection)
+			courses.POST("/:courseId/sections", courseOwner, activityController.CreateSection)
 
 			// Activities
 			courses.GET("/:courseId/activities/:activityId", activityController.GetActivity)
+
+			// Grading scheme and course grades
+			courses.GET("/:courseId/grading-scheme", gradeController.GetGradingScheme)
+			courses.PUT("/:courseId/grading-scheme", courseOwner, gradeController.UpdateGradingScheme)
+			courses.GET("/:courseId/course-grades", courseOwner, gradeController.GetCourseGrades)
+			courses.GET("/:courseId/course-grades/me", gradeController.GetCourseGrade)
+			courses.GET("/:courseId/course-grades/:studentId", courseOwner, gradeController.GetCourseGrade)
+
+			// Roster
+			courses.GET("/:courseId/roster", courseOwner, enrollmentController.GetCourseRoster)
+
+			// Progress
+			courses.GET("/:courseId/progress", courseOwner, progressController.GetCourseProgressForAll)
+			courses.GET("/:courseId/progress/me", progressController.GetCourseProgress)
+			courses.GET("/:courseId/progress/:studentId", courseOwner, progressController.GetCourseProgress)
+
+			// Resources
+			courses.POST("/:courseId/resources", courseOwner, fileController.UploadResource)
+
+			// Question bank and quizzes
+			courses.GET("/:courseId/questions", courseOwner, quizController.GetQuestions)
+			courses.POST("/:courseId/questions", courseOwner, quizController.CreateQuestion)
+			courses.GET("/:courseId/questions/qti", courseOwner, quizController.ExportCourseQTI)
+			courses.GET("/:courseId/quizzes", quizController.GetCourseQuizzes)
+			courses.POST("/:courseId/quizzes", courseOwner, quizController.CreateQuiz)
+
+			// Announcements
+			courses.POST("/:courseId/announcements", courseOwner, messageController.PostAnnouncement)
+
+			// Q&A forums
+			courses.GET("/:courseId/unanswered-questions", forumController.GetUnansweredQuestions)
 		}
 
 		// Section routes
 		sections := api.Group("/sections")
 		{
-			sections.POST("/:sectionId/activities", activityController.CreateActivity)
+			sections.POST("/:sectionId/activities", sectionOwner, activityController.CreateActivity)
+			sections.POST("/:sectionId/qti", sectionOwner, quizController.ImportQTI)
 		}
 
 		// Activity routes
 		activities := api.Group("/activities")
 		{
-			activities.PUT("/:activityId", activityController.UpdateActivity)
-			activities.DELETE("/:activityId", activityController.DeleteActivity)
+			activities.PUT("/:activityId", activityOwner, activityController.UpdateActivity)
+			activities.DELETE("/:activityId", activityOwner, activityController.DeleteActivity)
+			activities.POST("/:activityId/restore", deletedActivityOwner, activityController.RestoreActivity)
+			activities.POST("/:activityId/complete", studentOnly, progressController.CompleteActivity)
+			activities.DELETE("/:activityId/complete", studentOnly, progressController.UncompleteActivity)
+			activities.GET("/:activityId/qti", activityOwner, quizController.ExportActivityQTI)
 		}
 
 		// Assignment routes
 		assignments := api.Group("/assignments")
 		{
 			assignments.GET("", assignmentController.GetAllAssignments)
-			assignments.POST("", assignmentController.CreateAssignment)
+			assignments.POST("", staff, assignmentController.CreateAssignment)
 			assignments.GET("/:assignmentId", assignmentController.GetAssignmentByID)
-			assignments.POST("/submit", assignmentController.SubmitAssignment)
-			assignments.POST("/grade", assignmentController.GradeAssignment)
+			assignments.POST("/submit", studentOnly, assignmentController.SubmitAssignment)
+			assignments.POST("/grade", staff, assignmentController.GradeAssignment)
+			assignments.POST("/grade/rubric", staff, assignmentController.GradeWithRubric)
+			assignments.GET("/:assignmentId/rubric", assignmentController.GetRubric)
+			assignments.PUT("/:assignmentId/rubric", assignmentOwner, assignmentController.SetRubric)
+			assignments.DELETE("/:assignmentId/rubric", assignmentOwner, assignmentController.DeleteRubric)
+			assignments.GET("/:assignmentId/extensions", assignmentOwner, assignmentController.GetExtensions)
+			assignments.POST("/:assignmentId/extensions", assignmentOwner, assignmentController.GrantExtension)
+			assignments.GET("/:assignmentId/submissions/me", assignmentController.GetSubmissionHistory)
+			assignments.GET("/:assignmentId/submissions/:studentId", assignmentOwner, assignmentController.GetSubmissionHistory)
+			assignments.GET("/:assignmentId/peer-reviews", assignmentOwner, peerReviewController.GetSummaries)
+			assignments.POST("/:assignmentId/peer-reviews/assign", assignmentOwner, peerReviewController.AssignReviewers)
+			assignments.POST("/:assignmentId/peer-reviews/apply", assignmentOwner, peerReviewController.ApplyPeerScores)
+			assignments.GET("/:assignmentId/peer-reviews/assigned", studentOnly, peerReviewController.GetAssignedReviews)
+			assignments.GET("/:assignmentId/peer-reviews/received", studentOnly, peerReviewController.GetReceivedReviews)
+		}
+
+		// Peer review routes
+		api.PUT("/peer-reviews/:reviewId", studentOnly, peerReviewController.SubmitReview)
+
+		// Submission routes
+		submissions := api.Group("/submissions")
+		{
+			submissions.GET("/:submissionId/similarity", submissionOwner, similarityController.GetSubmissionReport)
+			submissions.POST("/:submissionId/similarity", submissionOwner, similarityController.RecheckSubmission)
+		}
+
+		// Question routes
+		questions := api.Group("/questions")
+		{
+			questions.PUT("/:questionId", questionOwner, quizController.UpdateQuestion)
+			questions.DELETE("/:questionId", questionOwner, quizController.DeleteQuestion)
+		}
+
+		// Quiz routes
+		quizzes := api.Group("/quizzes")
+		{
+			quizzes.GET("/:quizId", quizController.GetQuiz)
+			quizzes.POST("/:quizId/attempts", studentOnly, quizController.StartAttempt)
+		}
+
+		// Quiz attempt routes
+		quizAttempts := api.Group("/quiz-attempts")
+		{
+			quizAttempts.GET("/:attemptId", quizController.GetAttempt)
+			quizAttempts.PUT("/:attemptId/responses", studentOnly, quizController.SaveResponses)
+			quizAttempts.POST("/:attemptId/submit", studentOnly, quizController.SubmitAttempt)
 		}
 
 		// Generative task routes
 		generativeTasks := api.Group("/generative-tasks")
 		{
-			generativeTasks.POST("/generate", generativeTaskController.GenerateTask)
-			generativeTasks.POST("/submit", generativeTaskController.SubmitTask)
+			generativeTasks.POST("/generate", studentOnly, generativeTaskController.GenerateTask)
+			generativeTasks.POST("/submit", studentOnly, generativeTaskController.SubmitTask)
 			generativeTasks.GET("/:taskId/hints", generativeTaskController.GetTaskHints)
+			generativeTasks.GET("/submissions/:submissionId/similarity", taskSubmissionOwner, similarityController.GetTaskSubmissionReport)
+			generativeTasks.POST("/submissions/:submissionId/similarity", taskSubmissionOwner, similarityController.RecheckTaskSubmission)
 		}
 
 		// Grade routes
 		grades := api.Group("/grades")
 		{
-			grades.GET("", gradeController.GetAllGrades)
-			grades.GET("/student/:studentId", gradeController.GetGradesByStudentID)
+			grades.GET("", staff, gradeController.GetAllGrades)
+			grades.GET("/student/:studentId", middleware.RequireSelf("studentId", models.RoleProfessor, models.RoleAdmin), gradeController.GetGradesByStudentID)
 		}
 
 		// Enrollment routes
 		enrollments := api.Group("/enrollments")
 		{
-			enrollments.GET("", gradeController.GetAllEnrollments)
-			enrollments.POST("", gradeController.CreateEnrollment)
+			enrollments.GET("", staff, enrollmentController.GetAllEnrollments)
+			enrollments.POST("", enrollmentController.CreateEnrollment)
+			enrollments.PUT("/:enrollmentId/status", enrollmentController.UpdateEnrollmentStatus)
 		}
 
+		// File routes
+		api.POST("/uploads", fileController.UploadAttachment)
+		api.GET("/attachments/:attachmentId/download", fileController.GetAttachmentLink)
+		api.GET("/resources/:resourceId/download", fileController.GetResourceLink)
+
 		// Forum routes
 		forumPosts := api.Group("/forum-posts")
 		{
 			forumPosts.GET("", forumController.GetForumPosts)
 			forumPosts.POST("", forumController.CreatePost)
 			forumPosts.POST("/reply", forumController.CreateReply)
+			forumPosts.GET("/:postId", forumController.GetPost)
+			forumPosts.PUT("/:postId", forumController.UpdatePost)
+			forumPosts.DELETE("/:postId", forumController.DeletePost)
+			forumPosts.POST("/:postId/restore", forumController.RestorePost)
+			forumPosts.GET("/:postId/revisions", forumController.GetRevisions)
+			forumPosts.POST("/:postId/pin", postModerator, forumController.PinPost)
+			forumPosts.DELETE("/:postId/pin", postModerator, forumController.PinPost)
+			forumPosts.POST("/:postId/lock", postModerator, forumController.LockPost)
+			forumPosts.DELETE("/:postId/lock", postModerator, forumController.LockPost)
+			forumPosts.POST("/:postId/accept", forumController.AcceptAnswer)
+			forumPosts.DELETE("/:postId/accept", forumController.AcceptAnswer)
+			forumPosts.POST("/:postId/vote", forumController.VotePost)
+			forumPosts.DELETE("/:postId/vote", forumController.VotePost)
+		}
+
+		// Notification routes
+		notifications := api.Group("/notifications")
+		{
+			notifications.GET("", notificationController.GetNotifications)
+			notifications.GET("/unread-count", notificationController.GetUnreadCount)
+			notifications.POST("/read-all", notificationController.MarkAllRead)
+			notifications.POST("/:notificationId/read", notificationController.MarkRead)
+			notifications.GET("/preferences", notificationController.GetPreferences)
+			notifications.PUT("/preferences", notificationController.UpdatePreferences)
+		}
+
+		// Messaging routes
+		conversations := api.Group("/conversations")
+		{
+			conversations.GET("", messageController.GetConversations)
+			conversations.POST("", messageController.CreateConversation)
+			conversations.GET("/unread-count", messageController.GetUnreadCount)
+			conversations.GET("/:conversationId", messageController.GetConversation)
+			conversations.GET("/:conversationId/messages", messageController.GetMessages)
+			conversations.POST("/:conversationId/messages", messageController.SendMessage)
+			conversations.POST("/:conversationId/read", messageController.MarkRead)
 		}
 
 		// User routes
 		users := api.Group("/users")
 		{
-			users.GET("", userController.GetAllUsers)
+			users.GET("", adminOnly, userController.GetAllUsers)
 			users.GET("/:email", userController.GetUserByEmail)
-			users.POST("/", userController.CreateUser)
-			users.PUT("/:userId", userController.UpdateUser)
+			users.POST("/", adminOnly, userController.CreateUser)
+			users.PUT("/:userId", middleware.RequireSelf("userId", models.RoleAdmin), userController.UpdateUser)
+			users.PUT("/:userId/role", adminOnly, userController.UpdateUserRole)
 		}
+
+		// Audit log routes
+		api.GET("/audit-logs", adminOnly, auditController.GetAuditLogs)
 	}
 }
 
@@ -238,14 +584,39 @@ func (a *App) Run() error {
 	}
 
 	// Setup routes
-	a.SetupRoutes()
+	if err := a.SetupRoutes(); err != nil {
+		return err
+	}
 
 	// Start server
 	addr := fmt.Sprintf("%s:%s", a.Config.Host, a.Config.Port)
 	log.Printf("Starting Learning Space API server on %s", addr)
 	log.Printf("API endpoints available at http://%s/api", addr)
 
-	return a.Router.Run(addr)
+	server := &http.Server{Addr: addr, Handler: a.Router}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Stop taking requests, then let background similarity checks finish
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Printf("Failed to close open connections: %v", err)
+	}
+	a.similarityService.Stop()
+	return nil
 }
 
 // RunApp is the main entry point for running the application
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/migrations"
+	"github.com/TheApostroff/skill-space/pkg/database"
+)
+
+// MigrateUsage describes the migrate command
+const MigrateUsage = `usage: migrate <command>
+
+commands:
+  up            apply every pending migration
+  down [steps]  revert the last applied migration, or the given number of them
+  status        list the migrations and when they were applied`
+
+var ErrMigrateUsage = errors.New(MigrateUsage)
+
+// RunMigrate runs a migrate command against the configured database and
+// reports what it did to out
+func RunMigrate(cfg *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return ErrMigrateUsage
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			return ErrMigrateUsage
+		}
+	case "down":
+		if len(args) > 2 {
+			return ErrMigrateUsage
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+			steps = n
+		}
+	default:
+		return ErrMigrateUsage
+	}
+
+	db, err := database.NewPostgres(&cfg.Server)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Fprintln(out, "no applied migrations")
+		}
+		return err
+	default:
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/services"
 	"github.com/gin-gonic/gin"
@@ -19,7 +20,7 @@ func NewActivityController(service *services.ActivityService) *ActivityControlle
 func (c *ActivityController) GetCourseSections(ctx *gin.Context) {
 	courseID := ctx.Param("courseId")
 
-	sections, err := c.service.GetSectionsByCourseID(courseID)
+	sections, err := c.service.GetSectionsByCourseID(courseID, middleware.CurrentUser(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -49,7 +50,7 @@ func (c *ActivityController) CreateSection(ctx *gin.Context) {
 		return
 	}
 
-	section, err := c.service.CreateSection(courseID, &req)
+	section, err := c.service.CreateSection(courseID, &req, middleware.AuditSource(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -69,9 +70,9 @@ func (c *ActivityController) CreateSection(ctx *gin.Context) {
 func (c *ActivityController) GetActivity(ctx *gin.Context) {
 	activityID := ctx.Param("activityId")
 
-	activity, err := c.service.GetActivityByID(activityID)
+	activity, err := c.service.GetActivityByID(activityID, middleware.CurrentUser(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusNotFound), models.APIResponse{
 			Success: false,
 			Error:   "Activity not found",
 			Message: "The requested activity could not be found",
@@ -99,7 +100,7 @@ func (c *ActivityController) CreateActivity(ctx *gin.Context) {
 		return
 	}
 
-	activity, err := c.service.CreateActivity(sectionID, &req)
+	activity, err := c.service.CreateActivity(sectionID, &req, middleware.AuditSource(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -129,7 +130,7 @@ func (c *ActivityController) UpdateActivity(ctx *gin.Context) {
 		return
 	}
 
-	activity, err := c.service.UpdateActivity(activityID, &req)
+	activity, err := c.service.UpdateActivity(activityID, &req, middleware.AuditSource(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -149,9 +150,9 @@ func (c *ActivityController) UpdateActivity(ctx *gin.Context) {
 func (c *ActivityController) DeleteActivity(ctx *gin.Context) {
 	activityID := ctx.Param("activityId")
 
-	err := c.service.DeleteActivity(activityID)
+	err := c.service.DeleteActivity(activityID, middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to delete activity",
 			Message: err.Error(),
@@ -164,3 +165,23 @@ func (c *ActivityController) DeleteActivity(ctx *gin.Context) {
 		Message: "Activity deleted successfully",
 	})
 }
+
+func (c *ActivityController) RestoreActivity(ctx *gin.Context) {
+	activityID := ctx.Param("activityId")
+
+	activity, err := c.service.RestoreActivity(activityID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to restore activity",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    activity,
+		Message: "Activity restored successfully",
+	})
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/services"
 	"github.com/gin-gonic/gin"
@@ -17,9 +18,19 @@ func NewAssignmentController(service *services.AssignmentService) *AssignmentCon
 }
 
 func (c *AssignmentController) GetAllAssignments(ctx *gin.Context) {
-	assignments, err := c.service.GetAllAssignments()
+	q, err := parseListQuery(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	assignments, total, err := c.service.GetAllAssignments(q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to retrieve assignments",
 			Message: err.Error(),
@@ -27,10 +38,16 @@ func (c *AssignmentController) GetAllAssignments(ctx *gin.Context) {
 		return
 	}
 
+	viewer := middleware.CurrentUser(ctx)
+	for i := range assignments {
+		c.service.RestrictSubmissions(&assignments[i], viewer)
+	}
+
 	ctx.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    assignments,
 		Message: "Assignments retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
 	})
 }
 
@@ -47,6 +64,8 @@ func (c *AssignmentController) GetAssignmentByID(ctx *gin.Context) {
 		return
 	}
 
+	c.service.RestrictSubmissions(assignment, middleware.CurrentUser(ctx))
+
 	ctx.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    assignment,
@@ -65,12 +84,11 @@ func (c *AssignmentController) CreateAssignment(ctx *gin.Context) {
 		return
 	}
 
-	// In a real implementation, get instructorID from authentication context
-	instructorID := "professor-1"
+	instructor := middleware.CurrentUser(ctx)
 
-	assignment, err := c.service.CreateAssignment(&req, instructorID)
+	assignment, err := c.service.CreateAssignment(&req, instructor, middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to create assignment",
 			Message: err.Error(),
@@ -96,12 +114,11 @@ func (c *AssignmentController) SubmitAssignment(ctx *gin.Context) {
 		return
 	}
 
-	// In a real implementation, get studentID from authentication context
-	studentID := "student-1"
+	student := middleware.CurrentUser(ctx)
 
-	submission, err := c.service.SubmitAssignment(&req, studentID)
+	submission, err := c.service.SubmitAssignment(&req, student.ID, middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to submit assignment",
 			Message: err.Error(),
@@ -127,12 +144,184 @@ func (c *AssignmentController) GradeAssignment(ctx *gin.Context) {
 		return
 	}
 
-	// In a real implementation, get gradedBy from authentication context
-	gradedBy := "professor-1"
+	grader := middleware.CurrentUser(ctx)
+
+	submission, err := c.service.GradeAssignment(&req, grader, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to grade assignment",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    submission,
+		Message: "Assignment graded successfully",
+	})
+}
+
+func (c *AssignmentController) GrantExtension(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	var req models.AssignmentExtensionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	extension, err := c.service.GrantExtension(assignmentID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to grant extension",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    extension,
+		Message: "Extension granted successfully",
+	})
+}
+
+func (c *AssignmentController) GetExtensions(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	extensions, err := c.service.GetExtensions(assignmentID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve extensions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    extensions,
+		Message: "Extensions retrieved successfully",
+	})
+}
+
+// GetSubmissionHistory returns a student's attempts at an assignment. Without
+// a studentId in the path it returns the caller's own attempts.
+func (c *AssignmentController) GetSubmissionHistory(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+	studentID := ctx.Param("studentId")
+	if studentID == "" {
+		studentID = middleware.CurrentUser(ctx).ID
+	}
+
+	history, err := c.service.GetSubmissionHistory(assignmentID, studentID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve submission history",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    history,
+		Message: "Submission history retrieved successfully",
+	})
+}
+
+func (c *AssignmentController) GetRubric(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	rubric, err := c.service.GetRubric(assignmentID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve rubric",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    rubric,
+		Message: "Rubric retrieved successfully",
+	})
+}
+
+func (c *AssignmentController) SetRubric(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	var req models.RubricRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	rubric, err := c.service.SetRubric(assignmentID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to save rubric",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    rubric,
+		Message: "Rubric saved successfully",
+	})
+}
+
+func (c *AssignmentController) DeleteRubric(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	err := c.service.DeleteRubric(assignmentID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to delete rubric",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Rubric deleted successfully",
+	})
+}
+
+func (c *AssignmentController) GradeWithRubric(ctx *gin.Context) {
+	var req models.RubricGradeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
 
-	submission, err := c.service.GradeAssignment(&req, gradedBy)
+	submission, err := c.service.GradeWithRubric(&req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to grade assignment",
 			Message: err.Error(),
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	service *services.AuditService
+}
+
+func NewAuditController(service *services.AuditService) *AuditController {
+	return &AuditController{service: service}
+}
+
+// GetAuditLogs lists the audit trail, newest first unless another order is requested
+func (c *AuditController) GetAuditLogs(ctx *gin.Context) {
+	q, err := parseListQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	if ctx.Query("order") == "" {
+		q.Order = "desc"
+	}
+
+	entries, total, err := c.service.GetAuditLogs(q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve audit log",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entries,
+		Message: "Audit log retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
+	})
+}
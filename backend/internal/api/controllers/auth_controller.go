@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AuthController struct {
+	service *services.AuthService
+}
+
+func NewAuthController(service *services.AuthService) *AuthController {
+	return &AuthController{service: service}
+}
+
+func (c *AuthController) Register(ctx *gin.Context) {
+	var req models.RegisterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	auth, err := c.service.Register(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrEmailTaken) {
+			ctx.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Error:   "Registration failed",
+				Message: err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Registration failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    auth,
+		Message: "Registration successful",
+	})
+}
+
+func (c *AuthController) Login(ctx *gin.Context) {
+	var req models.LoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	auth, err := c.service.Login(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			ctx.JSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Error:   "Login failed",
+				Message: err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Login failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    auth,
+		Message: "Login successful",
+	})
+}
+
+func (c *AuthController) Me(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    middleware.CurrentUser(ctx),
+		Message: "User retrieved successfully",
+	})
+}
+
+// StreamTicket issues a ticket for opening the caller's event stream, which
+// browsers connect to without an Authorization header
+func (c *AuthController) StreamTicket(ctx *gin.Context) {
+	ticket, err := c.service.IssueStreamTicket(middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   "Failed to issue stream ticket",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    ticket,
+		Message: "Stream ticket issued successfully",
+	})
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/services"
 	"github.com/gin-gonic/gin"
@@ -17,9 +18,19 @@ func NewCourseController(service *services.CourseService) *CourseController {
 }
 
 func (c *CourseController) GetAllCourses(ctx *gin.Context) {
-	courses, err := c.service.GetAllCourses()
+	q, err := parseListQuery(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	courses, total, err := c.service.GetAllCourses(q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to retrieve courses",
 			Message: err.Error(),
@@ -31,6 +42,7 @@ func (c *CourseController) GetAllCourses(ctx *gin.Context) {
 		Success: true,
 		Data:    courses,
 		Message: "Courses retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
 	})
 }
 
@@ -65,10 +77,9 @@ func (c *CourseController) CreateCourse(ctx *gin.Context) {
 		return
 	}
 
-	// In a real implementation, get instructorID from authentication context
-	instructorID := "professor-1"
+	instructor := middleware.CurrentUser(ctx)
 
-	course, err := c.service.CreateCourse(&req, instructorID)
+	course, err := c.service.CreateCourse(&req, instructor.ID, middleware.AuditSource(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -98,7 +109,7 @@ func (c *CourseController) UpdateCourse(ctx *gin.Context) {
 		return
 	}
 
-	course, err := c.service.UpdateCourse(courseID, &req)
+	course, err := c.service.UpdateCourse(courseID, &req, middleware.AuditSource(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -118,9 +129,9 @@ func (c *CourseController) UpdateCourse(ctx *gin.Context) {
 func (c *CourseController) DeleteCourse(ctx *gin.Context) {
 	courseID := ctx.Param("courseId")
 
-	err := c.service.DeleteCourse(courseID)
+	err := c.service.DeleteCourse(courseID, middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to delete course",
 			Message: err.Error(),
@@ -133,3 +144,23 @@ func (c *CourseController) DeleteCourse(ctx *gin.Context) {
 		Message: "Course deleted successfully",
 	})
 }
+
+func (c *CourseController) RestoreCourse(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	course, err := c.service.RestoreCourse(courseID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to restore course",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    course,
+		Message: "Course restored successfully",
+	})
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type EnrollmentController struct {
+	service *services.EnrollmentService
+}
+
+func NewEnrollmentController(service *services.EnrollmentService) *EnrollmentController {
+	return &EnrollmentController{service: service}
+}
+
+func (c *EnrollmentController) GetAllEnrollments(ctx *gin.Context) {
+	q, err := parseListQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	enrollments, total, err := c.service.GetAllEnrollments(middleware.CurrentUser(ctx), q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve enrollments",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    enrollments,
+		Message: "Enrollments retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
+	})
+}
+
+func (c *EnrollmentController) CreateEnrollment(ctx *gin.Context) {
+	var req models.EnrollmentCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	enrollment, err := c.service.CreateEnrollment(&req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to create enrollment",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "Enrollment created successfully"
+	if enrollment.Status == models.EnrollmentWaitlisted {
+		message = "Course is full, student added to the waitlist"
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    enrollment,
+		Message: message,
+	})
+}
+
+func (c *EnrollmentController) UpdateEnrollmentStatus(ctx *gin.Context) {
+	enrollmentID := ctx.Param("enrollmentId")
+
+	var req models.EnrollmentStatusUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	enrollment, err := c.service.UpdateEnrollmentStatus(enrollmentID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to update enrollment",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    enrollment,
+		Message: "Enrollment updated successfully",
+	})
+}
+
+func (c *EnrollmentController) GetCourseRoster(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	roster, err := c.service.GetRoster(courseID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve roster",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    roster,
+		Message: "Roster retrieved successfully",
+	})
+}
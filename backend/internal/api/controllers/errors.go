@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/TheApostroff/skill-space/internal/qti"
+	"github.com/TheApostroff/skill-space/internal/sandbox"
+	"github.com/TheApostroff/skill-space/internal/storage"
+	"github.com/TheApostroff/skill-space/internal/taskgen"
+	"gorm.io/gorm"
+)
+
+// statusFor maps well-known service errors to HTTP status codes, falling back
+// to the given status for anything else
+func statusFor(err error, fallback int) int {
+	switch {
+	case errors.Is(err, services.ErrForbidden), errors.Is(err, services.ErrNotEnrolled),
+		errors.Is(err, services.ErrInvalidDownloadLink), errors.Is(err, services.ErrNotAnnouncer):
+		return http.StatusForbidden
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrInvalidSort), errors.Is(err, repositories.ErrInvalidFilter),
+		errors.Is(err, services.ErrInvalidDueDate), errors.Is(err, services.ErrInvalidDateFilter),
+		errors.Is(err, services.ErrUnknownTopic), errors.Is(err, services.ErrStudentNotEnrolled):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrTaskHasNoTests), errors.Is(err, sandbox.ErrUnsupportedLanguage),
+		errors.Is(err, services.ErrScoreOutOfRange), errors.Is(err, services.ErrInvalidGradingScheme),
+		errors.Is(err, services.ErrInvalidAttachment), errors.Is(err, services.ErrSubmissionClosed),
+		errors.Is(err, services.ErrNoAttemptsLeft), errors.Is(err, services.ErrInvalidQuestion),
+		errors.Is(err, services.ErrQuizHasNoQuestions), errors.Is(err, qti.ErrInvalidPackage),
+		errors.Is(err, services.ErrInvalidRubric), errors.Is(err, services.ErrPeerReviewDisabled),
+		errors.Is(err, services.ErrPeerReviewClosed), errors.Is(err, gorm.ErrForeignKeyViolated),
+		errors.Is(err, services.ErrUnknownNotification), errors.Is(err, services.ErrInvalidParticipants),
+		errors.Is(err, services.ErrNotAForum), errors.Is(err, services.ErrNotAThread),
+		errors.Is(err, services.ErrNotQAForum), errors.Is(err, services.ErrNotAReply),
+		errors.Is(err, services.ErrVoteOwnPost), errors.Is(err, services.ErrNotGenerativeTask),
+		errors.Is(err, services.ErrNotAStudent):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrAlreadyEnrolled), errors.Is(err, services.ErrCourseNotOpen),
+		errors.Is(err, services.ErrCourseFull), errors.Is(err, services.ErrInvalidTransition),
+		errors.Is(err, services.ErrAttemptClosed), errors.Is(err, services.ErrPeerReviewNotOpen),
+		errors.Is(err, services.ErrPeerReviewsAssigned), errors.Is(err, services.ErrParentDeleted),
+		errors.Is(err, repositories.ErrNotDeleted), errors.Is(err, gorm.ErrDuplicatedKey),
+		errors.Is(err, services.ErrThreadLocked):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrUnsupportedFileType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrGenerationLimit):
+		return http.StatusTooManyRequests
+	case errors.Is(err, taskgen.ErrInvalidTask):
+		return http.StatusBadGateway
+	case errors.Is(err, sandbox.ErrUnavailable), errors.Is(err, services.ErrSimilarityBusy):
+		return http.StatusServiceUnavailable
+	default:
+		return fallback
+	}
+}
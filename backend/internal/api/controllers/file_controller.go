@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead leaves room for form fields and part headers on top of
+// the file itself
+const multipartOverhead = 1 << 20
+
+type FileController struct {
+	service *services.FileService
+}
+
+func NewFileController(service *services.FileService) *FileController {
+	return &FileController{service: service}
+}
+
+func (c *FileController) UploadAttachment(ctx *gin.Context) {
+	file, req, ok := c.bindUpload(ctx)
+	if !ok {
+		return
+	}
+
+	attachment, err := c.service.UploadAttachment(file, req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to upload file",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    attachment,
+		Message: "File uploaded successfully",
+	})
+}
+
+func (c *FileController) UploadResource(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	file, req, ok := c.bindUpload(ctx)
+	if !ok {
+		return
+	}
+
+	resource, err := c.service.UploadResource(courseID, file, req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to upload resource",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    resource,
+		Message: "Resource uploaded successfully",
+	})
+}
+
+func (c *FileController) GetAttachmentLink(ctx *gin.Context) {
+	attachmentID := ctx.Param("attachmentId")
+
+	link, err := c.service.GetAttachmentLink(attachmentID, middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to create download link",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    link,
+		Message: "Download link created successfully",
+	})
+}
+
+func (c *FileController) GetResourceLink(ctx *gin.Context) {
+	resourceID := ctx.Param("resourceId")
+
+	link, err := c.service.GetResourceLink(resourceID, middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to create download link",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    link,
+		Message: "Download link created successfully",
+	})
+}
+
+// DownloadFile streams a stored file to holders of a valid signed link
+func (c *FileController) DownloadFile(ctx *gin.Context) {
+	file, err := c.service.OpenSigned(ctx.Param("kind"), ctx.Param("fileId"), ctx.Query("expires"), ctx.Query("signature"))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to download file",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	sendFile(ctx, file)
+}
+
+// sendFile streams the file to the client as a download and closes it
+func sendFile(ctx *gin.Context, file *services.StoredFile) {
+	defer file.Body.Close()
+
+	ctx.Header("Content-Type", file.MimeType)
+	ctx.Header("Content-Length", strconv.FormatInt(file.Size, 10))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Status(http.StatusOK)
+	io.Copy(ctx.Writer, file.Body)
+}
+
+// bindUpload reads the "file" part and form fields of a multipart upload,
+// writing an error response and returning false if they are missing or too
+// large
+func (c *FileController) bindUpload(ctx *gin.Context) (*multipart.FileHeader, *models.UploadRequest, bool) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.service.MaxUploadSize()+multipartOverhead)
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, models.APIResponse{
+				Success: false,
+				Error:   "Failed to upload file",
+				Message: services.ErrFileTooLarge.Error(),
+			})
+			return nil, nil, false
+		}
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "A file is required in the \"file\" form field",
+		})
+		return nil, nil, false
+	}
+
+	var req models.UploadRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return nil, nil, false
+	}
+
+	return file, &req, true
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/services"
 	"github.com/gin-gonic/gin"
@@ -27,9 +28,11 @@ func (c *GenerativeTaskController) GenerateTask(ctx *gin.Context) {
 		return
 	}
 
-	task, err := c.service.GenerateTask(&req)
+	req.StudentID = middleware.CurrentUser(ctx).ID
+
+	task, err := c.service.GenerateTask(ctx.Request.Context(), &req, middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to generate task",
 			Message: err.Error(),
@@ -55,9 +58,11 @@ func (c *GenerativeTaskController) SubmitTask(ctx *gin.Context) {
 		return
 	}
 
-	submission, err := c.service.SubmitTask(&req)
+	req.StudentID = middleware.CurrentUser(ctx).ID
+
+	submission, err := c.service.SubmitTask(ctx.Request.Context(), &req, middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to submit task",
 			Message: err.Error(),
@@ -74,14 +79,13 @@ func (c *GenerativeTaskController) SubmitTask(ctx *gin.Context) {
 
 func (c *GenerativeTaskController) GetTaskHints(ctx *gin.Context) {
 	taskID := ctx.Param("taskId")
-	difficulty := ctx.Query("difficulty")
 
-	hints, err := c.service.GetTaskHints(taskID, difficulty)
+	hints, err := c.service.GetTaskHints(taskID, middleware.CurrentUser(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
-			Error:   "Task not found",
-			Message: "The requested task could not be found",
+			Error:   "Failed to retrieve hints",
+			Message: err.Error(),
 		})
 		return
 	}
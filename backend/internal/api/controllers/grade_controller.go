@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/services"
 	"github.com/gin-gonic/gin"
@@ -17,9 +18,19 @@ func NewGradeController(service *services.GradeService) *GradeController {
 }
 
 func (c *GradeController) GetAllGrades(ctx *gin.Context) {
-	grades, err := c.service.GetAllGrades()
+	q, err := parseListQuery(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	grades, total, err := c.service.GetAllGrades(middleware.CurrentUser(ctx), q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to retrieve grades",
 			Message: err.Error(),
@@ -31,15 +42,26 @@ func (c *GradeController) GetAllGrades(ctx *gin.Context) {
 		Success: true,
 		Data:    grades,
 		Message: "Grades retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
 	})
 }
 
 func (c *GradeController) GetGradesByStudentID(ctx *gin.Context) {
 	studentID := ctx.Param("studentId")
 
-	grades, err := c.service.GetGradesByStudentID(studentID)
+	q, err := parseListQuery(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	grades, total, err := c.service.GetGradesByStudentID(studentID, middleware.CurrentUser(ctx), q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to retrieve grades",
 			Message: err.Error(),
@@ -51,15 +73,18 @@ func (c *GradeController) GetGradesByStudentID(ctx *gin.Context) {
 		Success: true,
 		Data:    grades,
 		Message: "Student grades retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
 	})
 }
 
-func (c *GradeController) GetAllEnrollments(ctx *gin.Context) {
-	enrollments, err := c.service.GetAllEnrollments()
+func (c *GradeController) GetGradingScheme(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	scheme, err := c.service.GetGradingScheme(courseID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
-			Error:   "Failed to retrieve enrollments",
+			Error:   "Failed to retrieve grading scheme",
 			Message: err.Error(),
 		})
 		return
@@ -67,13 +92,15 @@ func (c *GradeController) GetAllEnrollments(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    enrollments,
-		Message: "Enrollments retrieved successfully",
+		Data:    scheme,
+		Message: "Grading scheme retrieved successfully",
 	})
 }
 
-func (c *GradeController) CreateEnrollment(ctx *gin.Context) {
-	var req models.EnrollmentCreateRequest
+func (c *GradeController) UpdateGradingScheme(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	var req models.GradingSchemeUpdateRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
@@ -83,19 +110,63 @@ func (c *GradeController) CreateEnrollment(ctx *gin.Context) {
 		return
 	}
 
-	enrollment, err := c.service.CreateEnrollment(&req)
+	scheme, err := c.service.UpdateGradingScheme(courseID, &req, middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
-			Error:   "Failed to create enrollment",
+			Error:   "Failed to update grading scheme",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, models.APIResponse{
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    scheme,
+		Message: "Grading scheme updated successfully",
+	})
+}
+
+func (c *GradeController) GetCourseGrades(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	courseGrades, err := c.service.GetCourseGrades(courseID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to compute course grades",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    courseGrades,
+		Message: "Course grades computed successfully",
+	})
+}
+
+func (c *GradeController) GetCourseGrade(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+	studentID := ctx.Param("studentId")
+	if studentID == "" {
+		studentID = middleware.CurrentUser(ctx).ID
+	}
+
+	courseGrade, err := c.service.GetCourseGrade(courseID, studentID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to compute course grade",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    enrollment,
-		Message: "Enrollment created successfully",
+		Data:    courseGrade,
+		Message: "Course grade computed successfully",
 	})
 }
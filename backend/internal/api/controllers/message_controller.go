@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type MessageController struct {
+	service *services.MessageService
+}
+
+func NewMessageController(service *services.MessageService) *MessageController {
+	return &MessageController{service: service}
+}
+
+// GetConversations lists the caller's conversations, most recently active first
+// unless another order is requested
+func (c *MessageController) GetConversations(ctx *gin.Context) {
+	q, err := parseListQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	if ctx.Query("order") == "" {
+		q.Order = "desc"
+	}
+
+	conversations, total, err := c.service.GetConversations(middleware.CurrentUser(ctx), q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve conversations",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    conversations,
+		Message: "Conversations retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
+	})
+}
+
+func (c *MessageController) CreateConversation(ctx *gin.Context) {
+	var req models.ConversationCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	conversation, err := c.service.CreateConversation(&req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to create conversation",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    conversation,
+		Message: "Conversation created successfully",
+	})
+}
+
+func (c *MessageController) GetConversation(ctx *gin.Context) {
+	conversationID := ctx.Param("conversationId")
+
+	conversation, err := c.service.GetConversation(conversationID, middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve conversation",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    conversation,
+		Message: "Conversation retrieved successfully",
+	})
+}
+
+func (c *MessageController) GetUnreadCount(ctx *gin.Context) {
+	count, err := c.service.CountUnread(middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to count unread messages",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    count,
+		Message: "Unread messages counted successfully",
+	})
+}
+
+// GetMessages lists a conversation's messages, newest first unless another
+// order is requested
+func (c *MessageController) GetMessages(ctx *gin.Context) {
+	conversationID := ctx.Param("conversationId")
+
+	q, err := parseListQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	if ctx.Query("order") == "" {
+		q.Order = "desc"
+	}
+
+	messages, total, err := c.service.GetMessages(conversationID, middleware.CurrentUser(ctx), q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve messages",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    messages,
+		Message: "Messages retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
+	})
+}
+
+func (c *MessageController) SendMessage(ctx *gin.Context) {
+	conversationID := ctx.Param("conversationId")
+
+	var req models.MessageCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	message, err := c.service.SendMessage(conversationID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to send message",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    message,
+		Message: "Message sent successfully",
+	})
+}
+
+func (c *MessageController) MarkRead(ctx *gin.Context) {
+	conversationID := ctx.Param("conversationId")
+
+	conversation, err := c.service.MarkRead(conversationID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to mark conversation read",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    conversation,
+		Message: "Conversation marked read successfully",
+	})
+}
+
+func (c *MessageController) PostAnnouncement(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	var req models.MessageCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	message, err := c.service.PostAnnouncement(courseID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to post announcement",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    message,
+		Message: "Announcement posted successfully",
+	})
+}
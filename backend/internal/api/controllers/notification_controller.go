@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	service *services.NotificationService
+}
+
+func NewNotificationController(service *services.NotificationService) *NotificationController {
+	return &NotificationController{service: service}
+}
+
+// GetNotifications lists the caller's inbox, newest first unless another order is requested
+func (c *NotificationController) GetNotifications(ctx *gin.Context) {
+	q, err := parseListQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	if ctx.Query("order") == "" {
+		q.Order = "desc"
+	}
+
+	notifications, total, err := c.service.GetInbox(middleware.CurrentUser(ctx).ID, q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve notifications",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    notifications,
+		Message: "Notifications retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
+	})
+}
+
+func (c *NotificationController) GetUnreadCount(ctx *gin.Context) {
+	count, err := c.service.CountUnread(middleware.CurrentUser(ctx).ID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to count unread notifications",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    count,
+		Message: "Unread notifications counted successfully",
+	})
+}
+
+func (c *NotificationController) MarkRead(ctx *gin.Context) {
+	notificationID := ctx.Param("notificationId")
+
+	notification, err := c.service.MarkRead(middleware.CurrentUser(ctx).ID, notificationID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to mark notification read",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    notification,
+		Message: "Notification marked read successfully",
+	})
+}
+
+func (c *NotificationController) MarkAllRead(ctx *gin.Context) {
+	count, err := c.service.MarkAllRead(middleware.CurrentUser(ctx).ID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to mark notifications read",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    count,
+		Message: "Notifications marked read successfully",
+	})
+}
+
+func (c *NotificationController) GetPreferences(ctx *gin.Context) {
+	preferences, err := c.service.GetPreferences(middleware.CurrentUser(ctx).ID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve notification preferences",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    preferences,
+		Message: "Notification preferences retrieved successfully",
+	})
+}
+
+func (c *NotificationController) UpdatePreferences(ctx *gin.Context) {
+	var req models.NotificationPreferencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	preferences, err := c.service.UpdatePreferences(middleware.CurrentUser(ctx).ID, &req, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to update notification preferences",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    preferences,
+		Message: "Notification preferences updated successfully",
+	})
+}
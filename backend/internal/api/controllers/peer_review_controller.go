@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PeerReviewController struct {
+	service *services.PeerReviewService
+}
+
+func NewPeerReviewController(service *services.PeerReviewService) *PeerReviewController {
+	return &PeerReviewController{service: service}
+}
+
+func (c *PeerReviewController) AssignReviewers(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	reviews, err := c.service.AssignReviewers(assignmentID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to assign peer reviewers",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    reviews,
+		Message: "Peer reviewers assigned successfully",
+	})
+}
+
+func (c *PeerReviewController) GetSummaries(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	summaries, err := c.service.GetSummaries(assignmentID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve peer reviews",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    summaries,
+		Message: "Peer reviews retrieved successfully",
+	})
+}
+
+func (c *PeerReviewController) ApplyPeerScores(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	summaries, err := c.service.ApplyPeerScores(assignmentID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to apply peer scores",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    summaries,
+		Message: "Peer scores applied successfully",
+	})
+}
+
+func (c *PeerReviewController) GetAssignedReviews(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	tasks, err := c.service.GetAssignedReviews(assignmentID, middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve assigned peer reviews",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    tasks,
+		Message: "Assigned peer reviews retrieved successfully",
+	})
+}
+
+func (c *PeerReviewController) GetReceivedReviews(ctx *gin.Context) {
+	assignmentID := ctx.Param("assignmentId")
+
+	reviews, err := c.service.GetReceivedReviews(assignmentID, middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve received peer reviews",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    reviews,
+		Message: "Received peer reviews retrieved successfully",
+	})
+}
+
+func (c *PeerReviewController) SubmitReview(ctx *gin.Context) {
+	reviewID := ctx.Param("reviewId")
+
+	var req models.PeerReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	review, err := c.service.SubmitReview(reviewID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to submit peer review",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    review,
+		Message: "Peer review submitted successfully",
+	})
+}
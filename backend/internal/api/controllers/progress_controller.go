@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ProgressController struct {
+	service *services.ProgressService
+}
+
+func NewProgressController(service *services.ProgressService) *ProgressController {
+	return &ProgressController{service: service}
+}
+
+func (c *ProgressController) CompleteActivity(ctx *gin.Context) {
+	activityID := ctx.Param("activityId")
+
+	progress, err := c.service.CompleteActivity(activityID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to complete activity",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Activity marked as completed",
+	})
+}
+
+func (c *ProgressController) UncompleteActivity(ctx *gin.Context) {
+	activityID := ctx.Param("activityId")
+
+	progress, err := c.service.UncompleteActivity(activityID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to update activity",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Activity marked as not completed",
+	})
+}
+
+func (c *ProgressController) GetCourseProgressForAll(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	progress, err := c.service.GetCourseProgressForAll(courseID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve course progress",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Course progress retrieved successfully",
+	})
+}
+
+// GetCourseProgress returns one student's progress. Without a studentId in
+// the path it returns the caller's own progress.
+func (c *ProgressController) GetCourseProgress(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+	studentID := ctx.Param("studentId")
+	if studentID == "" {
+		studentID = middleware.CurrentUser(ctx).ID
+	}
+
+	progress, err := c.service.GetCourseProgress(courseID, studentID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve course progress",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Course progress retrieved successfully",
+	})
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parseListQuery reads page, limit, sort and order from the query string and
+// treats every other query parameter as a field filter
+func parseListQuery(ctx *gin.Context) (*models.ListQuery, error) {
+	q := &models.ListQuery{
+		Page:    1,
+		Limit:   defaultPageLimit,
+		Sort:    ctx.Query("sort"),
+		Order:   ctx.DefaultQuery("order", "asc"),
+		Filters: map[string]string{},
+	}
+
+	if page := ctx.Query("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			return nil, fmt.Errorf("page must be a positive integer")
+		}
+		q.Page = value
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxPageLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		q.Limit = value
+	}
+
+	if q.Order != "asc" && q.Order != "desc" {
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	for key, values := range ctx.Request.URL.Query() {
+		switch key {
+		case "page", "limit", "sort", "order":
+			continue
+		}
+		if len(values) > 0 {
+			q.Filters[key] = values[0]
+		}
+	}
+
+	return q, nil
+}
+
+// pageMeta builds the pagination metadata, with links to the neighbouring pages
+func pageMeta(ctx *gin.Context, q *models.ListQuery, total int64) *models.PageMeta {
+	totalPages := int((total + int64(q.Limit) - 1) / int64(q.Limit))
+	meta := &models.PageMeta{
+		Page:       q.Page,
+		Limit:      q.Limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	if q.Page < totalPages {
+		meta.NextPage = pageLink(ctx.Request.URL, q.Page+1)
+	}
+	if q.Page > 1 {
+		meta.PrevPage = pageLink(ctx.Request.URL, q.Page-1)
+	}
+
+	return meta
+}
+
+func pageLink(current *url.URL, page int) string {
+	query := current.Query()
+	query.Set("page", strconv.Itoa(page))
+	link := url.URL{Path: current.Path, RawQuery: query.Encode()}
+	return link.String()
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+func testContext(target string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", target, nil)
+	return ctx
+}
+
+func TestParseListQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    models.ListQuery
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  models.ListQuery{Page: 1, Limit: defaultPageLimit, Order: "asc", Filters: map[string]string{}},
+		},
+		{
+			name:  "page, limit, sort and order",
+			query: "page=3&limit=50&sort=title&order=desc",
+			want:  models.ListQuery{Page: 3, Limit: 50, Sort: "title", Order: "desc", Filters: map[string]string{}},
+		},
+		{
+			name:  "other parameters are filters",
+			query: "status=published&category=math&category=art&level=",
+			want: models.ListQuery{Page: 1, Limit: defaultPageLimit, Order: "asc", Filters: map[string]string{
+				"status":   "published",
+				"category": "math",
+				"level":    "",
+			}},
+		},
+		{name: "page is not a number", query: "page=two", wantErr: true},
+		{name: "page below one", query: "page=0", wantErr: true},
+		{name: "limit below one", query: "limit=0", wantErr: true},
+		{name: "limit above the maximum", query: "limit=101", wantErr: true},
+		{name: "unknown order", query: "order=up", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseListQuery(testContext("/api/courses?" + tt.query))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("query = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseListQuery: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("query = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageMeta(t *testing.T) {
+	tests := []struct {
+		name  string
+		page  int
+		limit int
+		total int64
+		want  models.PageMeta
+	}{
+		{
+			name:  "no results",
+			page:  1,
+			limit: 20,
+			want:  models.PageMeta{Page: 1, Limit: 20},
+		},
+		{
+			name:  "single page",
+			page:  1,
+			limit: 20,
+			total: 20,
+			want:  models.PageMeta{Page: 1, Limit: 20, Total: 20, TotalPages: 1},
+		},
+		{
+			name:  "first of several",
+			page:  1,
+			limit: 20,
+			total: 41,
+			want: models.PageMeta{Page: 1, Limit: 20, Total: 41, TotalPages: 3,
+				NextPage: "/api/courses?limit=20&page=2&status=published"},
+		},
+		{
+			name:  "in the middle",
+			page:  2,
+			limit: 20,
+			total: 41,
+			want: models.PageMeta{Page: 2, Limit: 20, Total: 41, TotalPages: 3,
+				NextPage: "/api/courses?limit=20&page=3&status=published",
+				PrevPage: "/api/courses?limit=20&page=1&status=published"},
+		},
+		{
+			name:  "last",
+			page:  3,
+			limit: 20,
+			total: 41,
+			want: models.PageMeta{Page: 3, Limit: 20, Total: 41, TotalPages: 3,
+				PrevPage: "/api/courses?limit=20&page=2&status=published"},
+		},
+		{
+			name:  "past the last",
+			page:  5,
+			limit: 20,
+			total: 41,
+			want: models.PageMeta{Page: 5, Limit: 20, Total: 41, TotalPages: 3,
+				PrevPage: "/api/courses?limit=20&page=4&status=published"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := testContext("/api/courses?status=published&limit=20&page=1")
+			got := pageMeta(ctx, &models.ListQuery{Page: tt.page, Limit: tt.limit}, tt.total)
+			if *got != tt.want {
+				t.Errorf("meta = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxQTIPackageSize bounds the size of an uploaded QTI package
+const maxQTIPackageSize = 32 << 20
+
+type QuizController struct {
+	service *services.QuizService
+}
+
+func NewQuizController(service *services.QuizService) *QuizController {
+	return &QuizController{service: service}
+}
+
+func (c *QuizController) GetQuestions(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	q, err := parseListQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	questions, total, err := c.service.GetQuestions(courseID, q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve questions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    questions,
+		Message: "Questions retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
+	})
+}
+
+func (c *QuizController) CreateQuestion(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	var req models.QuestionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	question, err := c.service.CreateQuestion(courseID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to create question",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    question,
+		Message: "Question created successfully",
+	})
+}
+
+func (c *QuizController) UpdateQuestion(ctx *gin.Context) {
+	questionID := ctx.Param("questionId")
+
+	var req models.QuestionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	question, err := c.service.UpdateQuestion(questionID, &req, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to update question",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    question,
+		Message: "Question updated successfully",
+	})
+}
+
+func (c *QuizController) DeleteQuestion(ctx *gin.Context) {
+	questionID := ctx.Param("questionId")
+
+	err := c.service.DeleteQuestion(questionID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to delete question",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Question deleted successfully",
+	})
+}
+
+func (c *QuizController) GetCourseQuizzes(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	quizzes, err := c.service.GetCourseQuizzes(courseID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve quizzes",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    quizzes,
+		Message: "Quizzes retrieved successfully",
+	})
+}
+
+func (c *QuizController) CreateQuiz(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	var req models.QuizCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	quiz, err := c.service.CreateQuiz(courseID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to create quiz",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    quiz,
+		Message: "Quiz created successfully",
+	})
+}
+
+func (c *QuizController) GetQuiz(ctx *gin.Context) {
+	quizID := ctx.Param("quizId")
+
+	quiz, err := c.service.GetQuiz(quizID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Quiz not found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    quiz,
+		Message: "Quiz retrieved successfully",
+	})
+}
+
+func (c *QuizController) StartAttempt(ctx *gin.Context) {
+	quizID := ctx.Param("quizId")
+
+	attempt, err := c.service.StartAttempt(quizID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to start quiz attempt",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    attempt,
+		Message: "Quiz attempt started successfully",
+	})
+}
+
+func (c *QuizController) GetAttempt(ctx *gin.Context) {
+	attemptID := ctx.Param("attemptId")
+
+	attempt, err := c.service.GetAttempt(attemptID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve quiz attempt",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    attempt,
+		Message: "Quiz attempt retrieved successfully",
+	})
+}
+
+func (c *QuizController) SaveResponses(ctx *gin.Context) {
+	attemptID := ctx.Param("attemptId")
+
+	var req models.QuizResponsesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	attempt, err := c.service.SaveResponses(attemptID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to save answers",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    attempt,
+		Message: "Answers saved successfully",
+	})
+}
+
+func (c *QuizController) SubmitAttempt(ctx *gin.Context) {
+	attemptID := ctx.Param("attemptId")
+
+	var req models.QuizResponsesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	attempt, err := c.service.SubmitAttempt(attemptID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to submit quiz attempt",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    attempt,
+		Message: "Quiz attempt submitted successfully",
+	})
+}
+
+// ImportQTI imports a QTI 2.1 package uploaded in the "file" form field as a
+// quiz activity of the section
+func (c *QuizController) ImportQTI(ctx *gin.Context) {
+	sectionID := ctx.Param("sectionId")
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxQTIPackageSize+multipartOverhead)
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, models.APIResponse{
+				Success: false,
+				Error:   "Failed to import QTI package",
+				Message: services.ErrFileTooLarge.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "A QTI package is required in the \"file\" form field",
+		})
+		return
+	}
+
+	var req models.QTIImportRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Failed to import QTI package",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := c.service.ImportQTI(sectionID, file, header.Size, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to import QTI package",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if result.Activity == nil {
+		ctx.JSON(http.StatusUnprocessableEntity, models.APIResponse{
+			Success: false,
+			Data:    result,
+			Error:   "Failed to import QTI package",
+			Message: "The package has no supported items",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    result,
+		Message: "QTI package imported successfully",
+	})
+}
+
+func (c *QuizController) ExportActivityQTI(ctx *gin.Context) {
+	activityID := ctx.Param("activityId")
+
+	file, err := c.service.ExportActivityQTI(activityID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to export QTI package",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	sendFile(ctx, file)
+}
+
+func (c *QuizController) ExportCourseQTI(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	file, err := c.service.ExportCourseQTI(courseID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to export QTI package",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	sendFile(ctx, file)
+}
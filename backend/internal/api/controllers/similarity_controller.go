@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SimilarityController struct {
+	service *services.SimilarityService
+}
+
+func NewSimilarityController(service *services.SimilarityService) *SimilarityController {
+	return &SimilarityController{service: service}
+}
+
+func (c *SimilarityController) GetSubmissionReport(ctx *gin.Context) {
+	c.getReport(ctx, models.SimilarityKindSubmission)
+}
+
+func (c *SimilarityController) RecheckSubmission(ctx *gin.Context) {
+	c.recheck(ctx, models.SimilarityKindSubmission)
+}
+
+func (c *SimilarityController) GetTaskSubmissionReport(ctx *gin.Context) {
+	c.getReport(ctx, models.SimilarityKindGenerativeTask)
+}
+
+func (c *SimilarityController) RecheckTaskSubmission(ctx *gin.Context) {
+	c.recheck(ctx, models.SimilarityKindGenerativeTask)
+}
+
+func (c *SimilarityController) getReport(ctx *gin.Context, kind string) {
+	submissionID := ctx.Param("submissionId")
+
+	report, err := c.service.GetReport(kind, submissionID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve similarity report",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+		Message: "Similarity report retrieved successfully",
+	})
+}
+
+func (c *SimilarityController) recheck(ctx *gin.Context, kind string) {
+	submissionID := ctx.Param("submissionId")
+
+	check, err := c.service.Recheck(kind, submissionID, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to start similarity check",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    check,
+		Message: "Similarity check started successfully",
+	})
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// heartbeatInterval is how often an idle stream sends a comment, so proxies
+// keep the connection open
+const heartbeatInterval = 25 * time.Second
+
+type StreamController struct {
+	service *services.StreamService
+}
+
+func NewStreamController(service *services.StreamService) *StreamController {
+	return &StreamController{service: service}
+}
+
+// Stream sends the events of the caller's own user topic and of the topics
+// named in the comma-separated "topics" parameter as server-sent events,
+// until the client disconnects. When one of the caller's enrollments changes,
+// the topics are authorized again and the stream is closed with a "closed"
+// event if the caller may no longer follow one of them.
+func (c *StreamController) Stream(ctx *gin.Context) {
+	topics := []string{}
+	if value := ctx.Query("topics"); value != "" {
+		topics = strings.Split(value, ",")
+	}
+
+	viewer := middleware.CurrentUser(ctx)
+	subscription, err := c.service.Open(topics, viewer)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to open event stream",
+			Message: err.Error(),
+		})
+		return
+	}
+	defer subscription.Close()
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.SSEvent("subscribed", map[string][]string{"topics": subscription.Topics()})
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case msg, ok := <-subscription.Messages():
+			if !ok {
+				return false
+			}
+			ctx.SSEvent(msg.Type, msg)
+			if msg.Type != models.EventEnrollmentChanged {
+				return true
+			}
+			err := c.service.Recheck(subscription, viewer)
+			if err != nil {
+				ctx.SSEvent("closed", map[string]string{"message": err.Error()})
+				return false
+			}
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TrashController struct {
+	service *services.TrashService
+}
+
+func NewTrashController(service *services.TrashService) *TrashController {
+	return &TrashController{service: service}
+}
+
+func (c *TrashController) GetDeletedCourses(ctx *gin.Context) {
+	courses, err := c.service.GetDeletedCourses(middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve deleted courses",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    courses,
+		Message: "Deleted courses retrieved successfully",
+	})
+}
+
+func (c *TrashController) GetCourseTrash(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	trash, err := c.service.GetCourseTrash(courseID)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve course trash",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    trash,
+		Message: "Course trash retrieved successfully",
+	})
+}
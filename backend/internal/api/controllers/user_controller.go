@@ -3,6 +3,7 @@ package controllers
 import (
 	"net/http"
 
+	"github.com/TheApostroff/skill-space/internal/api/middleware"
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/services"
 	"github.com/gin-gonic/gin"
@@ -16,6 +17,7 @@ func NewForumController(service *services.ForumService) *ForumController {
 	return &ForumController{service: service}
 }
 
+// GetForumPosts lists a forum's threads with their replies nested at every depth
 func (c *ForumController) GetForumPosts(ctx *gin.Context) {
 	forumID := ctx.Query("forumId")
 	if forumID == "" {
@@ -27,9 +29,19 @@ func (c *ForumController) GetForumPosts(ctx *gin.Context) {
 		return
 	}
 
-	posts, err := c.service.GetForumPosts(forumID)
+	q, err := parseListQuery(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	posts, total, err := c.service.GetForumPosts(forumID, middleware.CurrentUser(ctx), q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to retrieve forum posts",
 			Message: err.Error(),
@@ -41,6 +53,7 @@ func (c *ForumController) GetForumPosts(ctx *gin.Context) {
 		Success: true,
 		Data:    posts,
 		Message: "Forum posts retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
 	})
 }
 
@@ -55,9 +68,9 @@ func (c *ForumController) CreatePost(ctx *gin.Context) {
 		return
 	}
 
-	post, err := c.service.CreatePost(&req)
+	post, err := c.service.CreatePost(&req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to create post",
 			Message: err.Error(),
@@ -83,9 +96,9 @@ func (c *ForumController) CreateReply(ctx *gin.Context) {
 		return
 	}
 
-	reply, err := c.service.CreateReply(&req)
+	reply, err := c.service.CreateReply(&req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to create reply",
 			Message: err.Error(),
@@ -100,6 +113,264 @@ func (c *ForumController) CreateReply(ctx *gin.Context) {
 	})
 }
 
+func (c *ForumController) GetPost(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+
+	post, err := c.service.GetPost(postID, middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve post",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    post,
+		Message: "Forum post retrieved successfully",
+	})
+}
+
+func (c *ForumController) UpdatePost(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+
+	var req models.ForumPostUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	post, err := c.service.UpdatePost(postID, &req, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to update post",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    post,
+		Message: "Forum post updated successfully",
+	})
+}
+
+func (c *ForumController) GetRevisions(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+
+	revisions, err := c.service.GetRevisions(postID, middleware.CurrentUser(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve post history",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    revisions,
+		Message: "Post history retrieved successfully",
+	})
+}
+
+// PinPost pins a thread on POST and unpins it on DELETE
+func (c *ForumController) PinPost(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+	pinned := ctx.Request.Method != http.MethodDelete
+
+	post, err := c.service.SetPinned(postID, pinned, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to pin post",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "Thread pinned successfully"
+	if !pinned {
+		message = "Thread unpinned successfully"
+	}
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    post,
+		Message: message,
+	})
+}
+
+// LockPost locks a thread on POST and unlocks it on DELETE
+func (c *ForumController) LockPost(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+	locked := ctx.Request.Method != http.MethodDelete
+
+	post, err := c.service.SetLocked(postID, locked, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to lock post",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message := "Thread locked successfully"
+	if !locked {
+		message = "Thread unlocked successfully"
+	}
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    post,
+		Message: message,
+	})
+}
+
+// AcceptAnswer accepts a reply as its thread's answer on POST and takes the
+// acceptance back on DELETE
+func (c *ForumController) AcceptAnswer(ctx *gin.Context) {
+	replyID := ctx.Param("postId")
+	viewer := middleware.CurrentUser(ctx)
+
+	var thread *models.ForumPost
+	var err error
+	message := "Answer accepted successfully"
+	if ctx.Request.Method == http.MethodDelete {
+		thread, err = c.service.UnacceptAnswer(replyID, viewer, middleware.AuditSource(ctx))
+		message = "Answer unaccepted successfully"
+	} else {
+		thread, err = c.service.AcceptAnswer(replyID, viewer, middleware.AuditSource(ctx))
+	}
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to accept answer",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    thread,
+		Message: message,
+	})
+}
+
+// VotePost upvotes a post on POST and takes the upvote back on DELETE
+func (c *ForumController) VotePost(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+	viewer := middleware.CurrentUser(ctx)
+
+	var post *models.ForumPost
+	var err error
+	message := "Post upvoted successfully"
+	if ctx.Request.Method == http.MethodDelete {
+		post, err = c.service.Unvote(postID, viewer, middleware.AuditSource(ctx))
+		message = "Vote removed successfully"
+	} else {
+		post, err = c.service.Vote(postID, viewer, middleware.AuditSource(ctx))
+	}
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to vote",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    post,
+		Message: message,
+	})
+}
+
+// GetUnansweredQuestions lists the threads of a course's Q&A forums that have
+// no accepted answer, newest first unless another order is asked for
+func (c *ForumController) GetUnansweredQuestions(ctx *gin.Context) {
+	courseID := ctx.Param("courseId")
+
+	q, err := parseListQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	if ctx.Query("order") == "" {
+		q.Order = "desc"
+	}
+
+	posts, total, err := c.service.GetUnansweredQuestions(courseID, middleware.CurrentUser(ctx), q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to retrieve unanswered questions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    posts,
+		Message: "Unanswered questions retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
+	})
+}
+
+func (c *ForumController) DeletePost(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+
+	err := c.service.DeletePost(postID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to delete post",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Post deleted successfully",
+	})
+}
+
+func (c *ForumController) RestorePost(ctx *gin.Context) {
+	postID := ctx.Param("postId")
+
+	post, err := c.service.RestorePost(postID, middleware.CurrentUser(ctx), middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to restore post",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    post,
+		Message: "Post restored successfully",
+	})
+}
+
 type UserController struct {
 	service *services.UserService
 }
@@ -109,9 +380,19 @@ func NewUserController(service *services.UserService) *UserController {
 }
 
 func (c *UserController) GetAllUsers(ctx *gin.Context) {
-	users, err := c.service.GetAllUsers()
+	q, err := parseListQuery(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	users, total, err := c.service.GetAllUsers(q)
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
 			Success: false,
 			Error:   "Failed to retrieve users",
 			Message: err.Error(),
@@ -123,6 +404,7 @@ func (c *UserController) GetAllUsers(ctx *gin.Context) {
 		Success: true,
 		Data:    users,
 		Message: "Users retrieved successfully",
+		Meta:    pageMeta(ctx, q, total),
 	})
 }
 
@@ -175,7 +457,7 @@ func (c *UserController) GetUserByEmail(ctx *gin.Context) {
 }
 
 func (c *UserController) CreateUser(ctx *gin.Context) {
-	var req models.APIUser
+	var req models.UserCreateRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
@@ -185,7 +467,7 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.CreateUser(&req)
+	user, err := c.service.CreateUser(&req, middleware.AuditSource(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -215,7 +497,7 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.UpdateUser(userID, &req)
+	user, err := c.service.UpdateUser(userID, &req, middleware.AuditSource(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -231,3 +513,33 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		Message: "User updated successfully",
 	})
 }
+
+func (c *UserController) UpdateUserRole(ctx *gin.Context) {
+	userID := ctx.Param("userId")
+
+	var req models.UserRoleUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Validation failed",
+			Message: "Please check your input data",
+		})
+		return
+	}
+
+	user, err := c.service.UpdateUserRole(userID, &req, middleware.AuditSource(ctx))
+	if err != nil {
+		ctx.JSON(statusFor(err, http.StatusInternalServerError), models.APIResponse{
+			Success: false,
+			Error:   "Failed to update user role",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    user,
+		Message: "User role updated successfully",
+	})
+}
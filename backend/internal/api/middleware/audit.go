@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditSource attributes the changes a request makes to the authenticated
+// user, for the services to record in the audit trail
+func AuditSource(ctx *gin.Context) *models.AuditSource {
+	source := &models.AuditSource{
+		Method:    ctx.Request.Method,
+		Path:      ctx.Request.URL.Path,
+		IPAddress: ctx.ClientIP(),
+	}
+	if user := CurrentUser(ctx); user != nil {
+		source.ActorID = user.ID
+		source.ActorRole = user.Role
+	}
+	return source
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/services"
+	"github.com/gin-gonic/gin"
+)
+
+const currentUserKey = "currentUser"
+
+// RequireAuth rejects requests without a valid bearer token and stores the
+// authenticated user in the request context
+func RequireAuth(authService *services.AuthService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Error:   "Unauthorized",
+				Message: "A bearer token is required",
+			})
+			return
+		}
+
+		user, err := authService.Authenticate(token)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrInvalidToken) {
+				status = http.StatusUnauthorized
+			}
+			ctx.AbortWithStatusJSON(status, models.APIResponse{
+				Success: false,
+				Error:   "Unauthorized",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		ctx.Set(currentUserKey, user)
+		ctx.Next()
+	}
+}
+
+// RequireStreamTicket authenticates a request by the stream ticket in the
+// named query parameter, for browser EventSources, which cannot set headers.
+// Access tokens are not accepted there, since query strings end up in logs.
+func RequireStreamTicket(authService *services.AuthService, param string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ticket := ctx.Query(param)
+		if ticket == "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Error:   "Unauthorized",
+				Message: "A stream ticket is required",
+			})
+			return
+		}
+
+		user, err := authService.AuthenticateStreamTicket(ticket)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrInvalidToken) {
+				status = http.StatusUnauthorized
+			}
+			ctx.AbortWithStatusJSON(status, models.APIResponse{
+				Success: false,
+				Error:   "Unauthorized",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		ctx.Set(currentUserKey, user)
+		ctx.Next()
+	}
+}
+
+// CurrentUser returns the authenticated user set by RequireAuth
+func CurrentUser(ctx *gin.Context) *models.APIUser {
+	value, exists := ctx.Get(currentUserKey)
+	if !exists {
+		return nil
+	}
+	user, _ := value.(*models.APIUser)
+	return user
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OwnerLookup resolves the ID of the user that owns the entity with the given ID
+type OwnerLookup func(id string) (string, error)
+
+// RequireRoles allows the request only if the caller has one of the roles
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !HasRole(ctx, roles...) {
+			forbid(ctx, "Your role does not allow this action")
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireSelf allows the request only if the path parameter is the caller's
+// own user ID, unless the caller has one of the bypass roles
+func RequireSelf(param string, bypassRoles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := CurrentUser(ctx)
+		if user == nil || (user.ID != ctx.Param(param) && !HasRole(ctx, bypassRoles...)) {
+			forbid(ctx, "You can only access your own records")
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// RequireOwner allows the request only if the caller owns the entity named by
+// the path parameter, unless the caller has one of the bypass roles
+func RequireOwner(param string, lookup OwnerLookup, bypassRoles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if HasRole(ctx, bypassRoles...) {
+			ctx.Next()
+			return
+		}
+
+		ownerID, err := lookup(ctx.Param(param))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.AbortWithStatusJSON(http.StatusNotFound, models.APIResponse{
+					Success: false,
+					Error:   "Not found",
+					Message: "The requested resource could not be found",
+				})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Error:   "Authorization failed",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user := CurrentUser(ctx)
+		if user == nil || user.ID != ownerID {
+			forbid(ctx, "Only the owner of this resource can perform this action")
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// HasRole reports whether the caller has one of the roles
+func HasRole(ctx *gin.Context, roles ...string) bool {
+	user := CurrentUser(ctx)
+	return user != nil && slices.Contains(roles, user.Role)
+}
+
+func forbid(ctx *gin.Context, message string) {
+	ctx.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+		Success: false,
+		Error:   "Forbidden",
+		Message: message,
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // ActivityMetadata is a custom type for handling metadata JSON in GORM
@@ -38,15 +40,16 @@ func (a *ActivityMetadata) Scan(value interface{}) error {
 
 // Section represents a course section
 type Section struct {
-	ID          string     `json:"id" gorm:"primaryKey"`
-	CourseID    string     `json:"courseId"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Order       int        `json:"order"`
-	Visible     bool       `json:"visible"`
-	Activities  []Activity `json:"activities" gorm:"foreignKey:SectionID"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
+	ID          string         `json:"id" gorm:"primaryKey"`
+	CourseID    string         `json:"courseId"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Order       int            `json:"order"`
+	Visible     bool           `json:"visible"`
+	Activities  []Activity     `json:"activities" gorm:"foreignKey:SectionID"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
 }
 
 // Activity represents an activity within a section
@@ -58,13 +61,14 @@ type Activity struct {
 	Type           string           `json:"type"`
 	Order          int              `json:"order"`
 	Visible        bool             `json:"visible"`
-	Completed      bool             `json:"completed"`
+	Completed      bool             `json:"completed" gorm:"-"`
 	DueDate        *string          `json:"dueDate,omitempty"`
 	AvailableFrom  *string          `json:"availableFrom,omitempty"`
 	AvailableUntil *string          `json:"availableUntil,omitempty"`
 	Metadata       ActivityMetadata `json:"metadata" gorm:"type:text"`
 	CreatedAt      time.Time        `json:"createdAt"`
 	UpdatedAt      time.Time        `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt   `json:"deletedAt,omitempty" gorm:"index"`
 }
 
 // SectionCreateRequest represents the request to create a section
@@ -90,7 +94,8 @@ type ActivityCreateRequest struct {
 
 // ActivityUpdateRequest represents the request to update an activity
 type ActivityUpdateRequest struct {
-	Title       *string `json:"title,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Visible     *bool   `json:"visible,omitempty"`
+	Title       *string                `json:"title,omitempty"`
+	Description *string                `json:"description,omitempty"`
+	Visible     *bool                  `json:"visible,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 }
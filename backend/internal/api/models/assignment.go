@@ -1,55 +1,130 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/textdiff"
+	"gorm.io/gorm"
+)
+
+// Late submission policies
+const (
+	LatePolicyAccept  = "accept"
+	LatePolicyReject  = "reject"
+	LatePolicyPenalty = "penalty"
+)
+
+// Attempt policies decide which graded attempt counts towards the grade
+const (
+	AttemptPolicyLatest  = "latest"
+	AttemptPolicyHighest = "highest"
+	AttemptPolicyAverage = "average"
+)
+
+// AssignmentTypeCode marks assignments whose submissions are source code
+const AssignmentTypeCode = "code"
+
+// Submission statuses
+const (
+	SubmissionSubmitted = "submitted"
+	SubmissionLate      = "late"
+	SubmissionGraded    = "graded"
+)
 
 // Assignment represents an assignment
 type Assignment struct {
-	ID           string       `json:"id" gorm:"primaryKey"`
-	Title        string       `json:"title"`
-	Description  string       `json:"description"`
-	CourseID     string       `json:"courseId"`
-	InstructorID string       `json:"instructorId"`
-	Type         string       `json:"type"`
-	TotalPoints  int          `json:"totalPoints"`
-	DueDate      string       `json:"dueDate"`
-	Status       string       `json:"status"`
-	Instructions string       `json:"instructions"`
-	Attachments  []Attachment `json:"attachments" gorm:"foreignKey:AssignmentID"`
-	Submissions  []Submission `json:"submissions" gorm:"foreignKey:AssignmentID"`
-	CreatedAt    time.Time    `json:"createdAt"`
-	UpdatedAt    time.Time    `json:"updatedAt"`
-}
-
-// Submission represents an assignment submission
+	ID                 string         `json:"id" gorm:"primaryKey"`
+	Title              string         `json:"title"`
+	Description        string         `json:"description"`
+	CourseID           string         `json:"courseId"`
+	InstructorID       string         `json:"instructorId"`
+	Type               string         `json:"type"`
+	TotalPoints        int            `json:"totalPoints"`
+	DueDate            time.Time      `json:"dueDate"`
+	LatePolicy         string         `json:"latePolicy"`
+	LatePenaltyPercent float64        `json:"latePenaltyPercent"`
+	GracePeriodMinutes int            `json:"gracePeriodMinutes"`
+	MaxLateDays        int            `json:"maxLateDays"`
+	MaxAttempts        int            `json:"maxAttempts"`
+	AttemptPolicy      string         `json:"attemptPolicy"`
+	PeerReviewCount    int            `json:"peerReviewCount"`
+	PeerReviewWeight   float64        `json:"peerReviewWeight"`
+	PeerReviewDueDate  *time.Time     `json:"peerReviewDueDate,omitempty"`
+	Status             string         `json:"status"`
+	Instructions       string         `json:"instructions"`
+	Attachments        []Attachment   `json:"attachments" gorm:"foreignKey:AssignmentID"`
+	Submissions        []Submission   `json:"submissions" gorm:"foreignKey:AssignmentID"`
+	CreatedAt          time.Time      `json:"createdAt"`
+	UpdatedAt          time.Time      `json:"updatedAt"`
+	DeletedAt          gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
+}
+
+// AssignmentExtension moves an assignment's due date for one student
+type AssignmentExtension struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	AssignmentID string    `json:"assignmentId" gorm:"uniqueIndex:idx_assignment_extension"`
+	StudentID    string    `json:"studentId" gorm:"uniqueIndex:idx_assignment_extension"`
+	DueDate      time.Time `json:"dueDate"`
+	Reason       string    `json:"reason"`
+	GrantedBy    string    `json:"grantedBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// Submission represents an assignment submission. When peer scores are
+// blended into Score, InstructorScore keeps the instructor's own score.
 type Submission struct {
-	ID           string       `json:"id" gorm:"primaryKey"`
-	AssignmentID string       `json:"assignmentId"`
-	StudentID    string       `json:"studentId"`
-	Content      string       `json:"content"`
-	Score        *int         `json:"score,omitempty"`
-	Feedback     *string      `json:"feedback,omitempty"`
-	Status       string       `json:"status"`
-	Attachments  []Attachment `json:"attachments" gorm:"foreignKey:SubmissionID"`
-	SubmittedAt  time.Time    `json:"submittedAt"`
-	GradedAt     *time.Time   `json:"gradedAt,omitempty"`
+	ID              string            `json:"id" gorm:"primaryKey"`
+	AssignmentID    string            `json:"assignmentId"`
+	StudentID       string            `json:"studentId"`
+	Attempt         int               `json:"attempt"`
+	Content         string            `json:"content"`
+	Score           *int              `json:"score,omitempty"`
+	InstructorScore *int              `json:"instructorScore,omitempty"`
+	PeerScore       *float64          `json:"peerScore,omitempty"`
+	Feedback        *string           `json:"feedback,omitempty"`
+	Status          string            `json:"status"`
+	LateDays        int               `json:"lateDays"`
+	LatePenalty     float64           `json:"latePenalty"`
+	Attachments     []Attachment      `json:"attachments" gorm:"foreignKey:SubmissionID"`
+	Rubric          *RubricAssessment `json:"rubric,omitempty" gorm:"foreignKey:SubmissionID"`
+	SubmittedAt     time.Time         `json:"submittedAt"`
+	GradedAt        *time.Time        `json:"gradedAt,omitempty"`
 }
 
 // AssignmentCreateRequest represents the request to create an assignment
 type AssignmentCreateRequest struct {
-	Title        string `json:"title" binding:"required"`
-	Description  string `json:"description" binding:"required"`
-	CourseID     string `json:"courseId" binding:"required"`
-	Type         string `json:"type" binding:"required"`
-	TotalPoints  int    `json:"totalPoints" binding:"required"`
-	DueDate      string `json:"dueDate" binding:"required"`
-	Instructions string `json:"instructions"`
+	Title              string   `json:"title" binding:"required"`
+	Description        string   `json:"description" binding:"required"`
+	CourseID           string   `json:"courseId" binding:"required"`
+	Type               string   `json:"type" binding:"required"`
+	TotalPoints        int      `json:"totalPoints" binding:"required"`
+	DueDate            string   `json:"dueDate" binding:"required"`
+	LatePolicy         string   `json:"latePolicy" binding:"omitempty,oneof=accept reject penalty"`
+	LatePenaltyPercent float64  `json:"latePenaltyPercent" binding:"gte=0,lte=100"`
+	GracePeriodMinutes int      `json:"gracePeriodMinutes" binding:"gte=0"`
+	MaxLateDays        int      `json:"maxLateDays" binding:"gte=0"`
+	MaxAttempts        int      `json:"maxAttempts" binding:"gte=0"`
+	AttemptPolicy      string   `json:"attemptPolicy" binding:"omitempty,oneof=latest highest average"`
+	PeerReviewCount    int      `json:"peerReviewCount" binding:"gte=0"`
+	PeerReviewWeight   float64  `json:"peerReviewWeight" binding:"gte=0,lte=100"`
+	PeerReviewDueDate  string   `json:"peerReviewDueDate"`
+	Instructions       string   `json:"instructions"`
+	AttachmentIDs      []string `json:"attachmentIds"`
+}
+
+// AssignmentExtensionRequest represents the request to grant a student an extension
+type AssignmentExtensionRequest struct {
+	StudentID string `json:"studentId" binding:"required"`
+	DueDate   string `json:"dueDate" binding:"required"`
+	Reason    string `json:"reason"`
 }
 
 // AssignmentSubmitRequest represents the request to submit an assignment
 type AssignmentSubmitRequest struct {
-	AssignmentID string       `json:"assignmentId" binding:"required"`
-	Content      string       `json:"content" binding:"required"`
-	Attachments  []Attachment `json:"attachments"`
+	AssignmentID  string   `json:"assignmentId" binding:"required"`
+	Content       string   `json:"content" binding:"required"`
+	AttachmentIDs []string `json:"attachmentIds"`
 }
 
 // AssignmentGradeRequest represents the request to grade an assignment
@@ -58,3 +133,21 @@ type AssignmentGradeRequest struct {
 	Score        int    `json:"score" binding:"required"`
 	Feedback     string `json:"feedback"`
 }
+
+// SubmissionVersion is one attempt in a student's submission history, with
+// the changes to its content since the previous attempt
+type SubmissionVersion struct {
+	Submission
+	Diff *textdiff.Diff `json:"diff,omitempty"`
+}
+
+// SubmissionHistory represents every attempt a student made at an assignment
+type SubmissionHistory struct {
+	AssignmentID  string              `json:"assignmentId"`
+	StudentID     string              `json:"studentId"`
+	MaxAttempts   int                 `json:"maxAttempts"`
+	AttemptPolicy string              `json:"attemptPolicy"`
+	AttemptsUsed  int                 `json:"attemptsUsed"`
+	CountedScore  *int                `json:"countedScore,omitempty"`
+	Attempts      []SubmissionVersion `json:"attempts"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audited actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditSnapshot is the JSON state of an entity before or after a change
+type AuditSnapshot json.RawMessage
+
+func (s AuditSnapshot) MarshalJSON() ([]byte, error) {
+	if len(s) == 0 {
+		return []byte("null"), nil
+	}
+	return s, nil
+}
+
+func (s AuditSnapshot) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	return string(s), nil
+}
+
+func (s *AuditSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = nil
+	case []byte:
+		*s = append(AuditSnapshot{}, v...)
+	case string:
+		*s = AuditSnapshot(v)
+	default:
+		return fmt.Errorf("cannot scan %T into AuditSnapshot", value)
+	}
+	return nil
+}
+
+// AuditChange is the old and new value of a changed field
+type AuditChange struct {
+	From json.RawMessage `json:"from"`
+	To   json.RawMessage `json:"to"`
+}
+
+// AuditChanges is a custom type for handling changed fields in GORM
+type AuditChanges map[string]AuditChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *AuditChanges) Scan(value interface{}) error {
+	return scanJSON(value, c, "AuditChanges")
+}
+
+// AuditSource is who makes a change and through which request
+type AuditSource struct {
+	ActorID   string
+	ActorRole string
+	Method    string
+	Path      string
+	IPAddress string
+}
+
+// AuditLog is an entry of the append-only audit trail: who changed which
+// entity through which request, with the entity's state before and after and
+// the fields that changed
+type AuditLog struct {
+	ID         string        `json:"id" gorm:"primaryKey"`
+	ActorID    string        `json:"actorId" gorm:"index"`
+	ActorRole  string        `json:"actorRole"`
+	Action     string        `json:"action" gorm:"index"`
+	EntityType string        `json:"entityType" gorm:"index:idx_audit_entity"`
+	EntityID   string        `json:"entityId" gorm:"index:idx_audit_entity"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"`
+	IPAddress  string        `json:"ipAddress"`
+	Before     AuditSnapshot `json:"before" gorm:"type:text"`
+	After      AuditSnapshot `json:"after" gorm:"type:text"`
+	Changes    AuditChanges  `json:"changes" gorm:"type:text"`
+	CreatedAt  time.Time     `json:"createdAt" gorm:"index"`
+}
@@ -8,6 +8,7 @@ type APIResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+	Meta    interface{} `json:"meta,omitempty"`
 }
 
 // Resource represents a file resource
@@ -18,6 +19,12 @@ type Resource struct {
 	Type        string    `json:"type"`
 	URL         string    `json:"url"`
 	Description string    `json:"description"`
+	FileName    string    `json:"fileName,omitempty"`
+	StorageKey  string    `json:"-"`
+	Size        int64     `json:"size,omitempty"`
+	MimeType    string    `json:"mimeType,omitempty"`
+	Checksum    string    `json:"checksum,omitempty"`
+	UploadedBy  string    `json:"uploadedBy,omitempty"`
 	UploadedAt  time.Time `json:"uploadedAt"`
 }
 
@@ -30,5 +37,23 @@ type Attachment struct {
 	Type         string    `json:"type"`
 	URL          string    `json:"url"`
 	Description  string    `json:"description"`
+	FileName     string    `json:"fileName,omitempty"`
+	StorageKey   string    `json:"-"`
+	Size         int64     `json:"size,omitempty"`
+	MimeType     string    `json:"mimeType,omitempty"`
+	Checksum     string    `json:"checksum,omitempty"`
+	UploadedBy   string    `json:"uploadedBy,omitempty"`
 	UploadedAt   time.Time `json:"uploadedAt"`
 }
+
+// UploadRequest carries the form fields sent along with an uploaded file
+type UploadRequest struct {
+	Title       string `form:"title"`
+	Description string `form:"description"`
+}
+
+// DownloadLink is a signed, expiring link to a stored file
+type DownloadLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // StringSlice is a custom type for handling string arrays in GORM
@@ -38,22 +40,23 @@ func (s *StringSlice) Scan(value interface{}) error {
 
 // Course represents a course
 type Course struct {
-	ID               string      `json:"id" gorm:"primaryKey"`
-	Title            string      `json:"title"`
-	Description      string      `json:"description"`
-	InstructorID     string      `json:"instructorId"`
-	Category         string      `json:"category"`
-	Level            string      `json:"level"`
-	Duration         string      `json:"duration"`
-	EnrolledStudents StringSlice `json:"enrolledStudents" gorm:"type:text"`
-	MaxStudents      int         `json:"maxStudents"`
-	StartDate        string      `json:"startDate"`
-	EndDate          string      `json:"endDate"`
-	Status           string      `json:"status"`
-	Syllabus         StringSlice `json:"syllabus" gorm:"type:text"`
-	Resources        []Resource  `json:"resources" gorm:"foreignKey:CourseID"`
-	CreatedAt        time.Time   `json:"createdAt"`
-	UpdatedAt        time.Time   `json:"updatedAt"`
+	ID               string         `json:"id" gorm:"primaryKey"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description"`
+	InstructorID     string         `json:"instructorId"`
+	Category         string         `json:"category"`
+	Level            string         `json:"level"`
+	Duration         string         `json:"duration"`
+	EnrolledStudents StringSlice    `json:"enrolledStudents" gorm:"type:text"`
+	MaxStudents      int            `json:"maxStudents"`
+	StartDate        string         `json:"startDate"`
+	EndDate          string         `json:"endDate"`
+	Status           string         `json:"status"`
+	Syllabus         StringSlice    `json:"syllabus" gorm:"type:text"`
+	Resources        []Resource     `json:"resources" gorm:"foreignKey:CourseID"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	UpdatedAt        time.Time      `json:"updatedAt"`
+	DeletedAt        gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
 }
 
 // CourseCreateRequest represents the request to create a course
@@ -75,3 +78,24 @@ type CourseUpdateRequest struct {
 	Description *string `json:"description,omitempty"`
 	MaxStudents *int    `json:"maxStudents,omitempty"`
 }
+
+// CourseTrash lists the deleted content of a course that can be restored
+type CourseTrash struct {
+	CourseID    string       `json:"courseId"`
+	Course      *Course      `json:"course,omitempty"`
+	Sections    []Section    `json:"sections"`
+	Activities  []Activity   `json:"activities"`
+	Assignments []Assignment `json:"assignments"`
+	ForumPosts  []ForumPost  `json:"forumPosts"`
+}
+
+// TrashPurge counts the rows a purge of the trash removed for good
+type TrashPurge struct {
+	Courses     int64 `json:"courses"`
+	Sections    int64 `json:"sections"`
+	Activities  int64 `json:"activities"`
+	Assignments int64 `json:"assignments"`
+	Enrollments int64 `json:"enrollments"`
+	ForumPosts  int64 `json:"forumPosts"`
+	Files       int64 `json:"files"`
+}
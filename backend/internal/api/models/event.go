@@ -0,0 +1,30 @@
+package models
+
+// Domain events published on the event bus
+const (
+	EventAssignmentCreated   = "assignment.created"
+	EventSubmissionGraded    = "submission.graded"
+	EventEnrollmentChanged   = "enrollment.changed"
+	EventForumPostCreated    = "forum.post_created"
+	EventForumReplyCreated   = "forum.reply_created"
+	EventNotificationCreated = "notification.created"
+)
+
+// SubmissionGradedEvent is published when a submission gets a score
+type SubmissionGradedEvent struct {
+	Assignment Assignment
+	Submission Submission
+}
+
+// EnrollmentChangedEvent is published when a student enrolls in a course or
+// their enrollment's status changes
+type EnrollmentChangedEvent struct {
+	Course     Course
+	Enrollment Enrollment
+}
+
+// ForumReplyCreatedEvent is published when someone replies to a forum post
+type ForumReplyCreatedEvent struct {
+	Post  ForumPost
+	Reply ForumPost
+}
@@ -1,35 +1,89 @@
 package models
 
-import "time"
+import (
+	"time"
 
-// ForumPost represents a forum post
+	"gorm.io/gorm"
+)
+
+// ActivityTypeForum is the type of activity whose ID is a forum's ForumID
+const ActivityTypeForum = "forum"
+
+// ForumQAModeKey is the forum activity's metadata flag that turns it into a
+// Q&A forum, where each thread is a question and one reply can be accepted as
+// its answer
+const ForumQAModeKey = "qaMode"
+
+// IsQAForum reports whether the activity is a forum in Q&A mode
+func IsQAForum(activity *Activity) bool {
+	enabled, _ := activity.Metadata[ForumQAModeKey].(bool)
+	return activity.Type == ActivityTypeForum && enabled
+}
+
+// ForumPost represents a forum post. A post without a parent starts a thread,
+// and replies may answer any post in it; ThreadID is the first post's ID.
+// Instructors pin threads to the top of their forum and lock them against
+// replies and edits. In a Q&A forum the thread's AcceptedAnswerID names the
+// reply that answered it. LastActivityAt is when a thread last got a reply.
+// DeletedBy is who deleted the post, if it was deleted on its own rather than
+// with what it belongs to.
 type ForumPost struct {
-	ID         string      `json:"id" gorm:"primaryKey"`
-	ForumID    string      `json:"forumId"`
-	AuthorID   string      `json:"authorId"`
-	AuthorName string      `json:"authorName"`
-	Title      string      `json:"title"`
-	Content    string      `json:"content"`
-	IsPinned   bool        `json:"isPinned"`
-	Replies    []ForumPost `json:"replies" gorm:"foreignKey:ParentID"`
-	ParentID   *string     `json:"parentId,omitempty"`
-	CreatedAt  time.Time   `json:"createdAt"`
-	UpdatedAt  time.Time   `json:"updatedAt"`
+	ID               string         `json:"id" gorm:"primaryKey"`
+	ForumID          string         `json:"forumId"`
+	ThreadID         string         `json:"threadId"`
+	AuthorID         string         `json:"authorId"`
+	AuthorName       string         `json:"authorName"`
+	Title            string         `json:"title"`
+	Content          string         `json:"content"`
+	IsPinned         bool           `json:"isPinned"`
+	IsLocked         bool           `json:"isLocked"`
+	AcceptedAnswerID *string        `json:"acceptedAnswerId,omitempty"`
+	VoteCount        int            `json:"voteCount"`
+	Voted            bool           `json:"voted" gorm:"-"`
+	Replies          []ForumPost    `json:"replies" gorm:"foreignKey:ParentID"`
+	ParentID         *string        `json:"parentId,omitempty"`
+	EditedAt         *time.Time     `json:"editedAt,omitempty"`
+	LastActivityAt   time.Time      `json:"lastActivityAt"`
+	CreatedAt        time.Time      `json:"createdAt"`
+	UpdatedAt        time.Time      `json:"updatedAt"`
+	DeletedAt        gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
+	DeletedBy        *string        `json:"deletedBy,omitempty"`
 }
 
-// ForumPostCreateRequest represents the request to create a forum post
+// ForumPostVote is a user's upvote of a post. Voted on a post tells whether
+// the viewer upvoted it.
+type ForumPostVote struct {
+	PostID    string    `json:"postId" gorm:"primaryKey"`
+	UserID    string    `json:"userId" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ForumPostRevision is a version of a post that an edit replaced, kept with
+// who edited it and when
+type ForumPostRevision struct {
+	ID       string    `json:"id" gorm:"primaryKey"`
+	PostID   string    `json:"postId"`
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	EditedBy string    `json:"editedBy"`
+	EditedAt time.Time `json:"editedAt"`
+}
+
+// ForumPostCreateRequest represents the request to start a thread
 type ForumPostCreateRequest struct {
-	ForumID    string `json:"forumId" binding:"required"`
-	Title      string `json:"title" binding:"required"`
-	Content    string `json:"content" binding:"required"`
-	AuthorID   string `json:"authorId" binding:"required"`
-	AuthorName string `json:"authorName" binding:"required"`
+	ForumID string `json:"forumId" binding:"required"`
+	Title   string `json:"title" binding:"required"`
+	Content string `json:"content" binding:"required"`
 }
 
-// ForumReplyCreateRequest represents the request to create a forum reply
+// ForumReplyCreateRequest represents the request to reply to a post
 type ForumReplyCreateRequest struct {
-	PostID     string `json:"postId" binding:"required"`
-	Content    string `json:"content" binding:"required"`
-	AuthorID   string `json:"authorId" binding:"required"`
-	AuthorName string `json:"authorName" binding:"required"`
+	PostID  string `json:"postId" binding:"required"`
+	Content string `json:"content" binding:"required"`
+}
+
+// ForumPostUpdateRequest represents the request to edit a post
+type ForumPostUpdateRequest struct {
+	Title   *string `json:"title"`
+	Content *string `json:"content" binding:"omitempty,min=1"`
 }
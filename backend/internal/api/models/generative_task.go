@@ -1,8 +1,53 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
-// GenerativeTask represents an AI-generated task
+// ActivityTypeGenerativeTask is the type of activity students generate tasks for
+const ActivityTypeGenerativeTask = "generative-task"
+
+// TaskTest describes one input/expected-output check for a generative task
+type TaskTest struct {
+	Name           string `json:"name"`
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expectedOutput"`
+}
+
+// TaskTests is a custom type for handling task test definitions in GORM
+type TaskTests []TaskTest
+
+func (t TaskTests) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(t)
+}
+
+func (t *TaskTests) Scan(value interface{}) error {
+	if value == nil {
+		*t = TaskTests{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into TaskTests", value)
+	}
+
+	return json.Unmarshal(bytes, t)
+}
+
+// GenerativeTask represents an AI-generated task. Its tests stay on the
+// server, since students could hard-code the expected outputs.
 type GenerativeTask struct {
 	ID            string      `json:"id" gorm:"primaryKey"`
 	ActivityID    string      `json:"activityId"`
@@ -11,8 +56,10 @@ type GenerativeTask struct {
 	Description   string      `json:"description"`
 	Requirements  StringSlice `json:"requirements" gorm:"type:text"`
 	Difficulty    string      `json:"difficulty"`
+	Language      string      `json:"language"`
 	EstimatedTime int         `json:"estimatedTime"`
 	Hints         StringSlice `json:"hints" gorm:"type:text"`
+	Tests         TaskTests   `json:"-" gorm:"type:text"`
 	CreatedAt     time.Time   `json:"createdAt"`
 	UpdatedAt     time.Time   `json:"updatedAt"`
 }
@@ -35,18 +82,23 @@ type TestCase struct {
 	SubmissionID string `json:"-"`
 	Name         string `json:"name"`
 	Passed       bool   `json:"passed"`
+	Stdout       string `json:"stdout"`
+	Stderr       string `json:"stderr"`
+	ExitCode     int    `json:"exitCode"`
+	TimedOut     bool   `json:"timedOut"`
+	DurationMs   int64  `json:"durationMs"`
 }
 
 // GenerativeTaskGenerateRequest represents the request to generate a task
 type GenerativeTaskGenerateRequest struct {
 	ActivityID string `json:"activityId" binding:"required"`
-	Difficulty string `json:"difficulty" binding:"required"`
-	StudentID  string `json:"studentId" binding:"required"`
+	Difficulty string `json:"difficulty" binding:"required,oneof=beginner easy medium hard expert"`
+	StudentID  string `json:"studentId"`
 }
 
 // GenerativeTaskSubmitRequest represents the request to submit a generative task
 type GenerativeTaskSubmitRequest struct {
 	TaskID    string `json:"taskId" binding:"required"`
 	Code      string `json:"code" binding:"required"`
-	StudentID string `json:"studentId" binding:"required"`
+	StudentID string `json:"studentId"`
 }
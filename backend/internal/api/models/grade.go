@@ -1,6 +1,19 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Grade sources
+const (
+	GradeSourceAssignment     = "assignment"
+	GradeSourceGenerativeTask = "generative_task"
+)
+
+// SystemGrader is recorded as the grader of automatically graded work
+const SystemGrader = "system"
 
 // Grade represents a grade
 type Grade struct {
@@ -9,6 +22,7 @@ type Grade struct {
 	StudentName     string    `json:"studentName"`
 	AssignmentID    string    `json:"assignmentId"`
 	AssignmentTitle string    `json:"assignmentTitle"`
+	Source          string    `json:"source"`
 	CourseID        string    `json:"courseId"`
 	CourseName      string    `json:"courseName"`
 	Score           int       `json:"score"`
@@ -21,21 +35,44 @@ type Grade struct {
 	UpdatedAt       time.Time `json:"updatedAt"`
 }
 
+// Enrollment statuses
+const (
+	EnrollmentActive     = "active"
+	EnrollmentWaitlisted = "waitlisted"
+	EnrollmentDropped    = "dropped"
+	EnrollmentWithdrawn  = "withdrawn"
+	EnrollmentCompleted  = "completed"
+)
+
 // Enrollment represents a student enrollment in a course
 type Enrollment struct {
-	ID           string     `json:"id" gorm:"primaryKey"`
-	StudentID    string     `json:"studentId"`
-	CourseID     string     `json:"courseId"`
-	EnrolledAt   time.Time  `json:"enrolledAt"`
-	Status       string     `json:"status"`
-	Progress     int        `json:"progress"`
-	LastAccessed *time.Time `json:"lastAccessed,omitempty"`
-	CreatedAt    time.Time  `json:"createdAt"`
-	UpdatedAt    time.Time  `json:"updatedAt"`
+	ID           string         `json:"id" gorm:"primaryKey"`
+	StudentID    string         `json:"studentId"`
+	CourseID     string         `json:"courseId"`
+	EnrolledAt   time.Time      `json:"enrolledAt"`
+	Status       string         `json:"status"`
+	Progress     int            `json:"progress"`
+	LastAccessed *time.Time     `json:"lastAccessed,omitempty"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	DeletedAt    gorm.DeletedAt `json:"deletedAt,omitempty" gorm:"index"`
 }
 
 // EnrollmentCreateRequest represents the request to create an enrollment
 type EnrollmentCreateRequest struct {
 	CourseID  string `json:"courseId" binding:"required"`
-	StudentID string `json:"studentId" binding:"required"`
+	StudentID string `json:"studentId"`
+}
+
+// EnrollmentStatusUpdateRequest represents the request to move an enrollment to a new status
+type EnrollmentStatusUpdateRequest struct {
+	Status string `json:"status" binding:"required,oneof=active dropped withdrawn completed"`
+}
+
+// CourseRoster represents the students enrolled in and waiting for a course
+type CourseRoster struct {
+	CourseID    string       `json:"courseId"`
+	MaxStudents int          `json:"maxStudents"`
+	Active      []Enrollment `json:"active"`
+	Waitlisted  []Enrollment `json:"waitlisted"`
 }
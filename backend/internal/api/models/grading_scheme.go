@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Grading scheme types
+const (
+	GradingSchemeLetter   = "letter"
+	GradingSchemePassFail = "pass_fail"
+	GradingSchemeCustom   = "custom"
+)
+
+// GradeCutoff maps a minimum percentage to the label awarded at or above it
+type GradeCutoff struct {
+	Label   string  `json:"label"`
+	Minimum float64 `json:"minimum"`
+}
+
+// GradeCutoffs is a custom type for handling grade cutoffs in GORM
+type GradeCutoffs []GradeCutoff
+
+func (g GradeCutoffs) Value() (driver.Value, error) {
+	if len(g) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(g)
+}
+
+func (g *GradeCutoffs) Scan(value interface{}) error {
+	if value == nil {
+		*g = GradeCutoffs{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into GradeCutoffs", value)
+	}
+
+	return json.Unmarshal(bytes, g)
+}
+
+// CategoryWeights is a custom type for handling category weights in GORM
+type CategoryWeights map[string]float64
+
+func (c CategoryWeights) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *CategoryWeights) Scan(value interface{}) error {
+	if value == nil {
+		*c = CategoryWeights{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into CategoryWeights", value)
+	}
+
+	return json.Unmarshal(bytes, c)
+}
+
+// GradingScheme represents how a course turns scores into final grades
+type GradingScheme struct {
+	CourseID      string          `json:"courseId" gorm:"primaryKey"`
+	Type          string          `json:"type"`
+	Cutoffs       GradeCutoffs    `json:"cutoffs" gorm:"type:text"`
+	PassThreshold float64         `json:"passThreshold"`
+	Weights       CategoryWeights `json:"weights" gorm:"type:text"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+// GradingSchemeUpdateRequest represents the request to configure a course's grading scheme
+type GradingSchemeUpdateRequest struct {
+	Type          string             `json:"type" binding:"required,oneof=letter pass_fail custom"`
+	Cutoffs       []GradeCutoff      `json:"cutoffs"`
+	PassThreshold float64            `json:"passThreshold"`
+	Weights       map[string]float64 `json:"weights"`
+}
+
+// CategoryGrade represents a student's standing in one grading category
+type CategoryGrade struct {
+	Category       string  `json:"category"`
+	Weight         float64 `json:"weight"`
+	PointsEarned   int     `json:"pointsEarned"`
+	PointsPossible int     `json:"pointsPossible"`
+	Percentage     float64 `json:"percentage"`
+}
+
+// CourseGrade represents a student's running total in a course
+type CourseGrade struct {
+	CourseID    string          `json:"courseId"`
+	StudentID   string          `json:"studentId"`
+	StudentName string          `json:"studentName"`
+	Categories  []CategoryGrade `json:"categories"`
+	GradedItems int             `json:"gradedItems"`
+	Percentage  float64         `json:"percentage"`
+	FinalGrade  string          `json:"finalGrade"`
+}
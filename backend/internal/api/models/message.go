@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Conversation types
+const (
+	ConversationDirect       = "direct"
+	ConversationGroup        = "group"
+	ConversationAnnouncement = "announcement"
+)
+
+// Conversation is a thread of messages between its participants. A direct
+// conversation is between two users, a group conversation between any number,
+// and a course's announcement conversation reaches its instructor and students
+// but only the instructor writes in it.
+type Conversation struct {
+	ID             string                    `json:"id" gorm:"primaryKey"`
+	Type           string                    `json:"type"`
+	Title          string                    `json:"title"`
+	CourseID       *string                   `json:"courseId,omitempty"`
+	CreatedBy      string                    `json:"createdBy"`
+	Participants   []ConversationParticipant `json:"participants"`
+	LastMessage    *Message                  `json:"lastMessage,omitempty" gorm:"-"`
+	UnreadCount    int64                     `json:"unreadCount" gorm:"-"`
+	LastActivityAt time.Time                 `json:"lastActivityAt"`
+	CreatedAt      time.Time                 `json:"createdAt"`
+	UpdatedAt      time.Time                 `json:"updatedAt"`
+}
+
+// ConversationParticipant is a user in a conversation. LastReadAt is the
+// read receipt: every message up to that time counts as read by the user.
+type ConversationParticipant struct {
+	ConversationID string     `json:"-" gorm:"primaryKey"`
+	UserID         string     `json:"userId" gorm:"primaryKey"`
+	UserName       string     `json:"userName"`
+	LastReadAt     *time.Time `json:"lastReadAt"`
+	JoinedAt       time.Time  `json:"joinedAt"`
+}
+
+// Message is a message in a conversation. ReadBy lists the other participants
+// who have read it.
+type Message struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	ConversationID string    `json:"conversationId"`
+	SenderID       string    `json:"senderId"`
+	SenderName     string    `json:"senderName"`
+	Content        string    `json:"content"`
+	ReadBy         []string  `json:"readBy" gorm:"-"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// ConversationCreateRequest represents the request to start a conversation.
+// One other participant and no title makes a direct conversation.
+type ConversationCreateRequest struct {
+	ParticipantIDs []string `json:"participantIds" binding:"required,min=1"`
+	Title          string   `json:"title"`
+	Content        string   `json:"content"`
+}
+
+// MessageCreateRequest represents the request to send a message or course announcement
+type MessageCreateRequest struct {
+	Content string `json:"content" binding:"required"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Notification types, one per kind of event users are notified about
+const (
+	NotificationAssignmentCreated = "assignment_created"
+	NotificationSubmissionGraded  = "submission_graded"
+	NotificationForumReply        = "forum_reply"
+	NotificationAssignmentDueSoon = "assignment_due_soon"
+)
+
+// NotificationTypes lists every notification type users have preferences for
+var NotificationTypes = []string{
+	NotificationAssignmentCreated,
+	NotificationSubmissionGraded,
+	NotificationForumReply,
+	NotificationAssignmentDueSoon,
+}
+
+// Notification is a message to a user about an event. It shows in the user's
+// inbox if they want in-app notifications of its type, and goes out in their
+// next email digest while EmailPending is set.
+type Notification struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	UserID       string     `json:"userId"`
+	Type         string     `json:"type"`
+	Title        string     `json:"title"`
+	Body         string     `json:"body"`
+	EntityType   string     `json:"entityType"`
+	EntityID     string     `json:"entityId"`
+	InApp        bool       `json:"-"`
+	EmailPending bool       `json:"-"`
+	ReadAt       *time.Time `json:"readAt"`
+	EmailedAt    *time.Time `json:"emailedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+// NotificationPreference is how a user wants to be notified of one type of
+// event. Without a stored preference both channels are on.
+type NotificationPreference struct {
+	UserID    string    `json:"-" gorm:"primaryKey"`
+	Type      string    `json:"type" gorm:"primaryKey"`
+	InApp     bool      `json:"inApp"`
+	Email     bool      `json:"email"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// NotificationPreferenceUpdate changes the channels of one notification type;
+// channels left out keep their setting
+type NotificationPreferenceUpdate struct {
+	Type  string `json:"type" binding:"required"`
+	InApp *bool  `json:"inApp"`
+	Email *bool  `json:"email"`
+}
+
+// NotificationPreferencesRequest represents the request to change notification preferences
+type NotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceUpdate `json:"preferences" binding:"required,dive"`
+}
+
+// UnreadCount is the number of unread notifications or messages a user has
+type UnreadCount struct {
+	Count int64 `json:"count"`
+}
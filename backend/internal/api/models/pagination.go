@@ -0,0 +1,25 @@
+package models
+
+// ListQuery represents the pagination, sorting and filtering parameters of a list request
+type ListQuery struct {
+	Page    int
+	Limit   int
+	Sort    string
+	Order   string
+	Filters map[string]string
+}
+
+// Offset returns the number of rows to skip for the requested page
+func (q *ListQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// PageMeta represents pagination metadata returned with list responses
+type PageMeta struct {
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"totalPages"`
+	NextPage   string `json:"nextPage,omitempty"`
+	PrevPage   string `json:"prevPage,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Peer review statuses
+const (
+	PeerReviewAssigned  = "assigned"
+	PeerReviewCompleted = "completed"
+)
+
+// PeerReview is one student's review of another student's submission. Score
+// is on the assignment's scale; when the assignment has a rubric it is
+// computed from the reviewer's rubric Scores.
+type PeerReview struct {
+	ID           string       `json:"id" gorm:"primaryKey"`
+	AssignmentID string       `json:"assignmentId" gorm:"index"`
+	SubmissionID string       `json:"submissionId" gorm:"uniqueIndex:idx_peer_review"`
+	ReviewerID   string       `json:"reviewerId,omitempty" gorm:"uniqueIndex:idx_peer_review;index"`
+	Status       string       `json:"status"`
+	Scores       RubricScores `json:"scores,omitempty" gorm:"type:text"`
+	Score        *float64     `json:"score,omitempty"`
+	Comments     string       `json:"comments"`
+	AssignedAt   time.Time    `json:"assignedAt"`
+	CompletedAt  *time.Time   `json:"completedAt,omitempty"`
+}
+
+// PeerReviewRequest represents a reviewer's review: a level per rubric
+// criterion when the assignment has a rubric, a score otherwise
+type PeerReviewRequest struct {
+	Criteria []RubricCriterionGrade `json:"criteria" binding:"omitempty,dive"`
+	Score    *float64               `json:"score" binding:"omitempty,gte=0"`
+	Comments string                 `json:"comments"`
+}
+
+// PeerReviewTask is a review assigned to the caller, with the anonymous
+// submission to review
+type PeerReviewTask struct {
+	PeerReview
+	Content     string       `json:"content"`
+	Attachments []Attachment `json:"attachments"`
+	Rubric      *Rubric      `json:"rubric,omitempty"`
+}
+
+// PeerReviewSummary aggregates the peer reviews of one submission
+type PeerReviewSummary struct {
+	SubmissionID string       `json:"submissionId"`
+	StudentID    string       `json:"studentId"`
+	Assigned     int          `json:"assigned"`
+	Completed    int          `json:"completed"`
+	AverageScore *float64     `json:"averageScore,omitempty"`
+	Reviews      []PeerReview `json:"reviews"`
+}
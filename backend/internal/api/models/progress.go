@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ActivityCompletion records that a student has completed an activity
+type ActivityCompletion struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	ActivityID  string    `json:"activityId" gorm:"uniqueIndex:idx_activity_completion"`
+	StudentID   string    `json:"studentId" gorm:"uniqueIndex:idx_activity_completion;index"`
+	CourseID    string    `json:"courseId" gorm:"index"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// SectionProgress represents a student's progress through one section
+type SectionProgress struct {
+	SectionID           string   `json:"sectionId"`
+	Title               string   `json:"title"`
+	Order               int      `json:"order"`
+	CompletedActivities int      `json:"completedActivities"`
+	TotalActivities     int      `json:"totalActivities"`
+	Percentage          int      `json:"percentage"`
+	CompletedIDs        []string `json:"completedActivityIds"`
+}
+
+// CourseProgress represents a student's progress through a course
+type CourseProgress struct {
+	CourseID            string            `json:"courseId"`
+	StudentID           string            `json:"studentId"`
+	Progress            int               `json:"progress"`
+	CompletedActivities int               `json:"completedActivities"`
+	TotalActivities     int               `json:"totalActivities"`
+	LastAccessed        *time.Time        `json:"lastAccessed,omitempty"`
+	Sections            []SectionProgress `json:"sections"`
+}
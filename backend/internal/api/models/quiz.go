@@ -0,0 +1,304 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Question types
+const (
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionMultiSelect    = "multi_select"
+	QuestionTrueFalse      = "true_false"
+	QuestionShortAnswer    = "short_answer"
+	QuestionNumeric        = "numeric"
+	QuestionMatching       = "matching"
+)
+
+// Quiz attempt statuses
+const (
+	QuizAttemptInProgress = "in_progress"
+	QuizAttemptSubmitted  = "submitted"
+)
+
+// QuestionOption is a choice of a multiple choice, multi-select or matching
+// question
+type QuestionOption struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// QuestionOptions is a custom type for handling question options in GORM
+type QuestionOptions []QuestionOption
+
+func (o QuestionOptions) Value() (driver.Value, error) {
+	if len(o) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(o)
+}
+
+func (o *QuestionOptions) Scan(value interface{}) error {
+	return scanJSON(value, o, "QuestionOptions")
+}
+
+// Answer is an answer to a question: the key of the question, or a student's
+// response. Which fields are used depends on the question type:
+// OptionIDs for multiple choice and multi-select, Boolean for true/false,
+// Text for short answer, Number for numeric and Pairs (option ID to match
+// target ID) for matching.
+type Answer struct {
+	OptionIDs []string          `json:"optionIds,omitempty"`
+	Boolean   *bool             `json:"boolean,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	Number    *float64          `json:"number,omitempty"`
+	Pairs     map[string]string `json:"pairs,omitempty"`
+}
+
+func (a Answer) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *Answer) Scan(value interface{}) error {
+	return scanJSON(value, a, "Answer")
+}
+
+// Question is a question in a course's question bank
+type Question struct {
+	ID              string          `json:"id" gorm:"primaryKey"`
+	CourseID        string          `json:"courseId" gorm:"index"`
+	Type            string          `json:"type"`
+	Prompt          string          `json:"prompt"`
+	Options         QuestionOptions `json:"options" gorm:"type:text"`
+	MatchTargets    QuestionOptions `json:"matchTargets" gorm:"type:text"`
+	Answer          Answer          `json:"answer" gorm:"type:text"`
+	AcceptedAnswers StringSlice     `json:"acceptedAnswers" gorm:"type:text"`
+	Tolerance       float64         `json:"tolerance"`
+	Points          float64         `json:"points"`
+	Explanation     string          `json:"explanation"`
+	Tags            StringSlice     `json:"tags" gorm:"type:text"`
+	CreatedBy       string          `json:"createdBy"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+}
+
+// QuestionRequest represents the request to create or replace a question
+type QuestionRequest struct {
+	Type            string           `json:"type" binding:"required,oneof=multiple_choice multi_select true_false short_answer numeric matching"`
+	Prompt          string           `json:"prompt" binding:"required"`
+	Options         []QuestionOption `json:"options"`
+	MatchTargets    []QuestionOption `json:"matchTargets"`
+	Answer          Answer           `json:"answer"`
+	AcceptedAnswers []string         `json:"acceptedAnswers"`
+	Tolerance       float64          `json:"tolerance" binding:"gte=0"`
+	Points          float64          `json:"points" binding:"gt=0"`
+	Explanation     string           `json:"explanation"`
+	Tags            []string         `json:"tags"`
+}
+
+// Quiz is a quiz composed from a course's question bank. It is graded
+// through its assignment, which holds the due date, attempt and late rules.
+type Quiz struct {
+	ID                 string      `json:"id" gorm:"primaryKey"`
+	CourseID           string      `json:"courseId" gorm:"index"`
+	AssignmentID       string      `json:"assignmentId"`
+	ActivityID         *string     `json:"activityId,omitempty"`
+	Title              string      `json:"title"`
+	Description        string      `json:"description"`
+	QuestionIDs        StringSlice `json:"questionIds" gorm:"type:text"`
+	RandomCount        int         `json:"randomCount"`
+	RandomTags         StringSlice `json:"randomTags" gorm:"type:text"`
+	ShuffleQuestions   bool        `json:"shuffleQuestions"`
+	ShuffleOptions     bool        `json:"shuffleOptions"`
+	TimeLimitMinutes   int         `json:"timeLimitMinutes"`
+	ShowCorrectAnswers bool        `json:"showCorrectAnswers"`
+	CreatedAt          time.Time   `json:"createdAt"`
+	UpdatedAt          time.Time   `json:"updatedAt"`
+}
+
+// QuizCreateRequest represents the request to create a quiz
+type QuizCreateRequest struct {
+	Title              string   `json:"title" binding:"required"`
+	Description        string   `json:"description"`
+	ActivityID         *string  `json:"activityId"`
+	QuestionIDs        []string `json:"questionIds"`
+	RandomCount        int      `json:"randomCount" binding:"gte=0"`
+	RandomTags         []string `json:"randomTags"`
+	ShuffleQuestions   bool     `json:"shuffleQuestions"`
+	ShuffleOptions     bool     `json:"shuffleOptions"`
+	TimeLimitMinutes   int      `json:"timeLimitMinutes" binding:"gte=0"`
+	ShowCorrectAnswers bool     `json:"showCorrectAnswers"`
+	TotalPoints        int      `json:"totalPoints" binding:"required,gt=0"`
+	DueDate            string   `json:"dueDate" binding:"required"`
+	MaxAttempts        int      `json:"maxAttempts" binding:"gte=0"`
+	AttemptPolicy      string   `json:"attemptPolicy" binding:"omitempty,oneof=latest highest average"`
+	LatePolicy         string   `json:"latePolicy" binding:"omitempty,oneof=accept reject penalty"`
+	LatePenaltyPercent float64  `json:"latePenaltyPercent" binding:"gte=0,lte=100"`
+	GracePeriodMinutes int      `json:"gracePeriodMinutes" binding:"gte=0"`
+}
+
+// AttemptItem is a question drawn for an attempt, with the order its options
+// and match targets are shown in
+type AttemptItem struct {
+	QuestionID  string   `json:"questionId"`
+	OptionOrder []string `json:"optionOrder,omitempty"`
+	TargetOrder []string `json:"targetOrder,omitempty"`
+}
+
+// AttemptItems is a custom type for handling attempt items in GORM
+type AttemptItems []AttemptItem
+
+func (i AttemptItems) Value() (driver.Value, error) {
+	if len(i) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(i)
+}
+
+func (i *AttemptItems) Scan(value interface{}) error {
+	return scanJSON(value, i, "AttemptItems")
+}
+
+// QuizResponses maps question IDs to a student's answers
+type QuizResponses map[string]Answer
+
+func (r QuizResponses) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *QuizResponses) Scan(value interface{}) error {
+	return scanJSON(value, r, "QuizResponses")
+}
+
+// QuestionResult is the outcome of one question in a submitted attempt
+type QuestionResult struct {
+	QuestionID    string  `json:"questionId"`
+	PointsEarned  float64 `json:"pointsEarned"`
+	Points        float64 `json:"points"`
+	Correct       bool    `json:"correct"`
+	CorrectAnswer *Answer `json:"correctAnswer,omitempty"`
+	Explanation   string  `json:"explanation,omitempty"`
+}
+
+// QuestionResults is a custom type for handling question results in GORM
+type QuestionResults []QuestionResult
+
+func (r QuestionResults) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(r)
+}
+
+func (r *QuestionResults) Scan(value interface{}) error {
+	return scanJSON(value, r, "QuestionResults")
+}
+
+// QuizAttempt is a student's attempt at a quiz
+type QuizAttempt struct {
+	ID             string          `json:"id" gorm:"primaryKey"`
+	QuizID         string          `json:"quizId" gorm:"index"`
+	StudentID      string          `json:"studentId" gorm:"index"`
+	Status         string          `json:"status"`
+	Items          AttemptItems    `json:"-" gorm:"type:text"`
+	Responses      QuizResponses   `json:"responses" gorm:"type:text"`
+	Results        QuestionResults `json:"results,omitempty" gorm:"type:text"`
+	PointsEarned   float64         `json:"pointsEarned"`
+	PointsPossible float64         `json:"pointsPossible"`
+	Score          *int            `json:"score,omitempty"`
+	SubmissionID   *string         `json:"submissionId,omitempty"`
+	StartedAt      time.Time       `json:"startedAt"`
+	ExpiresAt      *time.Time      `json:"expiresAt,omitempty"`
+	SubmittedAt    *time.Time      `json:"submittedAt,omitempty"`
+}
+
+// QuizResponsesRequest represents the request to save or submit answers
+type QuizResponsesRequest struct {
+	Responses map[string]Answer `json:"responses"`
+}
+
+// QuizQuestionView is a question as a student sees it during an attempt
+type QuizQuestionView struct {
+	ID           string           `json:"id"`
+	Type         string           `json:"type"`
+	Prompt       string           `json:"prompt"`
+	Options      []QuestionOption `json:"options,omitempty"`
+	MatchTargets []QuestionOption `json:"matchTargets,omitempty"`
+	Points       float64          `json:"points"`
+}
+
+// QuizAttemptView is an attempt with its questions as the student sees them
+type QuizAttemptView struct {
+	QuizAttempt
+	Title     string             `json:"title"`
+	Questions []QuizQuestionView `json:"questions"`
+}
+
+// QTIImportRequest holds the form fields of a QTI package upload: the quiz
+// activity and the settings of the graded quiz created for it
+type QTIImportRequest struct {
+	Title              string  `form:"title"`
+	Description        string  `form:"description"`
+	Order              int     `form:"order" binding:"required"`
+	Visible            bool    `form:"visible"`
+	ShuffleQuestions   bool    `form:"shuffleQuestions"`
+	ShuffleOptions     bool    `form:"shuffleOptions"`
+	TimeLimitMinutes   int     `form:"timeLimitMinutes" binding:"gte=0"`
+	ShowCorrectAnswers bool    `form:"showCorrectAnswers"`
+	TotalPoints        int     `form:"totalPoints" binding:"required,gt=0"`
+	DueDate            string  `form:"dueDate" binding:"required"`
+	MaxAttempts        int     `form:"maxAttempts" binding:"gte=0"`
+	AttemptPolicy      string  `form:"attemptPolicy" binding:"omitempty,oneof=latest highest average"`
+	LatePolicy         string  `form:"latePolicy" binding:"omitempty,oneof=accept reject penalty"`
+	LatePenaltyPercent float64 `form:"latePenaltyPercent" binding:"gte=0,lte=100"`
+	GracePeriodMinutes int     `form:"gracePeriodMinutes" binding:"gte=0"`
+}
+
+// QTIIssue is a problem with one file or item of an imported package
+type QTIIssue struct {
+	File       string `json:"file,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+	Message    string `json:"message"`
+}
+
+// QTIReport summarizes a QTI import: how many items the package held, how
+// many were imported and why the others were not
+type QTIReport struct {
+	Total    int        `json:"total"`
+	Imported int        `json:"imported"`
+	Skipped  []QTIIssue `json:"skipped"`
+}
+
+// QTIImportResult is the outcome of a QTI import: the quiz activity and its
+// quiz, the questions added to the course's question bank and the validation
+// report
+type QTIImportResult struct {
+	Activity  *Activity  `json:"activity"`
+	Quiz      *Quiz      `json:"quiz"`
+	Questions []Question `json:"questions"`
+	Report    *QTIReport `json:"report"`
+}
+
+// scanJSON unmarshals a JSON column into dest
+func scanJSON(value interface{}, dest interface{}, name string) error {
+	if value == nil {
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into %s", value, name)
+	}
+
+	return json.Unmarshal(bytes, dest)
+}
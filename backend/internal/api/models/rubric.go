@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// RubricLevel is a performance level of a rubric criterion
+type RubricLevel struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Points      float64 `json:"points"`
+}
+
+// RubricCriterion is a criterion of a rubric with its performance levels
+type RubricCriterion struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Levels      []RubricLevel `json:"levels"`
+}
+
+// RubricCriteria is a custom type for handling rubric criteria in GORM
+type RubricCriteria []RubricCriterion
+
+func (c RubricCriteria) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(c)
+}
+
+func (c *RubricCriteria) Scan(value interface{}) error {
+	return scanJSON(value, c, "RubricCriteria")
+}
+
+// Rubric is the grading rubric of an assignment. The points of a graded
+// rubric are scaled to the assignment's total points.
+type Rubric struct {
+	ID           string         `json:"id" gorm:"primaryKey"`
+	AssignmentID string         `json:"assignmentId" gorm:"uniqueIndex"`
+	Title        string         `json:"title"`
+	Criteria     RubricCriteria `json:"criteria" gorm:"type:text"`
+	MaxPoints    float64        `json:"maxPoints"`
+	CreatedBy    string         `json:"createdBy"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// RubricScore is the level chosen for one criterion when grading. Titles and
+// points are copied from the rubric so the assessment stays readable if the
+// rubric changes later.
+type RubricScore struct {
+	CriterionID    string  `json:"criterionId"`
+	CriterionTitle string  `json:"criterionTitle"`
+	LevelID        string  `json:"levelId"`
+	LevelTitle     string  `json:"levelTitle"`
+	Points         float64 `json:"points"`
+	MaxPoints      float64 `json:"maxPoints"`
+	Comment        string  `json:"comment,omitempty"`
+}
+
+// RubricScores is a custom type for handling rubric scores in GORM
+type RubricScores []RubricScore
+
+func (s RubricScores) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *RubricScores) Scan(value interface{}) error {
+	return scanJSON(value, s, "RubricScores")
+}
+
+// RubricAssessment is a submission's filled-in rubric
+type RubricAssessment struct {
+	ID             string       `json:"id" gorm:"primaryKey"`
+	SubmissionID   string       `json:"submissionId" gorm:"uniqueIndex"`
+	RubricID       string       `json:"rubricId"`
+	Scores         RubricScores `json:"scores" gorm:"type:text"`
+	PointsEarned   float64      `json:"pointsEarned"`
+	PointsPossible float64      `json:"pointsPossible"`
+	GradedBy       string       `json:"gradedBy"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+}
+
+// RubricLevelRequest represents a performance level in a rubric request.
+// Levels and criteria without an ID get a new one.
+type RubricLevelRequest struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title" binding:"required"`
+	Description string  `json:"description"`
+	Points      float64 `json:"points" binding:"gte=0"`
+}
+
+// RubricCriterionRequest represents a criterion in a rubric request
+type RubricCriterionRequest struct {
+	ID          string               `json:"id"`
+	Title       string               `json:"title" binding:"required"`
+	Description string               `json:"description"`
+	Levels      []RubricLevelRequest `json:"levels" binding:"required,min=1,dive"`
+}
+
+// RubricRequest represents the request to create or replace a rubric
+type RubricRequest struct {
+	Title    string                   `json:"title"`
+	Criteria []RubricCriterionRequest `json:"criteria" binding:"required,min=1,dive"`
+}
+
+// RubricCriterionGrade is the level chosen for a criterion when grading
+type RubricCriterionGrade struct {
+	CriterionID string `json:"criterionId" binding:"required"`
+	LevelID     string `json:"levelId" binding:"required"`
+	Comment     string `json:"comment"`
+}
+
+// RubricGradeRequest represents the request to grade a submission by rubric
+type RubricGradeRequest struct {
+	SubmissionID string                 `json:"submissionId" binding:"required"`
+	Criteria     []RubricCriterionGrade `json:"criteria" binding:"required,min=1,dive"`
+	Feedback     string                 `json:"feedback"`
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/similarity"
+)
+
+// Kinds of submissions checked for similarity
+const (
+	SimilarityKindSubmission     = "submission"
+	SimilarityKindGenerativeTask = "generative_task"
+)
+
+// Similarity check statuses
+const (
+	SimilarityPending   = "pending"
+	SimilarityCompleted = "completed"
+	SimilarityFailed    = "failed"
+)
+
+// SimilaritySpans is a custom type for handling matched spans in GORM
+type SimilaritySpans []similarity.Span
+
+func (s SimilaritySpans) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(s)
+}
+
+func (s *SimilaritySpans) Scan(value interface{}) error {
+	return scanJSON(value, s, "SimilaritySpans")
+}
+
+// SimilarityCheck tracks the similarity check of a submission
+type SimilarityCheck struct {
+	SubmissionID string     `json:"submissionId" gorm:"primaryKey"`
+	Kind         string     `json:"kind"`
+	Status       string     `json:"status"`
+	Error        string     `json:"error,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	CheckedAt    *time.Time `json:"checkedAt,omitempty"`
+}
+
+// SimilarityMatch is a pair of similar submissions by different students.
+// Scores are the percentage of each submission's fingerprint found in the
+// other, and spans the shared passages as byte offsets into its content.
+// A match is stored once, from the side of the later submission.
+type SimilarityMatch struct {
+	ID                  string          `json:"id" gorm:"primaryKey"`
+	Kind                string          `json:"kind"`
+	SubmissionID        string          `json:"submissionId" gorm:"index"`
+	StudentID           string          `json:"studentId"`
+	MatchedSubmissionID string          `json:"matchedSubmissionId" gorm:"index"`
+	MatchedStudentID    string          `json:"matchedStudentId"`
+	Score               float64         `json:"score"`
+	MatchedScore        float64         `json:"matchedScore"`
+	Spans               SimilaritySpans `json:"spans" gorm:"type:text"`
+	MatchedSpans        SimilaritySpans `json:"matchedSpans" gorm:"type:text"`
+	CreatedAt           time.Time       `json:"createdAt"`
+}
+
+// Reversed returns the match seen from the matched submission's side
+func (m SimilarityMatch) Reversed() SimilarityMatch {
+	m.SubmissionID, m.MatchedSubmissionID = m.MatchedSubmissionID, m.SubmissionID
+	m.StudentID, m.MatchedStudentID = m.MatchedStudentID, m.StudentID
+	m.Score, m.MatchedScore = m.MatchedScore, m.Score
+	m.Spans, m.MatchedSpans = m.MatchedSpans, m.Spans
+	return m
+}
+
+// SimilarityReport lists the submissions similar to a submission, most
+// similar first
+type SimilarityReport struct {
+	SimilarityCheck
+	StudentID string            `json:"studentId"`
+	Content   string            `json:"content"`
+	MaxScore  float64           `json:"maxScore"`
+	Matches   []SimilarityMatch `json:"matches"`
+}
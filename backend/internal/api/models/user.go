@@ -2,6 +2,13 @@ package models
 
 import "time"
 
+// User roles
+const (
+	RoleStudent   = "student"
+	RoleProfessor = "professor"
+	RoleAdmin     = "admin"
+)
+
 // UserProfile represents user profile information
 type UserProfile struct {
 	Bio        string `json:"bio"`
@@ -12,15 +19,26 @@ type UserProfile struct {
 
 // APIUser represents a user in the API context
 type APIUser struct {
-	ID        string      `json:"id" gorm:"primaryKey"`
-	Name      string      `json:"name"`
-	Email     string      `json:"email"`
-	Role      string      `json:"role"`
-	Avatar    string      `json:"avatar"`
-	Profile   UserProfile `json:"profile" gorm:"embedded"`
-	CreatedAt time.Time   `json:"createdAt"`
-	LastLogin *time.Time  `json:"lastLogin,omitempty"`
-	UpdatedAt time.Time   `json:"updatedAt"`
+	ID           string      `json:"id" gorm:"primaryKey"`
+	Name         string      `json:"name"`
+	Email        string      `json:"email" gorm:"uniqueIndex"`
+	Role         string      `json:"role"`
+	Avatar       string      `json:"avatar"`
+	Profile      UserProfile `json:"profile" gorm:"embedded"`
+	PasswordHash string      `json:"-"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	LastLogin    *time.Time  `json:"lastLogin,omitempty"`
+	UpdatedAt    time.Time   `json:"updatedAt"`
+}
+
+// UserCreateRequest represents the request to create a user
+type UserCreateRequest struct {
+	Name     string      `json:"name" binding:"required"`
+	Email    string      `json:"email" binding:"required,email"`
+	Role     string      `json:"role" binding:"required,oneof=student professor admin"`
+	Password string      `json:"password" binding:"omitempty,min=8"`
+	Avatar   string      `json:"avatar"`
+	Profile  UserProfile `json:"profile"`
 }
 
 // UserUpdateRequest represents the request to update a user
@@ -29,3 +47,34 @@ type UserUpdateRequest struct {
 	Email   *string      `json:"email,omitempty"`
 	Profile *UserProfile `json:"profile,omitempty"`
 }
+
+// UserRoleUpdateRequest represents the request to change a user's role
+type UserRoleUpdateRequest struct {
+	Role string `json:"role" binding:"required,oneof=student professor admin"`
+}
+
+// RegisterRequest represents the request to register a new account
+type RegisterRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// LoginRequest represents the request to log in
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// AuthResponse represents a successful login or registration
+type AuthResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      *APIUser  `json:"user"`
+}
+
+// StreamTicket is a short-lived token that opens the event stream
+type StreamTicket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
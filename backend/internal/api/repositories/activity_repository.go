@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SectionRepository struct {
@@ -19,6 +20,25 @@ func (r *SectionRepository) GetByCourseID(courseID string) ([]models.Section, er
 	return sections, err
 }
 
+func (r *SectionRepository) GetByID(id string) (*models.Section, error) {
+	var section models.Section
+	err := r.db.First(&section, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
+// GetByIDWithDeleted loads the section even if it is deleted
+func (r *SectionRepository) GetByIDWithDeleted(id string) (*models.Section, error) {
+	var section models.Section
+	err := r.db.Unscoped().First(&section, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &section, nil
+}
+
 func (r *SectionRepository) Create(section *models.Section) error {
 	return r.db.Create(section).Error
 }
@@ -40,6 +60,16 @@ func (r *ActivityRepository) GetByID(id string) (*models.Activity, error) {
 	return &activity, nil
 }
 
+// GetByIDWithDeleted loads the activity even if it is deleted
+func (r *ActivityRepository) GetByIDWithDeleted(id string) (*models.Activity, error) {
+	var activity models.Activity
+	err := r.db.Unscoped().First(&activity, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &activity, nil
+}
+
 func (r *ActivityRepository) Create(activity *models.Activity) error {
 	return r.db.Create(activity).Error
 }
@@ -48,6 +78,52 @@ func (r *ActivityRepository) Update(activity *models.Activity) error {
 	return r.db.Save(activity).Error
 }
 
+// Delete soft-deletes the activity together with its forum posts
 func (r *ActivityRepository) Delete(id string) error {
-	return r.db.Delete(&models.Activity{}, "id = ?", id).Error
+	return softDelete(r.db, &models.Activity{}, id, activityDependents(id))
+}
+
+// Restore brings back the deleted activity and the posts deleted with it
+func (r *ActivityRepository) Restore(id string) error {
+	return restore(r.db, &models.Activity{}, id, activityDependents(id))
+}
+
+type ActivityCompletionRepository struct {
+	db *gorm.DB
+}
+
+func NewActivityCompletionRepository(db *gorm.DB) *ActivityCompletionRepository {
+	return &ActivityCompletionRepository{db: db}
+}
+
+// Get returns the student's completion of the activity
+func (r *ActivityCompletionRepository) Get(activityID string, studentID string) (*models.ActivityCompletion, error) {
+	var completion models.ActivityCompletion
+	err := r.db.First(&completion, "activity_id = ? AND student_id = ?", activityID, studentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &completion, nil
+}
+
+// Create records the completion, leaving an existing record for the same
+// activity and student untouched
+func (r *ActivityCompletionRepository) Create(completion *models.ActivityCompletion) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(completion).Error
+}
+
+func (r *ActivityCompletionRepository) Delete(activityID string, studentID string) error {
+	return r.db.Delete(&models.ActivityCompletion{}, "activity_id = ? AND student_id = ?", activityID, studentID).Error
+}
+
+func (r *ActivityCompletionRepository) GetByCourseID(courseID string) ([]models.ActivityCompletion, error) {
+	var completions []models.ActivityCompletion
+	err := r.db.Where("course_id = ?", courseID).Find(&completions).Error
+	return completions, err
+}
+
+func (r *ActivityCompletionRepository) GetByCourseAndStudent(courseID string, studentID string) ([]models.ActivityCompletion, error) {
+	var completions []models.ActivityCompletion
+	err := r.db.Where("course_id = ? AND student_id = ?", courseID, studentID).Find(&completions).Error
+	return completions, err
 }
@@ -1,8 +1,11 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AssignmentRepository struct {
@@ -13,15 +16,57 @@ func NewAssignmentRepository(db *gorm.DB) *AssignmentRepository {
 	return &AssignmentRepository{db: db}
 }
 
-func (r *AssignmentRepository) GetAll() ([]models.Assignment, error) {
+var assignmentListSpec = listSpec{
+	filters: map[string]string{
+		"courseId": "course_id",
+		"status":   "status",
+		"type":     "type",
+	},
+	sorts: map[string]string{
+		"title":       "title",
+		"dueDate":     "due_date",
+		"totalPoints": "total_points",
+		"createdAt":   "created_at",
+	},
+	defaultSort: "createdAt",
+}
+
+func (r *AssignmentRepository) List(q *models.ListQuery) ([]models.Assignment, int64, error) {
+	var assignments []models.Assignment
+	total, err := paginate(r.db, q, assignmentListSpec, &assignments, "Attachments", "Submissions")
+	return assignments, total, err
+}
+
+func (r *AssignmentRepository) GetByCourseID(courseID string) ([]models.Assignment, error) {
 	var assignments []models.Assignment
-	err := r.db.Preload("Attachments").Preload("Submissions").Find(&assignments).Error
+	err := r.db.Where("course_id = ?", courseID).Find(&assignments).Error
 	return assignments, err
 }
 
 func (r *AssignmentRepository) GetByID(id string) (*models.Assignment, error) {
 	var assignment models.Assignment
-	err := r.db.Preload("Attachments").Preload("Submissions").First(&assignment, "id = ?", id).Error
+	err := r.db.Preload("Attachments").Preload("Submissions.Attachments").Preload("Submissions.Rubric").First(&assignment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// GetDueBetween returns the active assignments due within [from, to) with
+// their submissions
+func (r *AssignmentRepository) GetDueBetween(from time.Time, to time.Time) ([]models.Assignment, error) {
+	var assignments []models.Assignment
+	err := r.db.Preload("Submissions").
+		Where("status = ? AND due_date >= ? AND due_date < ?", "active", from, to).
+		Find(&assignments).Error
+	return assignments, err
+}
+
+// GetByIDForUpdate loads the assignment and locks its row until the
+// surrounding transaction ends
+func (r *AssignmentRepository) GetByIDForUpdate(id string) (*models.Assignment, error) {
+	var assignment models.Assignment
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&assignment, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -37,14 +82,82 @@ func (r *AssignmentRepository) CreateSubmission(submission *models.Submission) e
 }
 
 func (r *AssignmentRepository) UpdateSubmission(submission *models.Submission) error {
-	return r.db.Save(submission).Error
+	return r.db.Omit(clause.Associations).Save(submission).Error
 }
 
 func (r *AssignmentRepository) GetSubmissionByID(id string) (*models.Submission, error) {
 	var submission models.Submission
-	err := r.db.First(&submission, "id = ?", id).Error
+	err := r.db.Preload("Attachments").Preload("Rubric").First(&submission, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &submission, nil
 }
+
+// GetStudentSubmissions returns the student's attempts at the assignment,
+// oldest first
+func (r *AssignmentRepository) GetStudentSubmissions(assignmentID string, studentID string) ([]models.Submission, error) {
+	var submissions []models.Submission
+	err := r.db.Preload("Attachments").Preload("Rubric").
+		Where("assignment_id = ? AND student_id = ?", assignmentID, studentID).
+		Order("attempt ASC, submitted_at ASC").
+		Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *AssignmentRepository) GetExtension(assignmentID string, studentID string) (*models.AssignmentExtension, error) {
+	var extension models.AssignmentExtension
+	err := r.db.First(&extension, "assignment_id = ? AND student_id = ?", assignmentID, studentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &extension, nil
+}
+
+func (r *AssignmentRepository) GetExtensions(assignmentID string) ([]models.AssignmentExtension, error) {
+	var extensions []models.AssignmentExtension
+	err := r.db.Where("assignment_id = ?", assignmentID).Order("student_id").Find(&extensions).Error
+	return extensions, err
+}
+
+// SaveExtension creates the extension or replaces the student's existing one
+func (r *AssignmentRepository) SaveExtension(extension *models.AssignmentExtension) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "assignment_id"}, {Name: "student_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"due_date", "reason", "granted_by", "updated_at"}),
+	}).Create(extension).Error
+}
+
+func (r *AssignmentRepository) GetRubric(assignmentID string) (*models.Rubric, error) {
+	var rubric models.Rubric
+	err := r.db.First(&rubric, "assignment_id = ?", assignmentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rubric, nil
+}
+
+// SaveRubric creates the assignment's rubric or replaces its existing one
+func (r *AssignmentRepository) SaveRubric(rubric *models.Rubric) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "assignment_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "criteria", "max_points", "updated_at"}),
+	}).Create(rubric).Error
+}
+
+func (r *AssignmentRepository) DeleteRubric(assignmentID string) error {
+	return r.db.Delete(&models.Rubric{}, "assignment_id = ?", assignmentID).Error
+}
+
+// SaveRubricAssessment creates the submission's rubric assessment or replaces
+// its existing one
+func (r *AssignmentRepository) SaveRubricAssessment(assessment *models.RubricAssessment) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "submission_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rubric_id", "scores", "points_earned", "points_possible", "graded_by", "updated_at"}),
+	}).Create(assessment).Error
+}
+
+func (r *AssignmentRepository) DeleteRubricAssessment(submissionID string) error {
+	return r.db.Delete(&models.RubricAssessment{}, "submission_id = ?", submissionID).Error
+}
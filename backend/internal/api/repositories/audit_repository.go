@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+)
+
+var auditLogListSpec = listSpec{
+	filters: map[string]string{
+		"actorId":    "actor_id",
+		"action":     "action",
+		"entityType": "entity_type",
+		"entityId":   "entity_id",
+	},
+	params: []string{"from", "to"},
+	sorts: map[string]string{
+		"createdAt": "created_at",
+	},
+	defaultSort: "createdAt",
+}
+
+// AuditRepository stores the audit trail. Entries are only ever appended.
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// List returns a page of entries created within [from, to), where either
+// bound may be nil
+func (r *AuditRepository) List(q *models.ListQuery, from *time.Time, to *time.Time) ([]models.AuditLog, int64, error) {
+	db := r.db
+	if from != nil {
+		db = db.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		db = db.Where("created_at < ?", *to)
+	}
+
+	var entries []models.AuditLog
+	total, err := paginate(db, q, auditLogListSpec, &entries)
+	return entries, total, err
+}
+
+func (r *AuditRepository) Create(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CourseRepository struct {
@@ -13,10 +14,27 @@ func NewCourseRepository(db *gorm.DB) *CourseRepository {
 	return &CourseRepository{db: db}
 }
 
-func (r *CourseRepository) GetAll() ([]models.Course, error) {
+var courseListSpec = listSpec{
+	filters: map[string]string{
+		"category":     "category",
+		"level":        "level",
+		"status":       "status",
+		"instructorId": "instructor_id",
+	},
+	sorts: map[string]string{
+		"title":     "title",
+		"category":  "category",
+		"level":     "level",
+		"startDate": "start_date",
+		"createdAt": "created_at",
+	},
+	defaultSort: "createdAt",
+}
+
+func (r *CourseRepository) List(q *models.ListQuery) ([]models.Course, int64, error) {
 	var courses []models.Course
-	err := r.db.Preload("Resources").Find(&courses).Error
-	return courses, err
+	total, err := paginate(r.db, q, courseListSpec, &courses, "Resources")
+	return courses, total, err
 }
 
 func (r *CourseRepository) GetByID(id string) (*models.Course, error) {
@@ -28,6 +46,16 @@ func (r *CourseRepository) GetByID(id string) (*models.Course, error) {
 	return &course, nil
 }
 
+// GetByIDForUpdate loads the course and locks its row until the surrounding transaction ends
+func (r *CourseRepository) GetByIDForUpdate(id string) (*models.Course, error) {
+	var course models.Course
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&course, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &course, nil
+}
+
 func (r *CourseRepository) Create(course *models.Course) error {
 	return r.db.Create(course).Error
 }
@@ -36,6 +64,23 @@ func (r *CourseRepository) Update(course *models.Course) error {
 	return r.db.Save(course).Error
 }
 
+// GetByIDWithDeleted loads the course even if it is deleted
+func (r *CourseRepository) GetByIDWithDeleted(id string) (*models.Course, error) {
+	var course models.Course
+	err := r.db.Unscoped().Preload("Resources").First(&course, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &course, nil
+}
+
+// Delete soft-deletes the course together with its sections, activities,
+// forum posts, assignments and enrollments
 func (r *CourseRepository) Delete(id string) error {
-	return r.db.Delete(&models.Course{}, "id = ?", id).Error
+	return softDelete(r.db, &models.Course{}, id, courseDependents(id))
+}
+
+// Restore brings back the deleted course and what was deleted with it
+func (r *CourseRepository) Restore(id string) error {
+	return restore(r.db, &models.Course{}, id, courseDependents(id))
 }
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) GetByID(id string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.First(&attachment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *AttachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// LinkToAssignment attaches the uploader's unused uploads to the assignment
+// and returns how many were linked
+func (r *AttachmentRepository) LinkToAssignment(ids []string, uploaderID string, assignmentID string) (int64, error) {
+	return r.link(ids, uploaderID, "assignment_id", assignmentID)
+}
+
+// LinkToSubmission attaches the uploader's unused uploads to the submission
+// and returns how many were linked
+func (r *AttachmentRepository) LinkToSubmission(ids []string, uploaderID string, submissionID string) (int64, error) {
+	return r.link(ids, uploaderID, "submission_id", submissionID)
+}
+
+func (r *AttachmentRepository) GetByIDs(ids []string) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Where("id IN ?", ids).Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *AttachmentRepository) link(ids []string, uploaderID string, column string, value string) (int64, error) {
+	result := r.db.Model(&models.Attachment{}).
+		Where("id IN ? AND uploaded_by = ? AND assignment_id IS NULL AND submission_id IS NULL", ids, uploaderID).
+		Update(column, value)
+	return result.RowsAffected, result.Error
+}
+
+type ResourceRepository struct {
+	db *gorm.DB
+}
+
+func NewResourceRepository(db *gorm.DB) *ResourceRepository {
+	return &ResourceRepository{db: db}
+}
+
+func (r *ResourceRepository) GetByID(id string) (*models.Resource, error) {
+	var resource models.Resource
+	err := r.db.First(&resource, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+func (r *ResourceRepository) Create(resource *models.Resource) error {
+	return r.db.Create(resource).Error
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"gorm.io/gorm"
 )
@@ -26,10 +28,29 @@ func (r *GenerativeTaskRepository) GetByID(id string) (*models.GenerativeTask, e
 	return &task, nil
 }
 
+// CountByStudentSince returns how many tasks were generated for the student
+// since the given time
+func (r *GenerativeTaskRepository) CountByStudentSince(studentID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.GenerativeTask{}).Where("student_id = ? AND created_at >= ?", studentID, since).Count(&count).Error
+	return count, err
+}
+
 func (r *GenerativeTaskRepository) CreateSubmission(submission *models.GenerativeTaskSubmission) error {
 	return r.db.Create(submission).Error
 }
 
+// GetBestScore returns the student's highest score across every task generated for the activity
+func (r *GenerativeTaskRepository) GetBestScore(activityID string, studentID string) (int, error) {
+	var best int
+	err := r.db.Model(&models.GenerativeTaskSubmission{}).
+		Select("COALESCE(MAX(generative_task_submissions.score), 0)").
+		Joins("JOIN generative_tasks ON generative_tasks.id = generative_task_submissions.task_id").
+		Where("generative_tasks.activity_id = ? AND generative_task_submissions.student_id = ?", activityID, studentID).
+		Scan(&best).Error
+	return best, err
+}
+
 func (r *GenerativeTaskRepository) GetSubmissionByID(id string) (*models.GenerativeTaskSubmission, error) {
 	var submission models.GenerativeTaskSubmission
 	err := r.db.Preload("TestCases").First(&submission, "id = ?", id).Error
@@ -38,3 +59,12 @@ func (r *GenerativeTaskRepository) GetSubmissionByID(id string) (*models.Generat
 	}
 	return &submission, nil
 }
+
+// GetActivitySubmissions returns every submission for the tasks generated for the activity
+func (r *GenerativeTaskRepository) GetActivitySubmissions(activityID string) ([]models.GenerativeTaskSubmission, error) {
+	var submissions []models.GenerativeTaskSubmission
+	err := r.db.Joins("JOIN generative_tasks ON generative_tasks.id = generative_task_submissions.task_id").
+		Where("generative_tasks.activity_id = ?", activityID).
+		Find(&submissions).Error
+	return submissions, err
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GradeRepository struct {
@@ -13,20 +14,88 @@ func NewGradeRepository(db *gorm.DB) *GradeRepository {
 	return &GradeRepository{db: db}
 }
 
-func (r *GradeRepository) GetAll() ([]models.Grade, error) {
+var gradeListSpec = listSpec{
+	filters: map[string]string{
+		"courseId":     "course_id",
+		"assignmentId": "assignment_id",
+		"studentId":    "student_id",
+	},
+	sorts: map[string]string{
+		"score":     "score",
+		"gradedAt":  "graded_at",
+		"createdAt": "created_at",
+	},
+	defaultSort: "gradedAt",
+}
+
+func (r *GradeRepository) List(q *models.ListQuery) ([]models.Grade, int64, error) {
 	var grades []models.Grade
-	err := r.db.Find(&grades).Error
-	return grades, err
+	total, err := paginate(r.db, q, gradeListSpec, &grades)
+	return grades, total, err
+}
+
+func (r *GradeRepository) ListByStudentID(studentID string, q *models.ListQuery) ([]models.Grade, int64, error) {
+	var grades []models.Grade
+	total, err := paginate(r.db.Where("student_id = ?", studentID), q, gradeListSpec, &grades)
+	return grades, total, err
+}
+
+func (r *GradeRepository) ListByInstructorID(instructorID string, q *models.ListQuery) ([]models.Grade, int64, error) {
+	var grades []models.Grade
+	courseIDs := r.db.Model(&models.Course{}).Select("id").Where("instructor_id = ?", instructorID)
+	total, err := paginate(r.db.Where("course_id IN (?)", courseIDs), q, gradeListSpec, &grades)
+	return grades, total, err
 }
 
-func (r *GradeRepository) GetByStudentID(studentID string) ([]models.Grade, error) {
+// ListByStudentAndInstructorID returns the student's grades in the courses the
+// instructor teaches
+func (r *GradeRepository) ListByStudentAndInstructorID(
+	studentID string,
+	instructorID string,
+	q *models.ListQuery,
+) ([]models.Grade, int64, error) {
 	var grades []models.Grade
-	err := r.db.Where("student_id = ?", studentID).Find(&grades).Error
+	courseIDs := r.db.Model(&models.Course{}).Select("id").Where("instructor_id = ?", instructorID)
+	db := r.db.Where("student_id = ? AND course_id IN (?)", studentID, courseIDs)
+	total, err := paginate(db, q, gradeListSpec, &grades)
+	return grades, total, err
+}
+
+func (r *GradeRepository) GetByCourseID(courseID string) ([]models.Grade, error) {
+	var grades []models.Grade
+	err := r.db.Where("course_id = ?", courseID).Find(&grades).Error
 	return grades, err
 }
 
-func (r *GradeRepository) Create(grade *models.Grade) error {
-	return r.db.Create(grade).Error
+// GetForUpdate loads the student's grade for the assignment and locks its row
+// until the surrounding transaction ends
+func (r *GradeRepository) GetForUpdate(studentID string, assignmentID string) (*models.Grade, error) {
+	var grade models.Grade
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&grade, "student_id = ? AND assignment_id = ?", studentID, assignmentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &grade, nil
+}
+
+// Upsert creates the student's grade for the assignment, or overwrites the
+// one they have. The grade gets the ID and creation time of the stored row.
+func (r *GradeRepository) Upsert(grade *models.Grade) error {
+	return r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "student_id"}, {Name: "assignment_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"student_name", "assignment_title", "source", "course_id", "course_name", "score",
+				"total_points", "letter_grade", "feedback", "graded_by", "graded_at", "updated_at",
+			}),
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "created_at"}}},
+	).Create(grade).Error
+}
+
+func (r *GradeRepository) Update(grade *models.Grade) error {
+	return r.db.Save(grade).Error
 }
 
 type EnrollmentRepository struct {
@@ -37,12 +106,70 @@ func NewEnrollmentRepository(db *gorm.DB) *EnrollmentRepository {
 	return &EnrollmentRepository{db: db}
 }
 
-func (r *EnrollmentRepository) GetAll() ([]models.Enrollment, error) {
+var enrollmentListSpec = listSpec{
+	filters: map[string]string{
+		"courseId":  "course_id",
+		"studentId": "student_id",
+		"status":    "status",
+	},
+	sorts: map[string]string{
+		"enrolledAt": "enrolled_at",
+		"progress":   "progress",
+		"status":     "status",
+	},
+	defaultSort: "enrolledAt",
+}
+
+func (r *EnrollmentRepository) List(q *models.ListQuery) ([]models.Enrollment, int64, error) {
 	var enrollments []models.Enrollment
-	err := r.db.Find(&enrollments).Error
+	total, err := paginate(r.db, q, enrollmentListSpec, &enrollments)
+	return enrollments, total, err
+}
+
+// ListByInstructorID returns the enrollments in the courses the instructor teaches
+func (r *EnrollmentRepository) ListByInstructorID(instructorID string, q *models.ListQuery) ([]models.Enrollment, int64, error) {
+	var enrollments []models.Enrollment
+	courseIDs := r.db.Model(&models.Course{}).Select("id").Where("instructor_id = ?", instructorID)
+	total, err := paginate(r.db.Where("course_id IN (?)", courseIDs), q, enrollmentListSpec, &enrollments)
+	return enrollments, total, err
+}
+
+func (r *EnrollmentRepository) GetByCourseID(courseID string) ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	err := r.db.Where("course_id = ?", courseID).Find(&enrollments).Error
+	return enrollments, err
+}
+
+func (r *EnrollmentRepository) GetByID(id string) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := r.db.First(&enrollment, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// GetCurrent returns the student's active or waitlisted enrollment in the course
+func (r *EnrollmentRepository) GetCurrent(courseID string, studentID string) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := r.db.Where("course_id = ? AND student_id = ? AND status IN ?", courseID, studentID,
+		[]string{models.EnrollmentActive, models.EnrollmentWaitlisted}).First(&enrollment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+func (r *EnrollmentRepository) GetByCourseAndStatus(courseID string, status string) ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	err := r.db.Where("course_id = ? AND status = ?", courseID, status).Order("enrolled_at ASC").Find(&enrollments).Error
 	return enrollments, err
 }
 
 func (r *EnrollmentRepository) Create(enrollment *models.Enrollment) error {
 	return r.db.Create(enrollment).Error
 }
+
+func (r *EnrollmentRepository) Update(enrollment *models.Enrollment) error {
+	return r.db.Save(enrollment).Error
+}
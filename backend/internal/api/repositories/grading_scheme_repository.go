@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+)
+
+type GradingSchemeRepository struct {
+	db *gorm.DB
+}
+
+func NewGradingSchemeRepository(db *gorm.DB) *GradingSchemeRepository {
+	return &GradingSchemeRepository{db: db}
+}
+
+func (r *GradingSchemeRepository) GetByCourseID(courseID string) (*models.GradingScheme, error) {
+	var scheme models.GradingScheme
+	err := r.db.First(&scheme, "course_id = ?", courseID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &scheme, nil
+}
+
+func (r *GradingSchemeRepository) Save(scheme *models.GradingScheme) error {
+	return r.db.Save(scheme).Error
+}
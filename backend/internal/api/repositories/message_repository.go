@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var conversationListSpec = listSpec{
+	filters: map[string]string{
+		"type":     "type",
+		"courseId": "course_id",
+	},
+	sorts: map[string]string{
+		"lastActivityAt": "last_activity_at",
+		"createdAt":      "created_at",
+		"title":          "title",
+	},
+	defaultSort: "lastActivityAt",
+}
+
+var messageListSpec = listSpec{
+	filters: map[string]string{
+		"senderId": "sender_id",
+	},
+	sorts: map[string]string{
+		"createdAt": "created_at",
+	},
+	defaultSort: "createdAt",
+}
+
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// ListConversations returns a page of the conversations the user takes part in
+func (r *MessageRepository) ListConversations(userID string, q *models.ListQuery) ([]models.Conversation, int64, error) {
+	db := r.db.Where("id IN (?)", r.db.Model(&models.ConversationParticipant{}).
+		Select("conversation_id").Where("user_id = ?", userID))
+
+	var conversations []models.Conversation
+	total, err := paginate(db, q, conversationListSpec, &conversations, "Participants")
+	return conversations, total, err
+}
+
+func (r *MessageRepository) GetConversation(id string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.Preload("Participants").First(&conversation, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// GetDirectConversation returns the direct conversation between two users
+func (r *MessageRepository) GetDirectConversation(userID string, otherID string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.Preload("Participants").
+		Where("type = ?", models.ConversationDirect).
+		Where("id IN (?)", r.db.Model(&models.ConversationParticipant{}).Select("conversation_id").Where("user_id = ?", userID)).
+		Where("id IN (?)", r.db.Model(&models.ConversationParticipant{}).Select("conversation_id").Where("user_id = ?", otherID)).
+		First(&conversation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// GetCourseAnnouncements returns the announcement conversation of the course
+func (r *MessageRepository) GetCourseAnnouncements(courseID string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.Preload("Participants").
+		First(&conversation, "type = ? AND course_id = ?", models.ConversationAnnouncement, courseID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// CreateConversation stores the conversation with its participants
+func (r *MessageRepository) CreateConversation(conversation *models.Conversation) error {
+	return r.db.Create(conversation).Error
+}
+
+// AddParticipants adds users to a conversation, skipping those already in it
+func (r *MessageRepository) AddParticipants(participants []models.ConversationParticipant) error {
+	if len(participants) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&participants).Error
+}
+
+// CreateMessage stores the message and moves its conversation's last activity
+// to the time it was sent
+func (r *MessageRepository) CreateMessage(message *models.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Conversation{}).Where("id = ?", message.ConversationID).
+			Updates(map[string]any{"last_activity_at": message.CreatedAt, "updated_at": message.CreatedAt}).Error
+	})
+}
+
+// ListMessages returns a page of the conversation's history
+func (r *MessageRepository) ListMessages(conversationID string, q *models.ListQuery) ([]models.Message, int64, error) {
+	var messages []models.Message
+	total, err := paginate(r.db.Where("conversation_id = ?", conversationID), q, messageListSpec, &messages)
+	return messages, total, err
+}
+
+// GetLastMessages returns the latest message of each conversation by its ID
+func (r *MessageRepository) GetLastMessages(conversationIDs []string) (map[string]models.Message, error) {
+	last := map[string]models.Message{}
+	if len(conversationIDs) == 0 {
+		return last, nil
+	}
+
+	var messages []models.Message
+	err := r.db.Select("DISTINCT ON (conversation_id) *").
+		Where("conversation_id IN ?", conversationIDs).
+		Order("conversation_id, created_at DESC").
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, message := range messages {
+		last[message.ConversationID] = message
+	}
+	return last, nil
+}
+
+// CountUnread counts the messages others sent the user after their last
+// read receipt, by conversation. Conversations without unread messages are
+// left out, and every conversation is counted if no IDs are given.
+func (r *MessageRepository) CountUnread(userID string, conversationIDs ...string) (map[string]int64, error) {
+	db := r.db.Table("conversation_participants AS p").
+		Select("p.conversation_id, COUNT(m.id) AS count").
+		Joins("JOIN messages AS m ON m.conversation_id = p.conversation_id AND m.sender_id <> p.user_id "+
+			"AND (p.last_read_at IS NULL OR m.created_at > p.last_read_at)").
+		Where("p.user_id = ?", userID).
+		Group("p.conversation_id")
+	if len(conversationIDs) > 0 {
+		db = db.Where("p.conversation_id IN ?", conversationIDs)
+	}
+
+	var rows []struct {
+		ConversationID string
+		Count          int64
+	}
+	err := db.Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.ConversationID] = row.Count
+	}
+	return counts, nil
+}
+
+// MarkRead moves the user's read receipt in the conversation forward to the
+// given time
+func (r *MessageRepository) MarkRead(conversationID string, userID string, at time.Time) error {
+	return r.db.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Where("last_read_at IS NULL OR last_read_at < ?", at).
+		Update("last_read_at", at).Error
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var notificationListSpec = listSpec{
+	filters: map[string]string{
+		"type":       "type",
+		"entityType": "entity_type",
+		"entityId":   "entity_id",
+	},
+	params: []string{"unread"},
+	sorts: map[string]string{
+		"createdAt": "created_at",
+	},
+	defaultSort: "createdAt",
+}
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// ListInbox returns a page of the user's in-app notifications, only the
+// unread ones if unreadOnly is set
+func (r *NotificationRepository) ListInbox(userID string, unreadOnly bool, q *models.ListQuery) ([]models.Notification, int64, error) {
+	db := r.db.Where("user_id = ? AND in_app = ?", userID, true)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	total, err := paginate(db, q, notificationListSpec, &notifications)
+	return notifications, total, err
+}
+
+// CountUnread counts the unread notifications in the user's inbox
+func (r *NotificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND in_app = ? AND read_at IS NULL", userID, true).
+		Count(&count).Error
+	return count, err
+}
+
+// GetInboxItem returns a notification from the user's inbox
+func (r *NotificationRepository) GetInboxItem(id string, userID string) (*models.Notification, error) {
+	var notification models.Notification
+	err := r.db.First(&notification, "id = ? AND user_id = ? AND in_app = ?", id, userID, true).Error
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// CreateMany stores notifications
+func (r *NotificationRepository) CreateMany(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.Create(&notifications).Error
+}
+
+// MarkRead marks the notification read if it is in the user's inbox and unread
+func (r *NotificationRepository) MarkRead(id string, userID string, at time.Time) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND in_app = ? AND read_at IS NULL", id, userID, true).
+		Update("read_at", at).Error
+}
+
+// MarkAllRead marks every unread notification in the user's inbox read and
+// returns how many it marked
+func (r *NotificationRepository) MarkAllRead(userID string, at time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND in_app = ? AND read_at IS NULL", userID, true).
+		Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
+
+// GetPendingEmails returns the notifications waiting for an email digest,
+// grouped by user and oldest first
+func (r *NotificationRepository) GetPendingEmails() ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Where("email_pending = ?", true).Order("user_id, created_at").Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkEmailed records that the notifications went out in a digest
+func (r *NotificationRepository) MarkEmailed(ids []string, at time.Time) error {
+	return r.db.Model(&models.Notification{}).
+		Where("id IN ?", ids).
+		Updates(map[string]any{"email_pending": false, "emailed_at": at}).Error
+}
+
+// GetNotifiedUserIDs returns the users already notified of the entity by a
+// notification of the given type
+func (r *NotificationRepository) GetNotifiedUserIDs(notificationType string, entityID string) ([]string, error) {
+	var userIDs []string
+	err := r.db.Model(&models.Notification{}).
+		Where("type = ? AND entity_id = ?", notificationType, entityID).
+		Distinct().Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// GetPreferences returns the stored preferences of the users for the type
+func (r *NotificationRepository) GetPreferences(userIDs []string, notificationType string) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	err := r.db.Where("user_id IN ? AND type = ?", userIDs, notificationType).Find(&preferences).Error
+	return preferences, err
+}
+
+// GetUserPreferences returns every stored preference of the user
+func (r *NotificationRepository) GetUserPreferences(userID string) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+// SavePreferences inserts or replaces the preferences
+func (r *NotificationRepository) SavePreferences(preferences []models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+	}).Create(&preferences).Error
+}
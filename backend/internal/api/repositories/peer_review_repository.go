@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+)
+
+type PeerReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewPeerReviewRepository(db *gorm.DB) *PeerReviewRepository {
+	return &PeerReviewRepository{db: db}
+}
+
+func (r *PeerReviewRepository) GetByID(id string) (*models.PeerReview, error) {
+	var review models.PeerReview
+	err := r.db.First(&review, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *PeerReviewRepository) GetByAssignmentID(assignmentID string) ([]models.PeerReview, error) {
+	var reviews []models.PeerReview
+	err := r.db.Where("assignment_id = ?", assignmentID).Order("assigned_at, id").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *PeerReviewRepository) GetByReviewer(assignmentID string, reviewerID string) ([]models.PeerReview, error) {
+	var reviews []models.PeerReview
+	err := r.db.Where("assignment_id = ? AND reviewer_id = ?", assignmentID, reviewerID).Order("assigned_at, id").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *PeerReviewRepository) GetBySubmissionIDs(submissionIDs []string) ([]models.PeerReview, error) {
+	var reviews []models.PeerReview
+	err := r.db.Where("submission_id IN ?", submissionIDs).Order("assigned_at, id").Find(&reviews).Error
+	return reviews, err
+}
+
+// IsReviewer reports whether the user was assigned to review the submission
+func (r *PeerReviewRepository) IsReviewer(submissionID string, reviewerID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.PeerReview{}).Where("submission_id = ? AND reviewer_id = ?", submissionID, reviewerID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *PeerReviewRepository) CountByAssignmentID(assignmentID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.PeerReview{}).Where("assignment_id = ?", assignmentID).Count(&count).Error
+	return count, err
+}
+
+// CreateAll stores the reviews in a single insert
+func (r *PeerReviewRepository) CreateAll(reviews []models.PeerReview) error {
+	return r.db.Create(&reviews).Error
+}
+
+func (r *PeerReviewRepository) Update(review *models.PeerReview) error {
+	return r.db.Save(review).Error
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidSort is returned when a list is sorted by an unsupported field
+var ErrInvalidSort = errors.New("unsupported sort field")
+
+// ErrInvalidFilter is returned when a list is filtered by an unsupported field
+var ErrInvalidFilter = errors.New("unsupported filter")
+
+// listSpec maps the public filter and sort names of a list endpoint to columns.
+// params names the other query parameters the endpoint reads itself.
+type listSpec struct {
+	filters     map[string]string
+	params      []string
+	sorts       map[string]string
+	defaultSort string
+}
+
+// paginate applies the filters, sort order and page window of the query to db,
+// storing the requested page in out and returning the total number of matches.
+// Rows that sort equal are ordered by ID, so pages neither repeat nor skip rows.
+func paginate[T any](db *gorm.DB, q *models.ListQuery, spec listSpec, out *[]T, preloads ...string) (int64, error) {
+	for name, value := range q.Filters {
+		column, ok := spec.filters[name]
+		if !ok && !slices.Contains(spec.params, name) {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidFilter, name)
+		}
+		if !ok || value == "" {
+			continue
+		}
+		db = db.Where(fmt.Sprintf("%s = ?", column), value)
+	}
+
+	var total int64
+	err := db.Session(&gorm.Session{}).Model(new(T)).Count(&total).Error
+	if err != nil {
+		return 0, err
+	}
+
+	column := spec.sorts[spec.defaultSort]
+	if q.Sort != "" {
+		var ok bool
+		column, ok = spec.sorts[q.Sort]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s", ErrInvalidSort, q.Sort)
+		}
+	}
+	order := clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: column, Raw: true}, Desc: q.Order == "desc"},
+		{Column: clause.Column{Table: clause.CurrentTable, Name: "id"}},
+	}}
+
+	for _, association := range preloads {
+		db = db.Preload(association)
+	}
+
+	err = db.Order(order).Offset(q.Offset()).Limit(q.Limit).Find(out).Error
+	return total, err
+}
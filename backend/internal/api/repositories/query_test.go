@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder is a logger that keeps the SQL of every statement
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// dryRunDB returns a database that builds statements without running them,
+// and the recorder that receives them
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, recorder
+}
+
+var testListSpec = listSpec{
+	filters: map[string]string{
+		"status":   "status",
+		"category": "category",
+	},
+	params: []string{"search"},
+	sorts: map[string]string{
+		"createdAt": "created_at",
+		"title":     "title",
+	},
+	defaultSort: "createdAt",
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     models.ListQuery
+		wantCount string
+		wantFind  string
+		wantErr   error
+	}{
+		{
+			name:      "default sort with the ID as tiebreaker",
+			query:     models.ListQuery{Page: 1, Limit: 20},
+			wantCount: `SELECT count(*) FROM "courses" WHERE "courses"."deleted_at" IS NULL`,
+			wantFind:  `SELECT * FROM "courses" WHERE "courses"."deleted_at" IS NULL ORDER BY created_at,"courses"."id" LIMIT 20`,
+		},
+		{
+			name:      "sorted descending on a later page",
+			query:     models.ListQuery{Page: 3, Limit: 10, Sort: "title", Order: "desc"},
+			wantCount: `SELECT count(*) FROM "courses" WHERE "courses"."deleted_at" IS NULL`,
+			wantFind:  `SELECT * FROM "courses" WHERE "courses"."deleted_at" IS NULL ORDER BY title DESC,"courses"."id" LIMIT 10 OFFSET 20`,
+		},
+		{
+			name:      "filtered",
+			query:     models.ListQuery{Page: 1, Limit: 20, Filters: map[string]string{"status": "published"}},
+			wantCount: `SELECT count(*) FROM "courses" WHERE status = 'published' AND "courses"."deleted_at" IS NULL`,
+			wantFind:  `SELECT * FROM "courses" WHERE status = 'published' AND "courses"."deleted_at" IS NULL ORDER BY created_at,"courses"."id" LIMIT 20`,
+		},
+		{
+			name:      "empty filters and parameters the caller reads are skipped",
+			query:     models.ListQuery{Page: 1, Limit: 20, Filters: map[string]string{"category": "", "search": "go"}},
+			wantCount: `SELECT count(*) FROM "courses" WHERE "courses"."deleted_at" IS NULL`,
+			wantFind:  `SELECT * FROM "courses" WHERE "courses"."deleted_at" IS NULL ORDER BY created_at,"courses"."id" LIMIT 20`,
+		},
+		{
+			name:    "unknown filter",
+			query:   models.ListQuery{Page: 1, Limit: 20, Filters: map[string]string{"instructor": "x"}},
+			wantErr: ErrInvalidFilter,
+		},
+		{
+			name:    "unknown sort",
+			query:   models.ListQuery{Page: 1, Limit: 20, Sort: "price"},
+			wantErr: ErrInvalidSort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, recorder := dryRunDB(t)
+			var courses []models.Course
+			_, err := paginate(db, &tt.query, testListSpec, &courses)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("paginate: %v", err)
+			}
+			if len(recorder.statements) != 2 {
+				t.Fatalf("statements = %q, want a count and a find", recorder.statements)
+			}
+			if recorder.statements[0] != tt.wantCount {
+				t.Errorf("count = %s\nwant %s", recorder.statements[0], tt.wantCount)
+			}
+			if recorder.statements[1] != tt.wantFind {
+				t.Errorf("find = %s\nwant %s", recorder.statements[1], tt.wantFind)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QuestionRepository struct {
+	db *gorm.DB
+}
+
+func NewQuestionRepository(db *gorm.DB) *QuestionRepository {
+	return &QuestionRepository{db: db}
+}
+
+var questionListSpec = listSpec{
+	filters: map[string]string{
+		"type": "type",
+	},
+	sorts: map[string]string{
+		"createdAt": "created_at",
+		"points":    "points",
+		"type":      "type",
+	},
+	defaultSort: "createdAt",
+}
+
+func (r *QuestionRepository) ListByCourseID(courseID string, q *models.ListQuery) ([]models.Question, int64, error) {
+	var questions []models.Question
+	total, err := paginate(r.db.Where("course_id = ?", courseID), q, questionListSpec, &questions)
+	return questions, total, err
+}
+
+func (r *QuestionRepository) GetByCourseID(courseID string) ([]models.Question, error) {
+	var questions []models.Question
+	err := r.db.Where("course_id = ?", courseID).Order("created_at").Find(&questions).Error
+	return questions, err
+}
+
+func (r *QuestionRepository) GetByID(id string) (*models.Question, error) {
+	var question models.Question
+	err := r.db.First(&question, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+func (r *QuestionRepository) GetByIDs(ids []string) ([]models.Question, error) {
+	var questions []models.Question
+	err := r.db.Where("id IN ?", ids).Find(&questions).Error
+	return questions, err
+}
+
+func (r *QuestionRepository) Create(question *models.Question) error {
+	return r.db.Create(question).Error
+}
+
+func (r *QuestionRepository) Update(question *models.Question) error {
+	return r.db.Save(question).Error
+}
+
+func (r *QuestionRepository) Delete(id string) error {
+	return r.db.Delete(&models.Question{}, "id = ?", id).Error
+}
+
+type QuizRepository struct {
+	db *gorm.DB
+}
+
+func NewQuizRepository(db *gorm.DB) *QuizRepository {
+	return &QuizRepository{db: db}
+}
+
+func (r *QuizRepository) GetByID(id string) (*models.Quiz, error) {
+	var quiz models.Quiz
+	err := r.db.First(&quiz, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &quiz, nil
+}
+
+func (r *QuizRepository) GetByCourseID(courseID string) ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	err := r.db.Where("course_id = ?", courseID).Order("created_at").Find(&quizzes).Error
+	return quizzes, err
+}
+
+func (r *QuizRepository) GetByActivityID(activityID string) ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	err := r.db.Where("activity_id = ?", activityID).Order("created_at").Find(&quizzes).Error
+	return quizzes, err
+}
+
+func (r *QuizRepository) Create(quiz *models.Quiz) error {
+	return r.db.Create(quiz).Error
+}
+
+func (r *QuizRepository) GetAttemptByID(id string) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	err := r.db.First(&attempt, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// GetAttemptByIDForUpdate loads the attempt and locks its row until the
+// surrounding transaction ends
+func (r *QuizRepository) GetAttemptByIDForUpdate(id string) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&attempt, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// GetOpenAttempt returns the student's attempt at the quiz that has not been
+// submitted yet
+func (r *QuizRepository) GetOpenAttempt(quizID string, studentID string) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	err := r.db.Where("quiz_id = ? AND student_id = ? AND status = ?", quizID, studentID, models.QuizAttemptInProgress).
+		First(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+func (r *QuizRepository) GetAttempts(quizID string, studentID string) ([]models.QuizAttempt, error) {
+	var attempts []models.QuizAttempt
+	err := r.db.Where("quiz_id = ? AND student_id = ?", quizID, studentID).Order("started_at").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *QuizRepository) CreateAttempt(attempt *models.QuizAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *QuizRepository) UpdateAttempt(attempt *models.QuizAttempt) error {
+	return r.db.Save(attempt).Error
+}
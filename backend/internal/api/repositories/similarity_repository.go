@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+)
+
+type SimilarityRepository struct {
+	db *gorm.DB
+}
+
+func NewSimilarityRepository(db *gorm.DB) *SimilarityRepository {
+	return &SimilarityRepository{db: db}
+}
+
+func (r *SimilarityRepository) GetCheck(submissionID string) (*models.SimilarityCheck, error) {
+	var check models.SimilarityCheck
+	err := r.db.First(&check, "submission_id = ?", submissionID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+func (r *SimilarityRepository) SaveCheck(check *models.SimilarityCheck) error {
+	return r.db.Save(check).Error
+}
+
+// GetMatches returns the matches the submission takes part in on either side
+func (r *SimilarityRepository) GetMatches(submissionID string) ([]models.SimilarityMatch, error) {
+	var matches []models.SimilarityMatch
+	err := r.db.Where("submission_id = ? OR matched_submission_id = ?", submissionID, submissionID).
+		Find(&matches).Error
+	return matches, err
+}
+
+// CompleteCheck replaces the matches found for the submission and stores its
+// finished check
+func (r *SimilarityRepository) CompleteCheck(check *models.SimilarityCheck, matches []models.SimilarityMatch) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("submission_id = ?", check.SubmissionID).Delete(&models.SimilarityMatch{}).Error
+		if err != nil {
+			return err
+		}
+		if len(matches) > 0 {
+			err = tx.Create(&matches).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Save(check).Error
+	})
+}
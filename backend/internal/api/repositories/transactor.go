@@ -0,0 +1,64 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// TxRepositories holds repositories that share one database transaction
+type TxRepositories struct {
+	Courses             *CourseRepository
+	Sections            *SectionRepository
+	Enrollments         *EnrollmentRepository
+	Assignments         *AssignmentRepository
+	Attachments         *AttachmentRepository
+	Resources           *ResourceRepository
+	Activities          *ActivityRepository
+	ActivityCompletions *ActivityCompletionRepository
+	Questions           *QuestionRepository
+	Quizzes             *QuizRepository
+	PeerReviews         *PeerReviewRepository
+	GenerativeTasks     *GenerativeTaskRepository
+	Similarity          *SimilarityRepository
+	Grades              *GradeRepository
+	GradingSchemes      *GradingSchemeRepository
+	Forum               *ForumRepository
+	Notifications       *NotificationRepository
+	Messages            *MessageRepository
+	Users               *UserRepository
+	Audit               *AuditRepository
+}
+
+// Transactor runs a unit of work inside a database transaction
+type Transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// Do commits the transaction if fn returns nil and rolls it back otherwise
+func (t *Transactor) Do(fn func(repos *TxRepositories) error) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&TxRepositories{
+			Courses:             NewCourseRepository(tx),
+			Sections:            NewSectionRepository(tx),
+			Enrollments:         NewEnrollmentRepository(tx),
+			Assignments:         NewAssignmentRepository(tx),
+			Attachments:         NewAttachmentRepository(tx),
+			Resources:           NewResourceRepository(tx),
+			Activities:          NewActivityRepository(tx),
+			ActivityCompletions: NewActivityCompletionRepository(tx),
+			Questions:           NewQuestionRepository(tx),
+			Quizzes:             NewQuizRepository(tx),
+			PeerReviews:         NewPeerReviewRepository(tx),
+			GenerativeTasks:     NewGenerativeTaskRepository(tx),
+			Similarity:          NewSimilarityRepository(tx),
+			Grades:              NewGradeRepository(tx),
+			GradingSchemes:      NewGradingSchemeRepository(tx),
+			Forum:               NewForumRepository(tx),
+			Notifications:       NewNotificationRepository(tx),
+			Messages:            NewMessageRepository(tx),
+			Users:               NewUserRepository(tx),
+			Audit:               NewAuditRepository(tx),
+		})
+	})
+}
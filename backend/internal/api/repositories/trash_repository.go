@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"gorm.io/gorm"
+)
+
+// ErrNotDeleted is returned when restoring something that is not deleted
+var ErrNotDeleted = errors.New("the item is not deleted")
+
+// Subqueries selecting the activities and forums of a course, deleted or not
+const (
+	courseActivities = "SELECT activities.id FROM activities JOIN sections ON sections.id = activities.section_id WHERE sections.course_id = ?"
+	courseSections   = "SELECT id FROM sections WHERE course_id = ?"
+)
+
+// postReplies selects the replies below a forum post at any depth, deleted or not
+const postReplies = "WITH RECURSIVE replies AS (" +
+	"SELECT id FROM forum_posts WHERE parent_id = ? " +
+	"UNION ALL SELECT forum_posts.id FROM forum_posts JOIN replies ON forum_posts.parent_id = replies.id" +
+	") SELECT id FROM replies"
+
+// dependent selects the rows that are deleted and restored along with a parent
+type dependent struct {
+	model any
+	query string
+	args  []any
+}
+
+func courseDependents(courseID string) []dependent {
+	return []dependent{
+		{&models.Section{}, "course_id = ?", []any{courseID}},
+		{&models.Activity{}, "section_id IN (" + courseSections + ")", []any{courseID}},
+		{&models.ForumPost{}, "forum_id IN (" + courseActivities + ")", []any{courseID}},
+		{&models.Assignment{}, "course_id = ?", []any{courseID}},
+		{&models.Enrollment{}, "course_id = ?", []any{courseID}},
+	}
+}
+
+func activityDependents(activityID string) []dependent {
+	return []dependent{
+		{&models.ForumPost{}, "forum_id = ?", []any{activityID}},
+	}
+}
+
+func forumPostDependents(postID string) []dependent {
+	return []dependent{
+		{&models.ForumPost{}, "id IN (" + postReplies + ")", []any{postID}},
+	}
+}
+
+// softDelete marks the row and its dependents that are not deleted yet as
+// deleted at the same time, which restore later matches them by
+func softDelete(db *gorm.DB, model any, id string, dependents []dependent) error {
+	at := time.Now().UTC().Truncate(time.Microsecond)
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(model).Where("id = ?", id).UpdateColumn("deleted_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		for _, d := range dependents {
+			err := tx.Model(d.model).Where(d.query, d.args...).UpdateColumn("deleted_at", at).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// restore brings back the deleted row and the dependents deleted with it.
+// Dependents deleted on their own before stay deleted.
+func restore(db *gorm.DB, model any, id string, dependents []dependent) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var deletedAt sql.NullTime
+		result := tx.Unscoped().Model(model).Select("deleted_at").Where("id = ?", id).Limit(1).Scan(&deletedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if !deletedAt.Valid {
+			return ErrNotDeleted
+		}
+
+		err := tx.Unscoped().Model(model).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+		for _, d := range dependents {
+			err = tx.Unscoped().Model(d.model).
+				Where(d.query, d.args...).
+				Where("deleted_at = ?", deletedAt.Time).
+				UpdateColumn("deleted_at", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TrashRepository lists and purges soft-deleted rows
+type TrashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) *TrashRepository {
+	return &TrashRepository{db: db}
+}
+
+// GetDeletedCourses returns the deleted courses of the instructor, or of
+// every instructor if instructorID is empty, most recently deleted first
+func (r *TrashRepository) GetDeletedCourses(instructorID string) ([]models.Course, error) {
+	db := r.db.Unscoped().Where("deleted_at IS NOT NULL")
+	if instructorID != "" {
+		db = db.Where("instructor_id = ?", instructorID)
+	}
+
+	var courses []models.Course
+	err := db.Order("deleted_at DESC").Find(&courses).Error
+	return courses, err
+}
+
+// GetCourseTrash returns the deleted content of the course
+func (r *TrashRepository) GetCourseTrash(courseID string) (*models.CourseTrash, error) {
+	trash := &models.CourseTrash{CourseID: courseID}
+	deleted := r.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC")
+
+	err := deleted.Where("course_id = ?", courseID).Find(&trash.Sections).Error
+	if err != nil {
+		return nil, err
+	}
+	err = deleted.Where("section_id IN ("+courseSections+")", courseID).Find(&trash.Activities).Error
+	if err != nil {
+		return nil, err
+	}
+	err = deleted.Where("course_id = ?", courseID).Find(&trash.Assignments).Error
+	if err != nil {
+		return nil, err
+	}
+	err = deleted.Where("forum_id IN ("+courseActivities+")", courseID).Find(&trash.ForumPosts).Error
+	if err != nil {
+		return nil, err
+	}
+	return trash, nil
+}
+
+// Purge permanently deletes everything deleted before the cutoff, together
+// with the rows that only belong to it:
+//   - the completions of purged activities, and the generative tasks generated
+//     from them with their submissions and similarity results
+//   - the submissions, similarity results, extensions, rubrics, peer reviews,
+//     quizzes and quiz attempts of purged assignments
+//   - the question banks, quizzes and resources of purged courses
+//   - the attachments of purged assignments and submissions
+//
+// It returns the storage keys of the purged resources and attachments, whose
+// files the caller deletes once the purge is committed. Grades are kept.
+func (r *TrashRepository) Purge(cutoff time.Time) (*models.TrashPurge, []string, error) {
+	purged := &models.TrashPurge{}
+	var storageKeys []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		expired := "deleted_at IS NOT NULL AND deleted_at < ?"
+		purgedCourses := tx.Unscoped().Model(&models.Course{}).Select("id").Where(expired, cutoff)
+		purgedActivities := tx.Unscoped().Model(&models.Activity{}).Select("id").Where(expired, cutoff)
+		purgedAssignments := tx.Unscoped().Model(&models.Assignment{}).Select("id").Where(expired, cutoff)
+		purgedSubmissions := tx.Model(&models.Submission{}).Select("id").Where("assignment_id IN (?)", purgedAssignments)
+		purgedQuizzes := tx.Model(&models.Quiz{}).Select("id").
+			Where("assignment_id IN (?) OR course_id IN (?)", purgedAssignments, purgedCourses)
+		purgedTasks := tx.Model(&models.GenerativeTask{}).Select("id").Where("activity_id IN (?)", purgedActivities)
+		purgedTaskSubmissions := tx.Model(&models.GenerativeTaskSubmission{}).Select("id").Where("task_id IN (?)", purgedTasks)
+
+		files := []struct {
+			model any
+			query string
+			args  []any
+		}{
+			{&models.Attachment{}, "assignment_id IN (?) OR submission_id IN (?)", []any{purgedAssignments, purgedSubmissions}},
+			{&models.Resource{}, "course_id IN (?)", []any{purgedCourses}},
+		}
+		for _, f := range files {
+			var keys []string
+			err := tx.Model(f.model).Where(f.query, f.args...).Where("storage_key <> ''").Pluck("storage_key", &keys).Error
+			if err != nil {
+				return err
+			}
+			storageKeys = append(storageKeys, keys...)
+			err = tx.Where(f.query, f.args...).Delete(f.model).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		owned := []struct {
+			model any
+			query string
+			args  []any
+		}{
+			{&models.ActivityCompletion{}, "activity_id IN (?)", []any{purgedActivities}},
+			{&models.SimilarityMatch{}, "submission_id IN (?) OR matched_submission_id IN (?)", []any{purgedTaskSubmissions, purgedTaskSubmissions}},
+			{&models.SimilarityCheck{}, "submission_id IN (?)", []any{purgedTaskSubmissions}},
+			{&models.TestCase{}, "submission_id IN (?)", []any{purgedTaskSubmissions}},
+			{&models.GenerativeTaskSubmission{}, "task_id IN (?)", []any{purgedTasks}},
+			{&models.GenerativeTask{}, "activity_id IN (?)", []any{purgedActivities}},
+			{&models.QuizAttempt{}, "quiz_id IN (?)", []any{purgedQuizzes}},
+			{&models.Quiz{}, "id IN (?)", []any{purgedQuizzes}},
+			{&models.Question{}, "course_id IN (?)", []any{purgedCourses}},
+			{&models.RubricAssessment{}, "submission_id IN (?)", []any{purgedSubmissions}},
+			{&models.SimilarityMatch{}, "submission_id IN (?) OR matched_submission_id IN (?)", []any{purgedSubmissions, purgedSubmissions}},
+			{&models.SimilarityCheck{}, "submission_id IN (?)", []any{purgedSubmissions}},
+			{&models.PeerReview{}, "assignment_id IN (?)", []any{purgedAssignments}},
+			{&models.Submission{}, "assignment_id IN (?)", []any{purgedAssignments}},
+			{&models.AssignmentExtension{}, "assignment_id IN (?)", []any{purgedAssignments}},
+			{&models.Rubric{}, "assignment_id IN (?)", []any{purgedAssignments}},
+		}
+		for _, o := range owned {
+			err := tx.Where(o.query, o.args...).Delete(o.model).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		counts := []struct {
+			model any
+			count *int64
+		}{
+			{&models.ForumPost{}, &purged.ForumPosts},
+			{&models.Activity{}, &purged.Activities},
+			{&models.Section{}, &purged.Sections},
+			{&models.Assignment{}, &purged.Assignments},
+			{&models.Enrollment{}, &purged.Enrollments},
+			{&models.Course{}, &purged.Courses},
+		}
+		for _, c := range counts {
+			result := tx.Unscoped().Where(expired, cutoff).Delete(c.model)
+			if result.Error != nil {
+				return result.Error
+			}
+			*c.count = result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return purged, storageKeys, nil
+}
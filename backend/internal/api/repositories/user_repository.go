@@ -1,8 +1,11 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ForumRepository struct {
@@ -13,18 +16,192 @@ func NewForumRepository(db *gorm.DB) *ForumRepository {
 	return &ForumRepository{db: db}
 }
 
-func (r *ForumRepository) GetByForumID(forumID string) ([]models.ForumPost, error) {
+var forumPostListSpec = listSpec{
+	filters: map[string]string{
+		"authorId": "author_id",
+		"isPinned": "is_pinned",
+	},
+	params: []string{"forumId"},
+	sorts: map[string]string{
+		"title":     "title",
+		"createdAt": "created_at",
+		"updatedAt": "updated_at",
+		"votes":     "vote_count",
+		"activity":  "last_activity_at",
+	},
+	defaultSort: "createdAt",
+}
+
+// ListByForumID returns a page of the forum's threads, pinned ones first,
+// without their replies
+func (r *ForumRepository) ListByForumID(forumID string, q *models.ListQuery) ([]models.ForumPost, int64, error) {
+	var posts []models.ForumPost
+	db := r.db.Where("forum_id = ? AND parent_id IS NULL", forumID).Order("is_pinned DESC")
+	total, err := paginate(db, q, forumPostListSpec, &posts)
+	return posts, total, err
+}
+
+// ListUnanswered returns a page of the forums' threads that have no accepted
+// answer
+func (r *ForumRepository) ListUnanswered(forumIDs []string, q *models.ListQuery) ([]models.ForumPost, int64, error) {
 	var posts []models.ForumPost
-	err := r.db.Preload("Replies").Where("forum_id = ? AND parent_id IS NULL", forumID).Find(&posts).Error
-	return posts, err
+	db := r.db.Where("forum_id IN ? AND parent_id IS NULL AND accepted_answer_id IS NULL", forumIDs)
+	total, err := paginate(db, q, forumPostListSpec, &posts)
+	return posts, total, err
+}
+
+// GetThreadReplies returns every reply in the threads, oldest first
+func (r *ForumRepository) GetThreadReplies(threadIDs []string) ([]models.ForumPost, error) {
+	var replies []models.ForumPost
+	if len(threadIDs) == 0 {
+		return replies, nil
+	}
+	err := r.db.Where("thread_id IN ? AND parent_id IS NOT NULL", threadIDs).Order("created_at, id").Find(&replies).Error
+	return replies, err
 }
 
 func (r *ForumRepository) Create(post *models.ForumPost) error {
 	return r.db.Create(post).Error
 }
 
+// CreateReply saves the reply and marks its thread as last active when the
+// reply was made
 func (r *ForumRepository) CreateReply(reply *models.ForumPost) error {
-	return r.db.Create(reply).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reply).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ForumPost{}).Where("id = ?", reply.ThreadID).
+			UpdateColumn("last_activity_at", reply.CreatedAt).Error
+	})
+}
+
+func (r *ForumRepository) GetByID(id string) (*models.ForumPost, error) {
+	var post models.ForumPost
+	err := r.db.First(&post, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Edit saves the edited post together with the revision it replaced
+func (r *ForumRepository) Edit(post *models.ForumPost, revision *models.ForumPostRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return tx.Model(post).Select("title", "content", "edited_at", "updated_at").Updates(post).Error
+	})
+}
+
+// SetFlags saves whether the post is pinned and locked
+func (r *ForumRepository) SetFlags(post *models.ForumPost) error {
+	return r.db.Model(post).Select("is_pinned", "is_locked", "updated_at").Updates(post).Error
+}
+
+// SetAcceptedAnswer saves which reply answers the thread
+func (r *ForumRepository) SetAcceptedAnswer(thread *models.ForumPost) error {
+	return r.db.Model(thread).Select("accepted_answer_id", "updated_at").Updates(thread).Error
+}
+
+// ClearDeletedAnswer takes back the thread's accepted answer if the answer has
+// been deleted
+func (r *ForumRepository) ClearDeletedAnswer(threadID string) error {
+	deleted := r.db.Unscoped().Model(&models.ForumPost{}).Select("id").
+		Where("thread_id = ? AND deleted_at IS NOT NULL", threadID)
+	return r.db.Model(&models.ForumPost{}).
+		Where("id = ? AND accepted_answer_id IN (?)", threadID, deleted).
+		UpdateColumn("accepted_answer_id", nil).Error
+}
+
+// Vote records the user's upvote of the post. It reports false if the user
+// had already upvoted it.
+func (r *ForumRepository) Vote(postID string, userID string) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		vote := &models.ForumPostVote{PostID: postID, UserID: userID, CreatedAt: time.Now()}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(vote)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		added = true
+		return tx.Model(&models.ForumPost{}).Where("id = ?", postID).
+			UpdateColumn("vote_count", gorm.Expr("vote_count + 1")).Error
+	})
+	return added, err
+}
+
+// Unvote takes back the user's upvote of the post. It reports false if the
+// user had not upvoted it.
+func (r *ForumRepository) Unvote(postID string, userID string) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&models.ForumPostVote{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+		return tx.Model(&models.ForumPost{}).Where("id = ?", postID).
+			UpdateColumn("vote_count", gorm.Expr("vote_count - 1")).Error
+	})
+	return removed, err
+}
+
+// GetVotedPostIDs returns which of the posts the user has upvoted
+func (r *ForumRepository) GetVotedPostIDs(userID string, postIDs []string) (map[string]bool, error) {
+	voted := map[string]bool{}
+	if len(postIDs) == 0 {
+		return voted, nil
+	}
+	var ids []string
+	err := r.db.Model(&models.ForumPostVote{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	for _, id := range ids {
+		voted[id] = true
+	}
+	return voted, err
+}
+
+// GetRevisions returns the post's earlier versions, oldest first
+func (r *ForumRepository) GetRevisions(postID string) ([]models.ForumPostRevision, error) {
+	var revisions []models.ForumPostRevision
+	err := r.db.Where("post_id = ?", postID).Order("edited_at").Find(&revisions).Error
+	return revisions, err
+}
+
+// GetByIDWithDeleted loads the post even if it is deleted
+func (r *ForumRepository) GetByIDWithDeleted(id string) (*models.ForumPost, error) {
+	var post models.ForumPost
+	err := r.db.Unscoped().First(&post, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Delete soft-deletes the post together with the replies below it and
+// records who deleted it
+func (r *ForumRepository) Delete(id string, deletedBy string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := softDelete(tx, &models.ForumPost{}, id, forumPostDependents(id))
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.ForumPost{}).Where("id = ?", id).UpdateColumn("deleted_by", deletedBy).Error
+	})
+}
+
+// Restore brings back the deleted post and the replies deleted with it
+func (r *ForumRepository) Restore(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := restore(tx, &models.ForumPost{}, id, forumPostDependents(id))
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.ForumPost{}).Where("id = ?", id).UpdateColumn("deleted_by", nil).Error
+	})
 }
 
 type UserRepository struct {
@@ -35,10 +212,24 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-func (r *UserRepository) GetAll() ([]models.APIUser, error) {
+var userListSpec = listSpec{
+	filters: map[string]string{
+		"role":  "role",
+		"email": "email",
+	},
+	sorts: map[string]string{
+		"name":      "name",
+		"email":     "email",
+		"createdAt": "created_at",
+		"lastLogin": "last_login",
+	},
+	defaultSort: "name",
+}
+
+func (r *UserRepository) List(q *models.ListQuery) ([]models.APIUser, int64, error) {
 	var users []models.APIUser
-	err := r.db.Find(&users).Error
-	return users, err
+	total, err := paginate(r.db, q, userListSpec, &users)
+	return users, total, err
 }
 
 func (r *UserRepository) GetByID(id string) (*models.APIUser, error) {
@@ -50,6 +241,13 @@ func (r *UserRepository) GetByID(id string) (*models.APIUser, error) {
 	return &user, nil
 }
 
+// GetByIDs returns the users with the given IDs that exist
+func (r *UserRepository) GetByIDs(ids []string) ([]models.APIUser, error) {
+	var users []models.APIUser
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 func (r *UserRepository) GetByEmail(email string) (*models.APIUser, error) {
 	var user models.APIUser
 	err := r.db.First(&user, "email = ?", email).Error
@@ -1,29 +1,84 @@
 package services
 
 import (
+	"errors"
 	"time"
 
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
 )
 
 type ActivityService struct {
-	sectionRepo  *repositories.SectionRepository
-	activityRepo *repositories.ActivityRepository
+	courseRepo      *repositories.CourseRepository
+	sectionRepo     *repositories.SectionRepository
+	activityRepo    *repositories.ActivityRepository
+	progressService *ProgressService
+	transactor      *repositories.Transactor
 }
 
-func NewActivityService(sectionRepo *repositories.SectionRepository, activityRepo *repositories.ActivityRepository) *ActivityService {
+func NewActivityService(
+	courseRepo *repositories.CourseRepository,
+	sectionRepo *repositories.SectionRepository,
+	activityRepo *repositories.ActivityRepository,
+	progressService *ProgressService,
+	transactor *repositories.Transactor,
+) *ActivityService {
 	return &ActivityService{
-		sectionRepo:  sectionRepo,
-		activityRepo: activityRepo,
+		courseRepo:      courseRepo,
+		sectionRepo:     sectionRepo,
+		activityRepo:    activityRepo,
+		progressService: progressService,
+		transactor:      transactor,
 	}
 }
 
-func (s *ActivityService) GetSectionsByCourseID(courseID string) ([]models.Section, error) {
-	return s.sectionRepo.GetByCourseID(courseID)
+// GetSectionInstructorID returns the instructor of the course the section belongs to
+func (s *ActivityService) GetSectionInstructorID(sectionID string) (string, error) {
+	section, err := s.sectionRepo.GetByID(sectionID)
+	if err != nil {
+		return "", err
+	}
+
+	course, err := s.courseRepo.GetByID(section.CourseID)
+	if err != nil {
+		return "", err
+	}
+	return course.InstructorID, nil
+}
+
+// GetActivityInstructorID returns the instructor of the course the activity belongs to
+func (s *ActivityService) GetActivityInstructorID(activityID string) (string, error) {
+	activity, err := s.activityRepo.GetByID(activityID)
+	if err != nil {
+		return "", err
+	}
+	return s.GetSectionInstructorID(activity.SectionID)
 }
 
-func (s *ActivityService) CreateSection(courseID string, req *models.SectionCreateRequest) (*models.Section, error) {
+// GetSectionsByCourseID returns the course's sections with each activity's
+// Completed flag set for the viewer
+func (s *ActivityService) GetSectionsByCourseID(courseID string, viewer *models.APIUser) ([]models.Section, error) {
+	sections, err := s.sectionRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sections {
+		err = s.progressService.MarkCompleted(courseID, viewer.ID, sections[i].Activities)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sections, nil
+}
+
+func (s *ActivityService) CreateSection(
+	courseID string,
+	req *models.SectionCreateRequest,
+	source *models.AuditSource,
+) (*models.Section, error) {
 	section := &models.Section{
 		ID:          GenerateID(),
 		CourseID:    courseID,
@@ -36,7 +91,13 @@ func (s *ActivityService) CreateSection(courseID string, req *models.SectionCrea
 		UpdatedAt:   time.Now(),
 	}
 
-	err := s.sectionRepo.Create(section)
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Sections.Create(section)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "section", section.ID, nil, section)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -44,11 +105,38 @@ func (s *ActivityService) CreateSection(courseID string, req *models.SectionCrea
 	return section, nil
 }
 
-func (s *ActivityService) GetActivityByID(activityID string) (*models.Activity, error) {
-	return s.activityRepo.GetByID(activityID)
+// GetActivityByID returns the activity as seen by the viewer and records the
+// access on their enrollment
+func (s *ActivityService) GetActivityByID(activityID string, viewer *models.APIUser) (*models.Activity, error) {
+	activity, err := s.activityRepo.GetByID(activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	section, err := s.sectionRepo.GetByID(activity.SectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := []models.Activity{*activity}
+	err = s.progressService.MarkCompleted(section.CourseID, viewer.ID, activities)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.progressService.RecordAccess(activity, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return &activities[0], nil
 }
 
-func (s *ActivityService) CreateActivity(sectionID string, req *models.ActivityCreateRequest) (*models.Activity, error) {
+func (s *ActivityService) CreateActivity(
+	sectionID string,
+	req *models.ActivityCreateRequest,
+	source *models.AuditSource,
+) (*models.Activity, error) {
 	activity := &models.Activity{
 		ID:             GenerateID(),
 		SectionID:      sectionID,
@@ -66,7 +154,18 @@ func (s *ActivityService) CreateActivity(sectionID string, req *models.ActivityC
 		UpdatedAt:      time.Now(),
 	}
 
-	err := s.activityRepo.Create(activity)
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Activities.Create(activity)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "activity", activity.ID, nil, activity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recalculateProgress(sectionID)
 	if err != nil {
 		return nil, err
 	}
@@ -74,12 +173,46 @@ func (s *ActivityService) CreateActivity(sectionID string, req *models.ActivityC
 	return activity, nil
 }
 
-func (s *ActivityService) UpdateActivity(activityID string, req *models.ActivityUpdateRequest) (*models.Activity, error) {
-	activity, err := s.activityRepo.GetByID(activityID)
+func (s *ActivityService) UpdateActivity(
+	activityID string,
+	req *models.ActivityUpdateRequest,
+	source *models.AuditSource,
+) (*models.Activity, error) {
+	var activity *models.Activity
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		var err error
+		activity, err = tx.Activities.GetByID(activityID)
+		if err != nil {
+			return err
+		}
+		before, err := snapshot(activity)
+		if err != nil {
+			return err
+		}
+
+		applyActivityUpdate(activity, req)
+		err = tx.Activities.Update(activity)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "activity", activityID, before, activity)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Visible != nil {
+		err = s.recalculateProgress(activity.SectionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return activity, nil
+}
+
+// applyActivityUpdate sets the fields the request changes
+func applyActivityUpdate(activity *models.Activity, req *models.ActivityUpdateRequest) {
 	if req.Title != nil {
 		activity.Title = *req.Title
 	}
@@ -89,17 +222,91 @@ func (s *ActivityService) UpdateActivity(activityID string, req *models.Activity
 	if req.Visible != nil {
 		activity.Visible = *req.Visible
 	}
+	if len(req.Metadata) > 0 {
+		if activity.Metadata == nil {
+			activity.Metadata = models.ActivityMetadata{}
+		}
+		for key, value := range req.Metadata {
+			activity.Metadata[key] = value
+		}
+	}
 
 	activity.UpdatedAt = time.Now()
+}
 
-	err = s.activityRepo.Update(activity)
+func (s *ActivityService) DeleteActivity(activityID string, source *models.AuditSource) error {
+	var activity *models.Activity
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		var err error
+		activity, err = tx.Activities.GetByID(activityID)
+		if err != nil {
+			return err
+		}
+		err = tx.Activities.Delete(activityID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditDelete, "activity", activityID, activity, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.recalculateProgress(activity.SectionID)
+}
+
+// RestoreActivity brings back the deleted activity and its forum posts. The
+// activity's section and course have to be restored first.
+func (s *ActivityService) RestoreActivity(activityID string, source *models.AuditSource) (*models.Activity, error) {
+	activity, err := s.activityRepo.GetByIDWithDeleted(activityID)
 	if err != nil {
 		return nil, err
 	}
 
-	return activity, nil
+	section, err := s.sectionRepo.GetByIDWithDeleted(activity.SectionID)
+	if err != nil {
+		return nil, err
+	}
+	if section.DeletedAt.Valid {
+		return nil, ErrParentDeleted
+	}
+	_, err = s.courseRepo.GetByID(section.CourseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrParentDeleted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var restored *models.Activity
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Activities.Restore(activityID)
+		if err != nil {
+			return err
+		}
+		restored, err = tx.Activities.GetByID(activityID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "activity", activityID, activity, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.recalculateProgress(activity.SectionID)
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
 }
 
-func (s *ActivityService) DeleteActivity(activityID string) error {
-	return s.activityRepo.Delete(activityID)
+// recalculateProgress refreshes enrollment progress after the set of visible
+// activities in the section's course changed
+func (s *ActivityService) recalculateProgress(sectionID string) error {
+	section, err := s.sectionRepo.GetByID(sectionID)
+	if err != nil {
+		return err
+	}
+	return s.progressService.RecalculateCourse(section.CourseID)
 }
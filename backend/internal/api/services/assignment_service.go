@@ -1,89 +1,535 @@
 package services
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/events"
 )
 
 type AssignmentService struct {
-	repo *repositories.AssignmentRepository
+	repo              *repositories.AssignmentRepository
+	courseRepo        *repositories.CourseRepository
+	access            courseAccess
+	gradeService      *GradeService
+	similarityService *SimilarityService
+	transactor        *repositories.Transactor
+	bus               *events.Bus
 }
 
-func NewAssignmentService(repo *repositories.AssignmentRepository) *AssignmentService {
-	return &AssignmentService{repo: repo}
+func NewAssignmentService(
+	repo *repositories.AssignmentRepository,
+	courseRepo *repositories.CourseRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	gradeService *GradeService,
+	similarityService *SimilarityService,
+	transactor *repositories.Transactor,
+	bus *events.Bus,
+) *AssignmentService {
+	return &AssignmentService{
+		repo:              repo,
+		courseRepo:        courseRepo,
+		access:            courseAccess{courseRepo: courseRepo, enrollmentRepo: enrollmentRepo},
+		gradeService:      gradeService,
+		similarityService: similarityService,
+		transactor:        transactor,
+		bus:               bus,
+	}
 }
 
-func (s *AssignmentService) GetAllAssignments() ([]models.Assignment, error) {
-	return s.repo.GetAll()
+func (s *AssignmentService) GetAllAssignments(q *models.ListQuery) ([]models.Assignment, int64, error) {
+	return s.repo.List(q)
 }
 
 func (s *AssignmentService) GetAssignmentByID(id string) (*models.Assignment, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *AssignmentService) CreateAssignment(req *models.AssignmentCreateRequest, instructorID string) (*models.Assignment, error) {
-	assignment := &models.Assignment{
-		ID:           GenerateID(),
-		Title:        req.Title,
-		Description:  req.Description,
-		CourseID:     req.CourseID,
-		InstructorID: instructorID,
-		Type:         req.Type,
-		TotalPoints:  req.TotalPoints,
-		DueDate:      req.DueDate,
-		Status:       "active",
-		Instructions: req.Instructions,
-		Attachments:  []models.Attachment{},
-		Submissions:  []models.Submission{},
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+// GetInstructorID returns the instructor of the assignment
+func (s *AssignmentService) GetInstructorID(id string) (string, error) {
+	assignment, err := s.repo.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	return assignment.InstructorID, nil
+}
+
+// RestrictSubmissions removes the submissions the viewer is not allowed to see:
+// students only see their own, and only the instructor or an admin sees all
+func (s *AssignmentService) RestrictSubmissions(assignment *models.Assignment, viewer *models.APIUser) {
+	if viewer.Role == models.RoleAdmin || viewer.ID == assignment.InstructorID {
+		return
+	}
+
+	visible := []models.Submission{}
+	for _, submission := range assignment.Submissions {
+		if submission.StudentID == viewer.ID {
+			visible = append(visible, submission)
+		}
+	}
+	assignment.Submissions = visible
+}
+
+func (s *AssignmentService) CreateAssignment(
+	req *models.AssignmentCreateRequest,
+	instructor *models.APIUser,
+	source *models.AuditSource,
+) (*models.Assignment, error) {
+	assignment, err := s.newAssignment(req, instructor)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Assignments.Create(assignment)
+		if err != nil {
+			return err
+		}
+
+		assignment.Attachments, err = linkAttachments(tx.Attachments, req.AttachmentIDs, func(ids []string) (int64, error) {
+			return tx.Attachments.LinkToAssignment(ids, instructor.ID, assignment.ID)
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "assignment", assignment.ID, nil, assignment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(models.EventAssignmentCreated, *assignment)
+	return assignment, nil
+}
+
+// newAssignment checks that the instructor may add an assignment to the
+// course and builds it from the request, without saving it
+func (s *AssignmentService) newAssignment(
+	req *models.AssignmentCreateRequest,
+	instructor *models.APIUser,
+) (*models.Assignment, error) {
+	course, err := s.courseRepo.GetByID(req.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	if instructor.Role != models.RoleAdmin && course.InstructorID != instructor.ID {
+		return nil, ErrForbidden
 	}
 
-	err := s.repo.Create(assignment)
+	dueDate, err := parseDueDate(req.DueDate)
 	if err != nil {
 		return nil, err
 	}
 
+	var peerReviewDueDate *time.Time
+	if req.PeerReviewDueDate != "" {
+		parsed, err := parseDueDate(req.PeerReviewDueDate)
+		if err != nil {
+			return nil, err
+		}
+		peerReviewDueDate = &parsed
+	}
+
+	latePolicy := req.LatePolicy
+	if latePolicy == "" {
+		latePolicy = models.LatePolicyAccept
+	}
+	attemptPolicy := req.AttemptPolicy
+	if attemptPolicy == "" {
+		attemptPolicy = models.AttemptPolicyLatest
+	}
+
+	assignment := &models.Assignment{
+		ID:                 GenerateID(),
+		Title:              req.Title,
+		Description:        req.Description,
+		CourseID:           req.CourseID,
+		InstructorID:       course.InstructorID,
+		Type:               req.Type,
+		TotalPoints:        req.TotalPoints,
+		DueDate:            dueDate,
+		LatePolicy:         latePolicy,
+		LatePenaltyPercent: req.LatePenaltyPercent,
+		GracePeriodMinutes: req.GracePeriodMinutes,
+		MaxLateDays:        req.MaxLateDays,
+		MaxAttempts:        req.MaxAttempts,
+		AttemptPolicy:      attemptPolicy,
+		PeerReviewCount:    req.PeerReviewCount,
+		PeerReviewWeight:   req.PeerReviewWeight,
+		PeerReviewDueDate:  peerReviewDueDate,
+		Status:             "active",
+		Instructions:       req.Instructions,
+		Attachments:        []models.Attachment{},
+		Submissions:        []models.Submission{},
+		CreatedAt:          time.Now(),
+		UpdatedAt:          time.Now(),
+	}
 	return assignment, nil
 }
 
-func (s *AssignmentService) SubmitAssignment(req *models.AssignmentSubmitRequest, studentID string) (*models.Submission, error) {
+// SubmitAssignment records the student's submission. Work handed in after the
+// student's due date and grace period is marked late, or rejected if the
+// assignment's late policy does not accept it. Each submission is a new
+// attempt, up to the assignment's MaxAttempts.
+func (s *AssignmentService) SubmitAssignment(
+	req *models.AssignmentSubmitRequest,
+	studentID string,
+	source *models.AuditSource,
+) (*models.Submission, error) {
+	assignment, err := s.repo.GetByID(req.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+
 	submission := &models.Submission{
 		ID:           GenerateID(),
-		AssignmentID: req.AssignmentID,
+		AssignmentID: assignment.ID,
 		StudentID:    studentID,
 		Content:      req.Content,
-		Status:       "submitted",
-		Attachments:  req.Attachments,
-		SubmittedAt:  time.Now(),
+		Status:       models.SubmissionSubmitted,
+		Attachments:  []models.Attachment{},
 	}
 
-	err := s.repo.CreateSubmission(submission)
+	err = s.createSubmission(assignment, submission, req.AttachmentIDs, source)
 	if err != nil {
 		return nil, err
 	}
 
+	s.similarityService.Check(models.SimilarityKindSubmission, submission.ID)
+
 	return submission, nil
 }
 
-func (s *AssignmentService) GradeAssignment(req *models.AssignmentGradeRequest, gradedBy string) (*models.Submission, error) {
-	submission, err := s.repo.GetSubmissionByID(req.SubmissionID)
+// SubmitGraded records a submission that was graded automatically, such as a
+// quiz attempt, and updates the student's grade in the caller's transaction.
+// It follows the same due date and attempt rules as SubmitAssignment. The
+// caller announces the submission with AnnounceGraded once the transaction
+// commits.
+func (s *AssignmentService) SubmitGraded(
+	tx *repositories.TxRepositories,
+	assignment *models.Assignment,
+	studentID string,
+	content string,
+	score int,
+	feedback string,
+	source *models.AuditSource,
+) (*models.Submission, error) {
+	if score < 0 || score > assignment.TotalPoints {
+		return nil, ErrScoreOutOfRange
+	}
+
+	submission := &models.Submission{
+		ID:           GenerateID(),
+		AssignmentID: assignment.ID,
+		StudentID:    studentID,
+		Content:      content,
+		Score:        &score,
+		Feedback:     &feedback,
+		Status:       models.SubmissionGraded,
+		Attachments:  []models.Attachment{},
+	}
+
+	err := s.prepareSubmission(assignment, submission)
+	if err != nil {
+		return nil, err
+	}
+	err = s.storeSubmission(tx, assignment, submission, nil, source)
+	if err != nil {
+		return nil, err
+	}
+	err = s.recordCountedGrade(tx, assignment, submission, feedback, models.SystemGrader, source)
 	if err != nil {
 		return nil, err
 	}
 
-	submission.Score = &req.Score
-	submission.Feedback = &req.Feedback
-	submission.Status = "graded"
+	return submission, nil
+}
+
+// AnnounceGraded publishes that the submission has been graded
+func (s *AssignmentService) AnnounceGraded(assignment *models.Assignment, submission *models.Submission) {
+	s.bus.Publish(models.EventSubmissionGraded, models.SubmissionGradedEvent{Assignment: *assignment, Submission: *submission})
+}
+
+// AttemptsLeft returns how many more submissions the student may make, or -1
+// if the assignment does not limit attempts
+func (s *AssignmentService) AttemptsLeft(assignment *models.Assignment, studentID string) (int, error) {
+	if assignment.MaxAttempts <= 0 {
+		return -1, nil
+	}
+
+	previous, err := s.repo.GetStudentSubmissions(assignment.ID, studentID)
+	if err != nil {
+		return 0, err
+	}
+	return max(0, assignment.MaxAttempts-len(previous)), nil
+}
+
+// createSubmission applies the student's due date and the assignment's late
+// policy and attempt limit to the submission, then stores it with the given
+// uploads attached. Only students actively enrolled in the course may submit.
+func (s *AssignmentService) createSubmission(
+	assignment *models.Assignment,
+	submission *models.Submission,
+	attachmentIDs []string,
+	source *models.AuditSource,
+) error {
+	err := s.prepareSubmission(assignment, submission)
+	if err != nil {
+		return err
+	}
+
+	return s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		return s.storeSubmission(tx, assignment, submission, attachmentIDs, source)
+	})
+}
+
+// prepareSubmission checks that the student is actively enrolled in the
+// course and applies their due date and the assignment's late policy to the
+// submission
+func (s *AssignmentService) prepareSubmission(assignment *models.Assignment, submission *models.Submission) error {
+	course, err := s.courseRepo.GetByID(assignment.CourseID)
+	if err != nil {
+		return err
+	}
+	err = s.access.checkEnrolled(course, submission.StudentID)
+	if err != nil {
+		return err
+	}
+
+	dueDate, err := s.effectiveDueDate(assignment, submission.StudentID)
+	if err != nil {
+		return err
+	}
+
 	now := time.Now()
-	submission.GradedAt = &now
+	late := daysLate(assignment, dueDate, now)
+	if !acceptsLate(assignment, late) {
+		return ErrSubmissionClosed
+	}
+
+	submission.LateDays = late
+	submission.SubmittedAt = now
+	if late > 0 && submission.Status == models.SubmissionSubmitted {
+		submission.Status = models.SubmissionLate
+	}
+	if submission.Score != nil {
+		submission.LatePenalty = latePenalty(assignment, late)
+		submission.GradedAt = &now
+	}
+	return nil
+}
+
+// storeSubmission stores the submission as the student's next attempt, up to
+// the assignment's attempt limit, with the given uploads attached
+func (s *AssignmentService) storeSubmission(
+	tx *repositories.TxRepositories,
+	assignment *models.Assignment,
+	submission *models.Submission,
+	attachmentIDs []string,
+	source *models.AuditSource,
+) error {
+	// Lock the assignment so concurrent submissions get distinct attempts
+	_, err := tx.Assignments.GetByIDForUpdate(assignment.ID)
+	if err != nil {
+		return err
+	}
+
+	previous, err := tx.Assignments.GetStudentSubmissions(assignment.ID, submission.StudentID)
+	if err != nil {
+		return err
+	}
+	if assignment.MaxAttempts > 0 && len(previous) >= assignment.MaxAttempts {
+		return ErrNoAttemptsLeft
+	}
+	submission.Attempt = len(previous) + 1
+
+	err = tx.Assignments.CreateSubmission(submission)
+	if err != nil {
+		return err
+	}
+
+	submission.Attachments, err = linkAttachments(tx.Attachments, attachmentIDs, func(ids []string) (int64, error) {
+		return tx.Attachments.LinkToSubmission(ids, submission.StudentID, submission.ID)
+	})
+	if err != nil {
+		return err
+	}
+	return recordAudit(tx, source, models.AuditCreate, "submission", submission.ID, nil, submission)
+}
 
-	err = s.repo.UpdateSubmission(submission)
+// linkAttachments links the uploads with link and returns them. Every upload
+// must be linked, so the caller can only use their own unattached uploads.
+func linkAttachments(
+	attachments *repositories.AttachmentRepository,
+	ids []string,
+	link func(ids []string) (int64, error),
+) ([]models.Attachment, error) {
+	if len(ids) == 0 {
+		return []models.Attachment{}, nil
+	}
+
+	unique := uniqueStrings(ids)
+	linked, err := link(unique)
+	if err != nil {
+		return nil, err
+	}
+	if linked != int64(len(unique)) {
+		return nil, ErrInvalidAttachment
+	}
+
+	return attachments.GetByIDs(unique)
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+func (s *AssignmentService) GradeAssignment(
+	req *models.AssignmentGradeRequest,
+	grader *models.APIUser,
+	source *models.AuditSource,
+) (*models.Submission, error) {
+	assignment, submission, err := s.gradableSubmission(req.SubmissionID, grader)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.saveGrade(assignment, submission, req.Score, req.Feedback, grader.ID, nil, source)
 	if err != nil {
 		return nil, err
 	}
 
 	return submission, nil
 }
+
+// gradableSubmission loads the submission and its assignment, making sure the
+// grader is the assignment's instructor or an admin
+func (s *AssignmentService) gradableSubmission(
+	submissionID string,
+	grader *models.APIUser,
+) (*models.Assignment, *models.Submission, error) {
+	submission, err := s.repo.GetSubmissionByID(submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assignment, err := s.repo.GetByID(submission.AssignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if grader.Role != models.RoleAdmin && assignment.InstructorID != grader.ID {
+		return nil, nil, ErrForbidden
+	}
+
+	return assignment, submission, nil
+}
+
+// saveGrade stores the score of a graded submission with the rubric it was
+// graded by, if any, and updates the gradebook. Grading without a rubric
+// drops an earlier rubric assessment, which no longer explains the score.
+// Once peer scores are applied, they are blended into the score by the
+// assignment's peer review weight.
+func (s *AssignmentService) saveGrade(
+	assignment *models.Assignment,
+	submission *models.Submission,
+	score int,
+	feedback string,
+	graderID string,
+	assessment *models.RubricAssessment,
+	source *models.AuditSource,
+) error {
+	if score < 0 || score > assignment.TotalPoints {
+		return ErrScoreOutOfRange
+	}
+
+	submission.InstructorScore = nil
+	if assignment.PeerReviewWeight > 0 && submission.PeerScore != nil {
+		instructorScore := score
+		submission.InstructorScore = &instructorScore
+		score = peerWeighted(score, *submission.PeerScore, assignment.PeerReviewWeight)
+	}
+
+	submission.Score = &score
+	submission.Feedback = &feedback
+	submission.Status = models.SubmissionGraded
+	submission.LatePenalty = latePenalty(assignment, submission.LateDays)
+	now := time.Now()
+	submission.GradedAt = &now
+
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		before, err := tx.Assignments.GetSubmissionByID(submission.ID)
+		if err != nil {
+			return err
+		}
+		err = tx.Assignments.UpdateSubmission(submission)
+		if err != nil {
+			return err
+		}
+		if assessment == nil {
+			err = tx.Assignments.DeleteRubricAssessment(submission.ID)
+		} else {
+			err = tx.Assignments.SaveRubricAssessment(assessment)
+		}
+		if err != nil {
+			return err
+		}
+
+		graded := *submission
+		graded.Rubric = assessment
+		err = recordAudit(tx, source, models.AuditUpdate, "submission", submission.ID, before, &graded)
+		if err != nil {
+			return err
+		}
+		return s.recordCountedGrade(tx, assignment, submission, feedback, graderID, source)
+	})
+	if err != nil {
+		return err
+	}
+	submission.Rubric = assessment
+
+	s.AnnounceGraded(assignment, submission)
+	return nil
+}
+
+// recordCountedGrade writes the score of the attempt that counts under the
+// assignment's attempt policy to the gradebook
+func (s *AssignmentService) recordCountedGrade(
+	tx *repositories.TxRepositories,
+	assignment *models.Assignment,
+	submission *models.Submission,
+	feedback string,
+	graderID string,
+	source *models.AuditSource,
+) error {
+	attempts, err := tx.Assignments.GetStudentSubmissions(assignment.ID, submission.StudentID)
+	if err != nil {
+		return err
+	}
+	score := countedScore(assignment.AttemptPolicy, attempts)
+	if score == nil {
+		return fmt.Errorf("no graded attempt found for submission %s", submission.ID)
+	}
+
+	_, err = s.gradeService.recordGrade(tx, &GradeEntry{
+		StudentID:       submission.StudentID,
+		AssignmentID:    assignment.ID,
+		AssignmentTitle: assignment.Title,
+		CourseID:        assignment.CourseID,
+		Source:          models.GradeSourceAssignment,
+		Score:           *score,
+		TotalPoints:     assignment.TotalPoints,
+		Feedback:        withPenaltyNote(feedback, submission),
+		GradedBy:        graderID,
+	}, source)
+	return err
+}
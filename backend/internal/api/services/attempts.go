@@ -0,0 +1,74 @@
+package services
+
+import (
+	"math"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/textdiff"
+)
+
+// GetSubmissionHistory returns the student's attempts at the assignment,
+// oldest first, each with the changes to its content since the attempt
+// before it
+func (s *AssignmentService) GetSubmissionHistory(assignmentID string, studentID string) (*models.SubmissionHistory, error) {
+	assignment, err := s.repo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions, err := s.repo.GetStudentSubmissions(assignmentID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make([]models.SubmissionVersion, len(submissions))
+	for i, submission := range submissions {
+		attempts[i].Submission = submission
+		if i > 0 {
+			attempts[i].Diff = textdiff.Lines(submissions[i-1].Content, submission.Content)
+		}
+	}
+
+	return &models.SubmissionHistory{
+		AssignmentID:  assignment.ID,
+		StudentID:     studentID,
+		MaxAttempts:   assignment.MaxAttempts,
+		AttemptPolicy: assignment.AttemptPolicy,
+		AttemptsUsed:  len(submissions),
+		CountedScore:  countedScore(assignment.AttemptPolicy, submissions),
+		Attempts:      attempts,
+	}, nil
+}
+
+// countedScore returns the score that goes into the gradebook under the
+// attempt policy, after late penalties, or nil if no attempt is graded yet.
+// Attempts must be ordered oldest first.
+func countedScore(policy string, attempts []models.Submission) *int {
+	scores := []int{}
+	for _, attempt := range attempts {
+		if attempt.Score != nil {
+			scores = append(scores, applyPenalty(*attempt.Score, attempt.LatePenalty))
+		}
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+
+	var counted int
+	switch policy {
+	case models.AttemptPolicyHighest:
+		counted = scores[0]
+		for _, score := range scores[1:] {
+			counted = max(counted, score)
+		}
+	case models.AttemptPolicyAverage:
+		total := 0
+		for _, score := range scores {
+			total += score
+		}
+		counted = int(math.Round(float64(total) / float64(len(scores))))
+	default:
+		counted = scores[len(scores)-1]
+	}
+	return &counted
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+)
+
+type AuditService struct {
+	repo *repositories.AuditRepository
+}
+
+func NewAuditService(repo *repositories.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// recordAudit appends a change to the audit trail in the transaction that
+// makes it, so the entry is kept exactly when the change is and an entry that
+// cannot be written fails the change. source is nil for changes the server
+// makes on its own. before and after are the entity's states, either values
+// to marshal or JSON already; nil means the entity did not exist. The entity
+// is identified by the "id" of its state, or else by key.
+func recordAudit(
+	tx *repositories.TxRepositories,
+	source *models.AuditSource,
+	action string,
+	entityType string,
+	key string,
+	before any,
+	after any,
+) error {
+	entry := &models.AuditLog{
+		ID:         GenerateID(),
+		Action:     action,
+		EntityType: entityType,
+		CreatedAt:  time.Now(),
+	}
+	if source != nil {
+		entry.ActorID = source.ActorID
+		entry.ActorRole = source.ActorRole
+		entry.Method = source.Method
+		entry.Path = source.Path
+		entry.IPAddress = source.IPAddress
+	}
+
+	var err error
+	entry.Before, err = snapshot(before)
+	if err != nil {
+		return err
+	}
+	entry.After, err = snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	entry.Changes = changedFields(entry.Before, entry.After)
+	entry.EntityID = snapshotID(entry.After)
+	if entry.EntityID == "" {
+		entry.EntityID = snapshotID(entry.Before)
+	}
+	if entry.EntityID == "" {
+		entry.EntityID = key
+	}
+
+	err = tx.Audit.Create(entry)
+	if err != nil {
+		return fmt.Errorf("failed to record %s of %s %s in the audit log: %w", action, entityType, entry.EntityID, err)
+	}
+	return nil
+}
+
+// GetAuditLogs lists the audit trail, filtered by the query's fields and by
+// the "from" and "to" bounds on the entries' time
+func (s *AuditService) GetAuditLogs(q *models.ListQuery) ([]models.AuditLog, int64, error) {
+	from, err := parseDateFilter(q.Filters["from"], false)
+	if err != nil {
+		return nil, 0, err
+	}
+	to, err := parseDateFilter(q.Filters["to"], true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.List(q, from, to)
+}
+
+func snapshot(state any) (models.AuditSnapshot, error) {
+	switch v := state.(type) {
+	case nil:
+		return nil, nil
+	case models.AuditSnapshot:
+		return v, nil
+	case json.RawMessage:
+		return models.AuditSnapshot(v), nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	return data, nil
+}
+
+func snapshotID(state models.AuditSnapshot) string {
+	var fields struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(state, &fields)
+	return fields.ID
+}
+
+// changedFields compares the top-level fields of two JSON objects. A missing
+// state counts as an object without fields, so creates and deletes list every
+// field. States that are not objects are not compared field by field.
+func changedFields(before models.AuditSnapshot, after models.AuditSnapshot) models.AuditChanges {
+	old, okOld := fieldsOf(before)
+	updated, okUpdated := fieldsOf(after)
+	changes := models.AuditChanges{}
+	if !okOld || !okUpdated {
+		return changes
+	}
+
+	null := json.RawMessage("null")
+	for name, from := range old {
+		to, ok := updated[name]
+		if !ok {
+			to = null
+		}
+		if !bytes.Equal(from, to) {
+			changes[name] = models.AuditChange{From: from, To: to}
+		}
+	}
+	for name, to := range updated {
+		if _, ok := old[name]; !ok {
+			changes[name] = models.AuditChange{From: null, To: to}
+		}
+	}
+	return changes
+}
+
+func fieldsOf(state models.AuditSnapshot) (map[string]json.RawMessage, bool) {
+	fields := map[string]json.RawMessage{}
+	if len(state) == 0 {
+		return fields, true
+	}
+	err := json.Unmarshal(state, &fields)
+	return fields, err == nil
+}
+
+// parseDateFilter reads an RFC 3339 timestamp or a YYYY-MM-DD date, which
+// means the start of that day in UTC, or the end of it for an upper bound
+func parseDateFilter(value string, upper bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDateFilter, value)
+	}
+	if upper {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return &parsed, nil
+}
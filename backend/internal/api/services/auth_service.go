@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrEmailTaken         = errors.New("email is already registered")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+)
+
+// streamTicketAudience marks the tokens that only open an event stream, and
+// streamTicketTTL is how long they stay valid. Tickets travel in the query
+// string, where they may be logged, so they last just long enough to connect.
+const (
+	streamTicketAudience = "stream"
+	streamTicketTTL      = time.Minute
+)
+
+// TokenClaims are the claims carried by an access token
+type TokenClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type AuthService struct {
+	userRepo *repositories.UserRepository
+	secret   []byte
+	ttl      time.Duration
+	issuer   string
+}
+
+func NewAuthService(userRepo *repositories.UserRepository, cfg *config.Auth) *AuthService {
+	return &AuthService{
+		userRepo: userRepo,
+		secret:   []byte(cfg.JWTSecret),
+		ttl:      cfg.TokenTTL,
+		issuer:   cfg.Issuer,
+	}
+}
+
+func (s *AuthService) Register(req *models.RegisterRequest) (*models.AuthResponse, error) {
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	_, err := s.userRepo.GetByEmail(email)
+	if err == nil {
+		return nil, ErrEmailTaken
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.APIUser{
+		ID:           GenerateID(),
+		Name:         req.Name,
+		Email:        email,
+		Role:         models.RoleStudent,
+		PasswordHash: hash,
+		CreatedAt:    now,
+		LastLogin:    &now,
+		UpdatedAt:    now,
+	}
+
+	err = s.userRepo.Create(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(user)
+}
+
+func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, error) {
+	user, err := s.userRepo.GetByEmail(strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	user.LastLogin = &now
+	err = s.userRepo.Update(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issue(user)
+}
+
+// Authenticate validates an access token and returns the user it was issued
+// to. Stream tickets are not access tokens.
+func (s *AuthService) Authenticate(token string) (*models.APIUser, error) {
+	claims, err := s.parse(token)
+	if err != nil || slices.Contains(claims.Audience, streamTicketAudience) {
+		return nil, ErrInvalidToken
+	}
+	return s.user(claims)
+}
+
+// IssueStreamTicket issues a short-lived token that only opens the user's
+// event stream, for clients that must pass it in the query string
+func (s *AuthService) IssueStreamTicket(user *models.APIUser) (*models.StreamTicket, error) {
+	now := time.Now()
+	expiresAt := now.Add(streamTicketTTL)
+
+	claims := TokenClaims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{streamTicketAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	ticket, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign stream ticket: %w", err)
+	}
+
+	return &models.StreamTicket{Ticket: ticket, ExpiresAt: expiresAt}, nil
+}
+
+// AuthenticateStreamTicket validates a stream ticket and returns the user it
+// was issued to
+func (s *AuthService) AuthenticateStreamTicket(ticket string) (*models.APIUser, error) {
+	claims, err := s.parse(ticket, jwt.WithAudience(streamTicketAudience))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return s.user(claims)
+}
+
+func (s *AuthService) parse(token string, options ...jwt.ParserOption) (*TokenClaims, error) {
+	options = append(options,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithExpirationRequired(),
+	)
+
+	claims := &TokenClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, options...)
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// user loads the user a validated token was issued to
+func (s *AuthService) user(claims *TokenClaims) (*models.APIUser, error) {
+	user, err := s.userRepo.GetByID(claims.Subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *AuthService) issue(user *models.APIUser) (*models.AuthResponse, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+
+	claims := TokenClaims{
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			Issuer:    s.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return &models.AuthResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	}, nil
+}
+
+// HashPassword hashes a plain-text password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+// courseAccess finds the course that content belongs to and decides who may
+// take part in it
+type courseAccess struct {
+	courseRepo     *repositories.CourseRepository
+	sectionRepo    *repositories.SectionRepository
+	activityRepo   *repositories.ActivityRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+}
+
+// activityCourse returns the activity and the course it belongs to
+func (a courseAccess) activityCourse(activityID string) (*models.Activity, *models.Course, error) {
+	activity, err := a.activityRepo.GetByID(activityID)
+	if err != nil {
+		return nil, nil, err
+	}
+	section, err := a.sectionRepo.GetByID(activity.SectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	course, err := a.courseRepo.GetByID(section.CourseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return activity, course, nil
+}
+
+// check lets the course's instructor, its actively enrolled students and
+// admins in
+func (a courseAccess) check(course *models.Course, viewer *models.APIUser) error {
+	if isModerator(course, viewer) {
+		return nil
+	}
+	return a.checkEnrolled(course, viewer.ID)
+}
+
+// checkEnrolled lets only the course's actively enrolled students in
+func (a courseAccess) checkEnrolled(course *models.Course, studentID string) error {
+	enrollment, err := a.enrollmentRepo.GetCurrent(course.ID, studentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && enrollment.Status != models.EnrollmentActive) {
+		return ErrNotEnrolled
+	}
+	return err
+}
+
+// isModerator reports whether the viewer runs the course: its instructor or an admin
+func isModerator(course *models.Course, viewer *models.APIUser) bool {
+	return viewer.Role == models.RoleAdmin || viewer.ID == course.InstructorID
+}
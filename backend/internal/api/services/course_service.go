@@ -5,25 +5,41 @@ import (
 
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/events"
 )
 
 type CourseService struct {
-	repo *repositories.CourseRepository
+	repo       *repositories.CourseRepository
+	transactor *repositories.Transactor
+	bus        *events.Bus
 }
 
-func NewCourseService(repo *repositories.CourseRepository) *CourseService {
-	return &CourseService{repo: repo}
+func NewCourseService(repo *repositories.CourseRepository, transactor *repositories.Transactor, bus *events.Bus) *CourseService {
+	return &CourseService{repo: repo, transactor: transactor, bus: bus}
 }
 
-func (s *CourseService) GetAllCourses() ([]models.Course, error) {
-	return s.repo.GetAll()
+func (s *CourseService) GetAllCourses(q *models.ListQuery) ([]models.Course, int64, error) {
+	return s.repo.List(q)
 }
 
 func (s *CourseService) GetCourseByID(id string) (*models.Course, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *CourseService) CreateCourse(req *models.CourseCreateRequest, instructorID string) (*models.Course, error) {
+// GetInstructorID returns the ID of the instructor who owns the course
+func (s *CourseService) GetInstructorID(id string) (string, error) {
+	course, err := s.repo.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	return course.InstructorID, nil
+}
+
+func (s *CourseService) CreateCourse(
+	req *models.CourseCreateRequest,
+	instructorID string,
+	source *models.AuditSource,
+) (*models.Course, error) {
 	course := &models.Course{
 		ID:               GenerateID(),
 		Title:            req.Title,
@@ -43,7 +59,13 @@ func (s *CourseService) CreateCourse(req *models.CourseCreateRequest, instructor
 		UpdatedAt:        time.Now(),
 	}
 
-	err := s.repo.Create(course)
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Courses.Create(course)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "course", course.ID, nil, course)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -51,32 +73,97 @@ func (s *CourseService) CreateCourse(req *models.CourseCreateRequest, instructor
 	return course, nil
 }
 
-func (s *CourseService) UpdateCourse(id string, req *models.CourseUpdateRequest) (*models.Course, error) {
-	course, err := s.repo.GetByID(id)
+// UpdateCourse changes the course with its row locked, so that it cannot
+// overwrite a roster that an enrollment changes at the same time. Raising
+// MaxStudents admits waitlisted students into the new seats.
+func (s *CourseService) UpdateCourse(id string, req *models.CourseUpdateRequest, source *models.AuditSource) (*models.Course, error) {
+	var course *models.Course
+	var promoted []models.Enrollment
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		var err error
+		course, err = tx.Courses.GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		before, err := snapshot(course)
+		if err != nil {
+			return err
+		}
+
+		seatsAdded := req.MaxStudents != nil && course.MaxStudents > 0 &&
+			(*req.MaxStudents <= 0 || *req.MaxStudents > course.MaxStudents)
+		if req.Title != nil {
+			course.Title = *req.Title
+		}
+		if req.Description != nil {
+			course.Description = *req.Description
+		}
+		if req.MaxStudents != nil {
+			course.MaxStudents = *req.MaxStudents
+		}
+		course.UpdatedAt = time.Now()
+
+		err = tx.Courses.Update(course)
+		if err != nil {
+			return err
+		}
+
+		if seatsAdded {
+			promoted, err = promoteWaitlisted(tx, course, source)
+			if err != nil {
+				return err
+			}
+			err = syncRoster(tx, course)
+			if err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "course", id, before, course)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if req.Title != nil {
-		course.Title = *req.Title
-	}
-	if req.Description != nil {
-		course.Description = *req.Description
-	}
-	if req.MaxStudents != nil {
-		course.MaxStudents = *req.MaxStudents
+	for _, enrollment := range promoted {
+		s.bus.Publish(models.EventEnrollmentChanged, models.EnrollmentChangedEvent{Course: *course, Enrollment: enrollment})
 	}
+	return s.repo.GetByID(id)
+}
 
-	course.UpdatedAt = time.Now()
+func (s *CourseService) DeleteCourse(id string, source *models.AuditSource) error {
+	return s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		course, err := tx.Courses.GetByID(id)
+		if err != nil {
+			return err
+		}
+		err = tx.Courses.Delete(id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditDelete, "course", id, course, nil)
+	})
+}
 
-	err = s.repo.Update(course)
+// RestoreCourse brings back the deleted course with the content deleted along with it
+func (s *CourseService) RestoreCourse(id string, source *models.AuditSource) (*models.Course, error) {
+	var course *models.Course
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		before, err := tx.Courses.GetByIDWithDeleted(id)
+		if err != nil {
+			return err
+		}
+		err = tx.Courses.Restore(id)
+		if err != nil {
+			return err
+		}
+		course, err = tx.Courses.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "course", id, before, course)
+	})
 	if err != nil {
 		return nil, err
 	}
-
 	return course, nil
 }
-
-func (s *CourseService) DeleteCourse(id string) error {
-	return s.repo.Delete(id)
-}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/events"
+	"gorm.io/gorm"
+)
+
+// enrollmentTransitions lists the statuses each enrollment status may move to
+var enrollmentTransitions = map[string][]string{
+	models.EnrollmentActive:     {models.EnrollmentDropped, models.EnrollmentWithdrawn, models.EnrollmentCompleted},
+	models.EnrollmentWaitlisted: {models.EnrollmentActive, models.EnrollmentDropped, models.EnrollmentWithdrawn},
+}
+
+type EnrollmentService struct {
+	enrollmentRepo *repositories.EnrollmentRepository
+	courseRepo     *repositories.CourseRepository
+	userRepo       *repositories.UserRepository
+	transactor     *repositories.Transactor
+	bus            *events.Bus
+}
+
+func NewEnrollmentService(
+	enrollmentRepo *repositories.EnrollmentRepository,
+	courseRepo *repositories.CourseRepository,
+	userRepo *repositories.UserRepository,
+	transactor *repositories.Transactor,
+	bus *events.Bus,
+) *EnrollmentService {
+	return &EnrollmentService{
+		enrollmentRepo: enrollmentRepo,
+		courseRepo:     courseRepo,
+		userRepo:       userRepo,
+		transactor:     transactor,
+		bus:            bus,
+	}
+}
+
+// GetAllEnrollments returns every enrollment for admins and the enrollments in
+// their own courses for instructors
+func (s *EnrollmentService) GetAllEnrollments(viewer *models.APIUser, q *models.ListQuery) ([]models.Enrollment, int64, error) {
+	if viewer.Role == models.RoleAdmin {
+		return s.enrollmentRepo.List(q)
+	}
+	return s.enrollmentRepo.ListByInstructorID(viewer.ID, q)
+}
+
+// CreateEnrollment enrolls the student in the course, or puts them on the
+// waitlist if every seat is taken. Students enroll themselves; the course's
+// instructor and admins may enroll any student.
+func (s *EnrollmentService) CreateEnrollment(
+	req *models.EnrollmentCreateRequest,
+	requester *models.APIUser,
+	source *models.AuditSource,
+) (*models.Enrollment, error) {
+	if req.StudentID == "" {
+		req.StudentID = requester.ID
+	}
+	if req.StudentID != requester.ID && requester.Role == models.RoleStudent {
+		return nil, ErrForbidden
+	}
+
+	student, err := s.userRepo.GetByID(req.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if student.Role != models.RoleStudent {
+		return nil, ErrNotAStudent
+	}
+
+	var enrollment *models.Enrollment
+	var course *models.Course
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		var err error
+		course, err = tx.Courses.GetByIDForUpdate(req.CourseID)
+		if err != nil {
+			return err
+		}
+		if req.StudentID != requester.ID && !isModerator(course, requester) {
+			return ErrForbidden
+		}
+		if course.Status != "active" {
+			return ErrCourseNotOpen
+		}
+
+		_, err = tx.Enrollments.GetCurrent(course.ID, req.StudentID)
+		if err == nil {
+			return ErrAlreadyEnrolled
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		status := models.EnrollmentActive
+		if !hasFreeSeat(course) {
+			status = models.EnrollmentWaitlisted
+		}
+
+		now := time.Now()
+		enrollment = &models.Enrollment{
+			ID:         GenerateID(),
+			StudentID:  req.StudentID,
+			CourseID:   course.ID,
+			EnrolledAt: now,
+			Status:     status,
+			Progress:   0,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		err = tx.Enrollments.Create(enrollment)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(tx, source, models.AuditCreate, "enrollment", enrollment.ID, nil, enrollment)
+		if err != nil {
+			return err
+		}
+
+		return syncRoster(tx, course)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(models.EventEnrollmentChanged, models.EnrollmentChangedEvent{Course: *course, Enrollment: *enrollment})
+	return enrollment, nil
+}
+
+// UpdateEnrollmentStatus moves an enrollment to a new status. Students may
+// drop or withdraw from their own enrollments; completing an enrollment or
+// admitting a waitlisted student is left to the course instructor. When a
+// seat frees up the earliest waitlisted student is admitted.
+func (s *EnrollmentService) UpdateEnrollmentStatus(
+	id string,
+	req *models.EnrollmentStatusUpdateRequest,
+	requester *models.APIUser,
+	source *models.AuditSource,
+) (*models.Enrollment, error) {
+	var enrollment *models.Enrollment
+	var course *models.Course
+	var promoted []models.Enrollment
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		current, err := tx.Enrollments.GetByID(id)
+		if err != nil {
+			return err
+		}
+
+		course, err = tx.Courses.GetByIDForUpdate(current.CourseID)
+		if err != nil {
+			return err
+		}
+
+		if !canChangeEnrollment(requester, current, course, req.Status) {
+			return ErrForbidden
+		}
+		if !canTransition(current.Status, req.Status) {
+			return ErrInvalidTransition
+		}
+		if req.Status == models.EnrollmentActive && !hasFreeSeat(course) {
+			return ErrCourseFull
+		}
+
+		before, err := snapshot(current)
+		if err != nil {
+			return err
+		}
+		freesSeat := current.Status == models.EnrollmentActive && req.Status != models.EnrollmentCompleted
+		current.Status = req.Status
+		current.UpdatedAt = time.Now()
+		err = tx.Enrollments.Update(current)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(tx, source, models.AuditUpdate, "enrollment", current.ID, before, current)
+		if err != nil {
+			return err
+		}
+		enrollment = current
+
+		if freesSeat {
+			err = syncRoster(tx, course)
+			if err != nil {
+				return err
+			}
+			promoted, err = promoteWaitlisted(tx, course, source)
+			if err != nil {
+				return err
+			}
+		}
+
+		return syncRoster(tx, course)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, changed := range append([]models.Enrollment{*enrollment}, promoted...) {
+		s.bus.Publish(models.EventEnrollmentChanged, models.EnrollmentChangedEvent{Course: *course, Enrollment: changed})
+	}
+	return enrollment, nil
+}
+
+// GetRoster returns the course's active and waitlisted enrollments
+func (s *EnrollmentService) GetRoster(courseID string) (*models.CourseRoster, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.enrollmentRepo.GetByCourseAndStatus(courseID, models.EnrollmentActive)
+	if err != nil {
+		return nil, err
+	}
+
+	waitlisted, err := s.enrollmentRepo.GetByCourseAndStatus(courseID, models.EnrollmentWaitlisted)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CourseRoster{
+		CourseID:    course.ID,
+		MaxStudents: course.MaxStudents,
+		Active:      active,
+		Waitlisted:  waitlisted,
+	}, nil
+}
+
+func canTransition(from string, to string) bool {
+	for _, allowed := range enrollmentTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func canChangeEnrollment(requester *models.APIUser, enrollment *models.Enrollment, course *models.Course, status string) bool {
+	if requester.Role == models.RoleAdmin || requester.ID == course.InstructorID {
+		return true
+	}
+	leaving := status == models.EnrollmentDropped || status == models.EnrollmentWithdrawn
+	return leaving && requester.ID == enrollment.StudentID
+}
+
+// hasFreeSeat reports whether the course can take another active student. A
+// MaxStudents of zero or less means the course has no limit.
+func hasFreeSeat(course *models.Course) bool {
+	return course.MaxStudents <= 0 || len(course.EnrolledStudents) < course.MaxStudents
+}
+
+// promoteWaitlisted admits waitlisted students in the order they joined until
+// the course is full again, and returns the enrollments it admitted
+func promoteWaitlisted(
+	tx *repositories.TxRepositories,
+	course *models.Course,
+	source *models.AuditSource,
+) ([]models.Enrollment, error) {
+	waitlisted, err := tx.Enrollments.GetByCourseAndStatus(course.ID, models.EnrollmentWaitlisted)
+	if err != nil {
+		return nil, err
+	}
+
+	free := len(waitlisted)
+	if course.MaxStudents > 0 {
+		free = max(min(free, course.MaxStudents-len(course.EnrolledStudents)), 0)
+	}
+
+	for i := 0; i < free; i++ {
+		before, err := snapshot(&waitlisted[i])
+		if err != nil {
+			return nil, err
+		}
+		waitlisted[i].Status = models.EnrollmentActive
+		waitlisted[i].UpdatedAt = time.Now()
+		err = tx.Enrollments.Update(&waitlisted[i])
+		if err != nil {
+			return nil, err
+		}
+		err = recordAudit(tx, source, models.AuditUpdate, "enrollment", waitlisted[i].ID, before, &waitlisted[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return waitlisted[:free], nil
+}
+
+// syncRoster rebuilds Course.EnrolledStudents from the course's active
+// enrollments
+func syncRoster(tx *repositories.TxRepositories, course *models.Course) error {
+	active, err := tx.Enrollments.GetByCourseAndStatus(course.ID, models.EnrollmentActive)
+	if err != nil {
+		return err
+	}
+
+	roster := models.StringSlice{}
+	for _, enrollment := range active {
+		roster = append(roster, enrollment.StudentID)
+	}
+
+	course.EnrolledStudents = roster
+	course.UpdatedAt = time.Now()
+	return tx.Courses.Update(course)
+}
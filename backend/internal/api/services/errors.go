@@ -0,0 +1,41 @@
+package services
+
+import "errors"
+
+var (
+	ErrForbidden           = errors.New("you do not have permission to perform this action")
+	ErrTaskHasNoTests      = errors.New("task has no test cases to evaluate against")
+	ErrScoreOutOfRange     = errors.New("score must be between 0 and the assignment's total points")
+	ErrAlreadyEnrolled     = errors.New("student is already enrolled or waitlisted in this course")
+	ErrCourseNotOpen       = errors.New("course is not open for enrollment")
+	ErrCourseFull          = errors.New("course has no free seats")
+	ErrInvalidTransition   = errors.New("enrollment cannot move to the requested status")
+	ErrNotEnrolled         = errors.New("student is not actively enrolled in this course")
+	ErrStudentNotEnrolled  = errors.New("the student is not actively enrolled in the assignment's course")
+	ErrFileTooLarge        = errors.New("file exceeds the maximum upload size")
+	ErrUnsupportedFileType = errors.New("file type is not allowed")
+	ErrInvalidAttachment   = errors.New("attachments must be your own uploads that are not attached to anything yet")
+	ErrInvalidDownloadLink = errors.New("download link is invalid or has expired")
+	ErrNoAttemptsLeft      = errors.New("no submission attempts left for this assignment")
+	ErrPeerReviewDisabled  = errors.New("peer review is not enabled for this assignment")
+	ErrPeerReviewNotOpen   = errors.New("peer review cannot start before the assignment's due date")
+	ErrPeerReviewsAssigned = errors.New("peer reviewers have already been assigned for this assignment")
+	ErrPeerReviewClosed    = errors.New("the peer review deadline has passed")
+	ErrInvalidDateFilter   = errors.New("date filters must be RFC 3339 timestamps or YYYY-MM-DD dates")
+	ErrParentDeleted       = errors.New("restore the item this belongs to first")
+	ErrUnknownNotification = errors.New("unknown notification type")
+	ErrInvalidParticipants = errors.New("participants must be existing users other than yourself")
+	ErrNotAnnouncer        = errors.New("only the course's instructor can post announcements")
+	ErrUnknownTopic        = errors.New("topics are user:<id>, course:<id> or forum:<id>")
+	ErrNotAForum           = errors.New("threads can only be started in forum activities")
+	ErrNotAThread          = errors.New("only the first post of a thread can be pinned or locked")
+	ErrThreadLocked        = errors.New("the thread is locked")
+	ErrNotQAForum          = errors.New("answers can only be accepted in Q&A forums")
+	ErrNotAReply           = errors.New("only a reply can be accepted as an answer")
+	ErrVoteOwnPost         = errors.New("you cannot vote for your own post")
+	ErrNotGenerativeTask   = errors.New("tasks can only be generated for generative task activities")
+	ErrGenerationLimit     = errors.New("too many tasks generated recently, try again later")
+	ErrNotAStudent         = errors.New("only students can be enrolled in courses")
+	ErrSimilarityBusy      = errors.New("too many similarity checks are waiting, try again later")
+	ErrShuttingDown        = errors.New("the server is shutting down")
+)
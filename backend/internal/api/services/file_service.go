@@ -0,0 +1,345 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/storage"
+)
+
+// File kinds used in storage keys and download links
+const (
+	FileKindAttachment = "attachments"
+	FileKindResource   = "resources"
+)
+
+// sniffLength is how much of a file is read to detect its content type
+const sniffLength = 512
+
+// StoredFile is an open stored file ready to be served
+type StoredFile struct {
+	Body     io.ReadCloser
+	FileName string
+	MimeType string
+	Size     int64
+}
+
+type FileService struct {
+	attachmentRepo *repositories.AttachmentRepository
+	resourceRepo   *repositories.ResourceRepository
+	assignmentRepo *repositories.AssignmentRepository
+	peerReviewRepo *repositories.PeerReviewRepository
+	access         courseAccess
+	storage        storage.Storage
+	signer         *storage.URLSigner
+	transactor     *repositories.Transactor
+	maxSize        int64
+	allowedTypes   []string
+}
+
+func NewFileService(
+	attachmentRepo *repositories.AttachmentRepository,
+	resourceRepo *repositories.ResourceRepository,
+	assignmentRepo *repositories.AssignmentRepository,
+	peerReviewRepo *repositories.PeerReviewRepository,
+	courseRepo *repositories.CourseRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	store storage.Storage,
+	signer *storage.URLSigner,
+	transactor *repositories.Transactor,
+	cfg *config.Storage,
+) *FileService {
+	return &FileService{
+		attachmentRepo: attachmentRepo,
+		resourceRepo:   resourceRepo,
+		assignmentRepo: assignmentRepo,
+		peerReviewRepo: peerReviewRepo,
+		access:         courseAccess{courseRepo: courseRepo, enrollmentRepo: enrollmentRepo},
+		storage:        store,
+		signer:         signer,
+		transactor:     transactor,
+		maxSize:        int64(cfg.MaxUploadMB) << 20,
+		allowedTypes:   cfg.AllowedTypes,
+	}
+}
+
+// MaxUploadSize returns the largest accepted file in bytes
+func (s *FileService) MaxUploadSize() int64 {
+	return s.maxSize
+}
+
+// UploadAttachment stores a file that is not yet attached to anything. The
+// uploader attaches it by passing its ID when creating an assignment or
+// submission.
+func (s *FileService) UploadAttachment(
+	file *multipart.FileHeader,
+	req *models.UploadRequest,
+	uploader *models.APIUser,
+	source *models.AuditSource,
+) (*models.Attachment, error) {
+	id := GenerateID()
+	key := FileKindAttachment + "/" + id
+
+	stored, err := s.store(key, file)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		ID:          id,
+		Title:       titleOrFileName(req.Title, file.Filename),
+		Type:        "file",
+		URL:         "/api/attachments/" + id + "/download",
+		Description: req.Description,
+		FileName:    filepath.Base(file.Filename),
+		StorageKey:  key,
+		Size:        stored.size,
+		MimeType:    stored.mimeType,
+		Checksum:    stored.checksum,
+		UploadedBy:  uploader.ID,
+		UploadedAt:  time.Now(),
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Attachments.Create(attachment)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "attachment", attachment.ID, nil, attachment)
+	})
+	if err != nil {
+		s.storage.Delete(context.Background(), key)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// UploadResource stores a file as a resource of the course
+func (s *FileService) UploadResource(
+	courseID string,
+	file *multipart.FileHeader,
+	req *models.UploadRequest,
+	uploader *models.APIUser,
+	source *models.AuditSource,
+) (*models.Resource, error) {
+	id := GenerateID()
+	key := FileKindResource + "/" + id
+
+	stored, err := s.store(key, file)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := &models.Resource{
+		ID:          id,
+		CourseID:    courseID,
+		Title:       titleOrFileName(req.Title, file.Filename),
+		Type:        "file",
+		URL:         "/api/resources/" + id + "/download",
+		Description: req.Description,
+		FileName:    filepath.Base(file.Filename),
+		StorageKey:  key,
+		Size:        stored.size,
+		MimeType:    stored.mimeType,
+		Checksum:    stored.checksum,
+		UploadedBy:  uploader.ID,
+		UploadedAt:  time.Now(),
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Resources.Create(resource)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "resource", resource.ID, nil, resource)
+	})
+	if err != nil {
+		s.storage.Delete(context.Background(), key)
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// GetAttachmentLink returns a signed download link for the attachment.
+// Assignment attachments are open to every user; submission attachments only
+// to the submitting student, the instructor and admins; unattached uploads
+// only to their uploader and admins.
+func (s *FileService) GetAttachmentLink(id string, viewer *models.APIUser) (*models.DownloadLink, error) {
+	attachment, err := s.attachmentRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if viewer.Role != models.RoleAdmin && attachment.AssignmentID == nil {
+		allowed, err := s.canReadSubmissionFile(attachment, viewer)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrForbidden
+		}
+	}
+
+	return s.link(FileKindAttachment, attachment.ID), nil
+}
+
+// GetResourceLink returns a signed download link for the course resource to
+// the course's instructor, its active students and admins
+func (s *FileService) GetResourceLink(id string, viewer *models.APIUser) (*models.DownloadLink, error) {
+	resource, err := s.resourceRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	course, err := s.access.courseRepo.GetByID(resource.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.access.check(course, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.link(FileKindResource, resource.ID), nil
+}
+
+// OpenSigned opens the file a signed download link points to
+func (s *FileService) OpenSigned(kind string, id string, expires string, signature string) (*StoredFile, error) {
+	if !s.signer.Verify(kind+"/"+id, expires, signature) {
+		return nil, ErrInvalidDownloadLink
+	}
+
+	var key string
+	file := &StoredFile{}
+	switch kind {
+	case FileKindAttachment:
+		attachment, err := s.attachmentRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		key = attachment.StorageKey
+		file.FileName, file.MimeType, file.Size = attachment.FileName, attachment.MimeType, attachment.Size
+	case FileKindResource:
+		resource, err := s.resourceRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		key = resource.StorageKey
+		file.FileName, file.MimeType, file.Size = resource.FileName, resource.MimeType, resource.Size
+	default:
+		return nil, ErrInvalidDownloadLink
+	}
+
+	body, err := s.storage.Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	file.Body = body
+
+	return file, nil
+}
+
+func (s *FileService) canReadSubmissionFile(attachment *models.Attachment, viewer *models.APIUser) (bool, error) {
+	if attachment.SubmissionID == nil {
+		return attachment.UploadedBy == viewer.ID, nil
+	}
+
+	submission, err := s.assignmentRepo.GetSubmissionByID(*attachment.SubmissionID)
+	if err != nil {
+		return false, err
+	}
+	if submission.StudentID == viewer.ID {
+		return true, nil
+	}
+
+	reviewer, err := s.peerReviewRepo.IsReviewer(submission.ID, viewer.ID)
+	if err != nil {
+		return false, err
+	}
+	if reviewer {
+		return true, nil
+	}
+
+	assignment, err := s.assignmentRepo.GetByID(submission.AssignmentID)
+	if err != nil {
+		return false, err
+	}
+	return assignment.InstructorID == viewer.ID, nil
+}
+
+func (s *FileService) link(kind string, id string) *models.DownloadLink {
+	expires, signature := s.signer.Sign(kind + "/" + id)
+	query := url.Values{}
+	query.Set("expires", fmt.Sprint(expires.Unix()))
+	query.Set("signature", signature)
+
+	return &models.DownloadLink{
+		URL:       "/api/files/" + kind + "/" + id + "?" + query.Encode(),
+		ExpiresAt: expires,
+	}
+}
+
+type storedObject struct {
+	size     int64
+	mimeType string
+	checksum string
+}
+
+// store validates the file's size and content type and writes it to storage,
+// computing its SHA-256 checksum on the way
+func (s *FileService) store(key string, file *multipart.FileHeader) (*storedObject, error) {
+	if file.Size > s.maxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	mimeType := storage.DetectContentType(head, file.Filename)
+	if !storage.Allowed(mimeType, s.allowedTypes) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFileType, mimeType)
+	}
+
+	hash := sha256.New()
+	body := io.TeeReader(io.MultiReader(bytes.NewReader(head), src), hash)
+	err = s.storage.Put(context.Background(), key, body, file.Size, mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storedObject{
+		size:     file.Size,
+		mimeType: mimeType,
+		checksum: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func titleOrFileName(title string, fileName string) string {
+	if title != "" {
+		return title
+	}
+	return filepath.Base(fileName)
+}
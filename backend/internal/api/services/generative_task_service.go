@@ -1,47 +1,136 @@
 package services
 
 import (
-	"math/rand"
+	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/events"
+	"github.com/TheApostroff/skill-space/internal/sandbox"
+	"github.com/TheApostroff/skill-space/internal/taskgen"
 )
 
+// generativeTaskPoints is what a generative task is worth in the gradebook
+const generativeTaskPoints = 100
+
 type GenerativeTaskService struct {
-	repo *repositories.GenerativeTaskRepository
+	repo              *repositories.GenerativeTaskRepository
+	access            courseAccess
+	gradeService      *GradeService
+	similarityService *SimilarityService
+	generator         taskgen.Generator
+	runner            *sandbox.Runner
+	transactor        *repositories.Transactor
+	bus               *events.Bus
+	cfg               *config.Generator
 }
 
-func NewGenerativeTaskService(repo *repositories.GenerativeTaskRepository) *GenerativeTaskService {
-	return &GenerativeTaskService{repo: repo}
+func NewGenerativeTaskService(
+	repo *repositories.GenerativeTaskRepository,
+	courseRepo *repositories.CourseRepository,
+	sectionRepo *repositories.SectionRepository,
+	activityRepo *repositories.ActivityRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	gradeService *GradeService,
+	similarityService *SimilarityService,
+	generator taskgen.Generator,
+	runner *sandbox.Runner,
+	transactor *repositories.Transactor,
+	bus *events.Bus,
+	cfg *config.Generator,
+) *GenerativeTaskService {
+	return &GenerativeTaskService{
+		repo: repo,
+		access: courseAccess{
+			courseRepo:     courseRepo,
+			sectionRepo:    sectionRepo,
+			activityRepo:   activityRepo,
+			enrollmentRepo: enrollmentRepo,
+		},
+		gradeService:      gradeService,
+		similarityService: similarityService,
+		generator:         generator,
+		runner:            runner,
+		transactor:        transactor,
+		bus:               bus,
+		cfg:               cfg,
+	}
 }
 
-func (s *GenerativeTaskService) GenerateTask(req *models.GenerativeTaskGenerateRequest) (*models.GenerativeTask, error) {
-	// Mock AI generation - in a real implementation, this would call an AI service
+// GenerateTask has a task generated for a student enrolled in the activity's
+// course. Each student may generate at most cfg.RateLimit tasks per
+// cfg.RateWindow, since every task costs a call to the generator.
+func (s *GenerativeTaskService) GenerateTask(
+	ctx context.Context,
+	req *models.GenerativeTaskGenerateRequest,
+	source *models.AuditSource,
+) (*models.GenerativeTask, error) {
+	activity, course, err := s.access.activityCourse(req.ActivityID)
+	if err != nil {
+		return nil, err
+	}
+	if activity.Type != models.ActivityTypeGenerativeTask {
+		return nil, ErrNotGenerativeTask
+	}
+	err = s.access.checkEnrolled(course, req.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.RateLimit > 0 {
+		count, err := s.repo.CountByStudentSince(req.StudentID, time.Now().Add(-s.cfg.RateWindow))
+		if err != nil {
+			return nil, err
+		}
+		if count >= int64(s.cfg.RateLimit) {
+			return nil, ErrGenerationLimit
+		}
+	}
+
+	// Activities may pin the language their tasks are written in
+	language := s.cfg.DefaultLanguage
+	if value, ok := activity.Metadata["language"].(string); ok {
+		if _, supported := sandbox.Languages[value]; supported {
+			language = value
+		}
+	}
+
+	generated, err := s.generator.Generate(ctx, &taskgen.Request{
+		Activity:   activity,
+		Difficulty: req.Difficulty,
+		Language:   language,
+		StudentID:  req.StudentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	task := &models.GenerativeTask{
-		ID:          GenerateID(),
-		ActivityID:  req.ActivityID,
-		StudentID:   req.StudentID,
-		Title:       "AI Generated Task - " + req.Difficulty,
-		Description: "Create a responsive web component using modern CSS techniques",
-		Requirements: models.StringSlice{
-			"Use CSS Grid or Flexbox for layout",
-			"Make it responsive for all screen sizes",
-			"Include hover effects and transitions",
-			"Follow accessibility guidelines",
-		},
+		ID:            GenerateID(),
+		ActivityID:    req.ActivityID,
+		StudentID:     req.StudentID,
+		Title:         generated.Title,
+		Description:   generated.Description,
+		Requirements:  models.StringSlice(generated.Requirements),
 		Difficulty:    req.Difficulty,
-		EstimatedTime: 30,
-		Hints: models.StringSlice{
-			"Start with mobile-first approach",
-			"Use CSS custom properties for theming",
-			"Test your code frequently",
-		},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Language:      generated.Language,
+		EstimatedTime: generated.EstimatedTime,
+		Hints:         models.StringSlice(generated.Hints),
+		Tests:         models.TaskTests(generated.Tests),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
-	err := s.repo.Create(task)
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.GenerativeTasks.Create(task)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "generative_task", task.ID, nil, task)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -49,9 +138,44 @@ func (s *GenerativeTaskService) GenerateTask(req *models.GenerativeTaskGenerateR
 	return task, nil
 }
 
-func (s *GenerativeTaskService) SubmitTask(req *models.GenerativeTaskSubmitRequest) (*models.GenerativeTaskSubmission, error) {
-	// Mock evaluation - in a real implementation, this would evaluate the code
-	score := rand.Intn(40) + 60 // Random score between 60-100
+// SubmitTask runs the code against the task's tests, one after another. Runs
+// wait for a free sandbox and stop when ctx is cancelled.
+func (s *GenerativeTaskService) SubmitTask(
+	ctx context.Context,
+	req *models.GenerativeTaskSubmitRequest,
+	source *models.AuditSource,
+) (*models.GenerativeTaskSubmission, error) {
+	task, err := s.repo.GetByID(req.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.StudentID != req.StudentID {
+		return nil, ErrForbidden
+	}
+	if len(task.Tests) == 0 {
+		return nil, ErrTaskHasNoTests
+	}
+	activity, course, err := s.access.activityCourse(task.ActivityID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.access.checkEnrolled(course, req.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	testCases, err := s.evaluate(ctx, task, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	passed := 0
+	for _, testCase := range testCases {
+		if testCase.Passed {
+			passed++
+		}
+	}
+	score := passed * generativeTaskPoints / len(testCases)
 
 	submission := &models.GenerativeTaskSubmission{
 		ID:        GenerateID(),
@@ -59,40 +183,135 @@ func (s *GenerativeTaskService) SubmitTask(req *models.GenerativeTaskSubmitReque
 		StudentID: req.StudentID,
 		Code:      req.Code,
 		Score:     score,
-		Feedback:  "Good work! Your solution demonstrates understanding of the concepts.",
-		TestCases: []models.TestCase{
-			{
-				ID:     GenerateID(),
-				Name:   "Responsive Design",
-				Passed: true,
-			},
-			{
-				ID:     GenerateID(),
-				Name:   "CSS Implementation",
-				Passed: true,
-			},
-			{
-				ID:     GenerateID(),
-				Name:   "Accessibility",
-				Passed: score > 80,
-			},
-		},
+		Feedback:  fmt.Sprintf("Passed %d of %d test cases.", passed, len(testCases)),
+		TestCases: testCases,
 		CreatedAt: time.Now(),
 	}
 
-	err := s.repo.CreateSubmission(submission)
+	// The submission and the grade it earns are saved together
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.GenerativeTasks.CreateSubmission(submission)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(tx, source, models.AuditCreate, "generative_task_submission", submission.ID, nil, submission)
+		if err != nil {
+			return err
+		}
+		return s.recordGrade(tx, activity, course, submission, source)
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.announceGraded(activity, course, submission)
+
+	s.similarityService.Check(models.SimilarityKindGenerativeTask, submission.ID)
 
 	return submission, nil
 }
 
-func (s *GenerativeTaskService) GetTaskHints(taskID string, difficulty string) ([]string, error) {
+// recordGrade stores the student's best score for the task's activity in the
+// gradebook, with the activity standing in for the assignment
+func (s *GenerativeTaskService) recordGrade(
+	tx *repositories.TxRepositories,
+	activity *models.Activity,
+	course *models.Course,
+	submission *models.GenerativeTaskSubmission,
+	source *models.AuditSource,
+) error {
+	best, err := tx.GenerativeTasks.GetBestScore(activity.ID, submission.StudentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.gradeService.recordGrade(tx, &GradeEntry{
+		StudentID:       submission.StudentID,
+		AssignmentID:    activity.ID,
+		AssignmentTitle: activity.Title,
+		CourseID:        course.ID,
+		Source:          models.GradeSourceGenerativeTask,
+		Score:           best,
+		TotalPoints:     generativeTaskPoints,
+		Feedback:        submission.Feedback,
+		GradedBy:        models.SystemGrader,
+	}, source)
+	return err
+}
+
+// announceGraded publishes the graded submission, with the activity standing
+// in for the assignment
+func (s *GenerativeTaskService) announceGraded(
+	activity *models.Activity,
+	course *models.Course,
+	submission *models.GenerativeTaskSubmission,
+) {
+	s.bus.Publish(models.EventSubmissionGraded, models.SubmissionGradedEvent{
+		Assignment: models.Assignment{
+			ID:          activity.ID,
+			Title:       activity.Title,
+			CourseID:    course.ID,
+			TotalPoints: generativeTaskPoints,
+		},
+		Submission: models.Submission{
+			ID:          submission.ID,
+			StudentID:   submission.StudentID,
+			Status:      models.SubmissionGraded,
+			Score:       &submission.Score,
+			Feedback:    &submission.Feedback,
+			SubmittedAt: submission.CreatedAt,
+		},
+	})
+}
+
+// evaluate runs the code against every test defined on the task
+func (s *GenerativeTaskService) evaluate(ctx context.Context, task *models.GenerativeTask, code string) ([]models.TestCase, error) {
+	testCases := make([]models.TestCase, 0, len(task.Tests))
+	for _, test := range task.Tests {
+		result, err := s.runner.Run(ctx, task.Language, code, test.Input)
+		if err != nil {
+			return nil, err
+		}
+
+		testCases = append(testCases, models.TestCase{
+			ID:         GenerateID(),
+			Name:       test.Name,
+			Passed:     !result.TimedOut && result.ExitCode == 0 && normalizeOutput(result.Stdout) == normalizeOutput(test.ExpectedOutput),
+			Stdout:     result.Stdout,
+			Stderr:     result.Stderr,
+			ExitCode:   result.ExitCode,
+			TimedOut:   result.TimedOut,
+			DurationMs: result.Duration.Milliseconds(),
+		})
+	}
+	return testCases, nil
+}
+
+// normalizeOutput ignores line-ending style, trailing spaces and trailing
+// blank lines when comparing program output
+func normalizeOutput(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// GetTaskHints returns the hints of a task to the student it was generated for
+// and to the staff of its course
+func (s *GenerativeTaskService) GetTaskHints(taskID string, viewer *models.APIUser) ([]string, error) {
 	task, err := s.repo.GetByID(taskID)
 	if err != nil {
 		return nil, err
 	}
+	if task.StudentID != viewer.ID {
+		_, course, err := s.access.activityCourse(task.ActivityID)
+		if err != nil {
+			return nil, err
+		}
+		if !isModerator(course, viewer) {
+			return nil, ErrForbidden
+		}
+	}
 
 	return []string(task.Hints), nil
 }
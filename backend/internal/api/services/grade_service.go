@@ -1,52 +1,139 @@
 package services
 
 import (
+	"errors"
 	"time"
 
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
 )
 
+// GradeEntry describes a graded piece of work to record in the gradebook
+type GradeEntry struct {
+	StudentID       string
+	AssignmentID    string
+	AssignmentTitle string
+	CourseID        string
+	Source          string
+	Score           int
+	TotalPoints     int
+	Feedback        string
+	GradedBy        string
+}
+
 type GradeService struct {
 	gradeRepo      *repositories.GradeRepository
 	enrollmentRepo *repositories.EnrollmentRepository
+	schemeRepo     *repositories.GradingSchemeRepository
+	assignmentRepo *repositories.AssignmentRepository
+	courseRepo     *repositories.CourseRepository
+	userRepo       *repositories.UserRepository
+	transactor     *repositories.Transactor
 }
 
-func NewGradeService(gradeRepo *repositories.GradeRepository, enrollmentRepo *repositories.EnrollmentRepository) *GradeService {
+func NewGradeService(
+	gradeRepo *repositories.GradeRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	schemeRepo *repositories.GradingSchemeRepository,
+	assignmentRepo *repositories.AssignmentRepository,
+	courseRepo *repositories.CourseRepository,
+	userRepo *repositories.UserRepository,
+	transactor *repositories.Transactor,
+) *GradeService {
 	return &GradeService{
 		gradeRepo:      gradeRepo,
 		enrollmentRepo: enrollmentRepo,
+		schemeRepo:     schemeRepo,
+		assignmentRepo: assignmentRepo,
+		courseRepo:     courseRepo,
+		userRepo:       userRepo,
+		transactor:     transactor,
 	}
 }
 
-func (s *GradeService) GetAllGrades() ([]models.Grade, error) {
-	return s.gradeRepo.GetAll()
+// GetAllGrades returns every grade for admins and the grades of their own
+// courses for instructors
+func (s *GradeService) GetAllGrades(viewer *models.APIUser, q *models.ListQuery) ([]models.Grade, int64, error) {
+	if viewer.Role == models.RoleAdmin {
+		return s.gradeRepo.List(q)
+	}
+	return s.gradeRepo.ListByInstructorID(viewer.ID, q)
 }
 
-func (s *GradeService) GetGradesByStudentID(studentID string) ([]models.Grade, error) {
-	return s.gradeRepo.GetByStudentID(studentID)
+// GetGradesByStudentID returns the student's grades. Professors only see the
+// grades from the courses they teach.
+func (s *GradeService) GetGradesByStudentID(
+	studentID string,
+	viewer *models.APIUser,
+	q *models.ListQuery,
+) ([]models.Grade, int64, error) {
+	if viewer.Role == models.RoleProfessor && viewer.ID != studentID {
+		return s.gradeRepo.ListByStudentAndInstructorID(studentID, viewer.ID, q)
+	}
+	return s.gradeRepo.ListByStudentID(studentID, q)
 }
 
-func (s *GradeService) GetAllEnrollments() ([]models.Enrollment, error) {
-	return s.enrollmentRepo.GetAll()
-}
+// recordGrade creates the student's grade for the assignment in the caller's
+// transaction, or updates it if the work has been graded before. Grades are
+// upserted, so concurrent grading of the same work leaves one grade.
+func (s *GradeService) recordGrade(
+	tx *repositories.TxRepositories,
+	entry *GradeEntry,
+	source *models.AuditSource,
+) (*models.Grade, error) {
+	student, err := s.userRepo.GetByID(entry.StudentID)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *GradeService) CreateEnrollment(req *models.EnrollmentCreateRequest) (*models.Enrollment, error) {
-	enrollment := &models.Enrollment{
-		ID:         GenerateID(),
-		StudentID:  req.StudentID,
-		CourseID:   req.CourseID,
-		EnrolledAt: time.Now(),
-		Status:     "active",
-		Progress:   0,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+	course, err := s.courseRepo.GetByID(entry.CourseID)
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.enrollmentRepo.Create(enrollment)
+	scheme, err := s.GetGradingScheme(course.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return enrollment, nil
+	now := time.Now()
+	grade := &models.Grade{
+		ID:              GenerateID(),
+		StudentID:       entry.StudentID,
+		StudentName:     student.Name,
+		AssignmentID:    entry.AssignmentID,
+		AssignmentTitle: entry.AssignmentTitle,
+		Source:          entry.Source,
+		CourseID:        course.ID,
+		CourseName:      course.Title,
+		Score:           entry.Score,
+		TotalPoints:     entry.TotalPoints,
+		LetterGrade:     gradeLabel(scheme, percentage(entry.Score, entry.TotalPoints)),
+		Feedback:        entry.Feedback,
+		GradedBy:        entry.GradedBy,
+		GradedAt:        now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	before, err := tx.Grades.GetForUpdate(entry.StudentID, entry.AssignmentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	action := models.AuditUpdate
+	if before == nil {
+		action = models.AuditCreate
+	}
+
+	err = tx.Grades.Upsert(grade)
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordAudit(tx, source, action, "grade", grade.ID, before, grade)
+	if err != nil {
+		return nil, err
+	}
+	return grade, nil
 }
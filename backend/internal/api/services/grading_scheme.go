@@ -0,0 +1,414 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidGradingScheme = errors.New("invalid grading scheme")
+
+// defaultCutoffs is the letter scale used by courses without a custom scheme
+var defaultCutoffs = models.GradeCutoffs{
+	{Label: "A", Minimum: 93}, {Label: "A-", Minimum: 90},
+	{Label: "B+", Minimum: 87}, {Label: "B", Minimum: 83}, {Label: "B-", Minimum: 80},
+	{Label: "C+", Minimum: 77}, {Label: "C", Minimum: 73}, {Label: "C-", Minimum: 70},
+	{Label: "D+", Minimum: 67}, {Label: "D", Minimum: 63}, {Label: "D-", Minimum: 60},
+	{Label: "F", Minimum: 0},
+}
+
+const defaultPassThreshold = 60
+
+// generativeTaskCategory is the grading category of generative task grades
+const generativeTaskCategory = "generative_task"
+
+func defaultGradingScheme(courseID string) *models.GradingScheme {
+	return &models.GradingScheme{
+		CourseID:      courseID,
+		Type:          models.GradingSchemeLetter,
+		Cutoffs:       append(models.GradeCutoffs{}, defaultCutoffs...),
+		PassThreshold: defaultPassThreshold,
+		Weights:       models.CategoryWeights{},
+	}
+}
+
+// GetGradingScheme returns the course's grading scheme, or the default letter
+// scheme if the instructor has not configured one
+func (s *GradeService) GetGradingScheme(courseID string) (*models.GradingScheme, error) {
+	scheme, err := s.schemeRepo.GetByCourseID(courseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultGradingScheme(courseID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// UpdateGradingScheme saves the course's grading scheme and relabels the
+// grades already recorded for the course. The audit trail records the scheme
+// and every grade whose label changes.
+func (s *GradeService) UpdateGradingScheme(
+	courseID string,
+	req *models.GradingSchemeUpdateRequest,
+	source *models.AuditSource,
+) (*models.GradingScheme, error) {
+	_, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme, err := s.GetGradingScheme(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme.Type = req.Type
+	scheme.PassThreshold = req.PassThreshold
+	scheme.Cutoffs = models.GradeCutoffs(req.Cutoffs)
+	scheme.Weights = models.CategoryWeights(req.Weights)
+	if scheme.Weights == nil {
+		scheme.Weights = models.CategoryWeights{}
+	}
+
+	switch scheme.Type {
+	case models.GradingSchemeLetter:
+		if len(scheme.Cutoffs) == 0 {
+			scheme.Cutoffs = append(models.GradeCutoffs{}, defaultCutoffs...)
+		}
+	case models.GradingSchemeCustom:
+		if len(scheme.Cutoffs) == 0 {
+			return nil, fmt.Errorf("%w: a custom scheme needs at least one cutoff", ErrInvalidGradingScheme)
+		}
+	case models.GradingSchemePassFail:
+		if scheme.PassThreshold == 0 {
+			scheme.PassThreshold = defaultPassThreshold
+		}
+	}
+
+	err = validateGradingScheme(scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(scheme.Cutoffs, func(i, j int) bool {
+		return scheme.Cutoffs[i].Minimum > scheme.Cutoffs[j].Minimum
+	})
+
+	now := time.Now()
+	if scheme.CreatedAt.IsZero() {
+		scheme.CreatedAt = now
+	}
+	scheme.UpdatedAt = now
+
+	// The scheme and the relabelled grades are saved together, so no grade
+	// keeps a label of the old scheme if one of the updates fails
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		_, err := tx.Courses.GetByIDForUpdate(courseID)
+		if err != nil {
+			return err
+		}
+
+		// The default scheme of a course without one is not stored
+		before, err := tx.GradingSchemes.GetByCourseID(courseID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		action := models.AuditUpdate
+		if before == nil {
+			action = models.AuditCreate
+		}
+
+		err = tx.GradingSchemes.Save(scheme)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(tx, source, action, "grading_scheme", courseID, before, scheme)
+		if err != nil {
+			return err
+		}
+
+		grades, err := tx.Grades.GetByCourseID(courseID)
+		if err != nil {
+			return err
+		}
+		for i := range grades {
+			label := gradeLabel(scheme, percentage(grades[i].Score, grades[i].TotalPoints))
+			if label == grades[i].LetterGrade {
+				continue
+			}
+			old, err := snapshot(&grades[i])
+			if err != nil {
+				return err
+			}
+			grades[i].LetterGrade = label
+			err = tx.Grades.Update(&grades[i])
+			if err != nil {
+				return err
+			}
+			err = recordAudit(tx, source, models.AuditUpdate, "grade", grades[i].ID, old, &grades[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return scheme, nil
+}
+
+func validateGradingScheme(scheme *models.GradingScheme) error {
+	for _, cutoff := range scheme.Cutoffs {
+		if cutoff.Label == "" {
+			return fmt.Errorf("%w: every cutoff needs a label", ErrInvalidGradingScheme)
+		}
+		if cutoff.Minimum < 0 || cutoff.Minimum > 100 {
+			return fmt.Errorf("%w: cutoff %q must be between 0 and 100", ErrInvalidGradingScheme, cutoff.Label)
+		}
+	}
+	if scheme.PassThreshold < 0 || scheme.PassThreshold > 100 {
+		return fmt.Errorf("%w: pass threshold must be between 0 and 100", ErrInvalidGradingScheme)
+	}
+	for category, weight := range scheme.Weights {
+		if weight < 0 {
+			return fmt.Errorf("%w: weight of %q must not be negative", ErrInvalidGradingScheme, category)
+		}
+	}
+	return nil
+}
+
+// GetCourseGrades returns the running course grade of every student who is
+// enrolled in or has been graded in the course
+func (s *GradeService) GetCourseGrades(courseID string) ([]models.CourseGrade, error) {
+	_, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	grades, err := s.gradeRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollments, err := s.enrollmentRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	byStudent := map[string][]models.Grade{}
+	studentIDs := []string{}
+	for _, enrollment := range enrollments {
+		if enrollment.Status == models.EnrollmentWaitlisted {
+			continue
+		}
+		if _, seen := byStudent[enrollment.StudentID]; !seen {
+			byStudent[enrollment.StudentID] = []models.Grade{}
+			studentIDs = append(studentIDs, enrollment.StudentID)
+		}
+	}
+	for _, grade := range grades {
+		if _, seen := byStudent[grade.StudentID]; !seen {
+			studentIDs = append(studentIDs, grade.StudentID)
+		}
+		byStudent[grade.StudentID] = append(byStudent[grade.StudentID], grade)
+	}
+
+	scheme, categories, err := s.gradingContext(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	courseGrades := make([]models.CourseGrade, 0, len(studentIDs))
+	for _, studentID := range studentIDs {
+		courseGrade, err := s.buildCourseGrade(courseID, studentID, byStudent[studentID], scheme, categories)
+		if err != nil {
+			return nil, err
+		}
+		courseGrades = append(courseGrades, *courseGrade)
+	}
+
+	sort.Slice(courseGrades, func(i, j int) bool {
+		return courseGrades[i].StudentName < courseGrades[j].StudentName
+	})
+
+	return courseGrades, nil
+}
+
+// GetCourseGrade returns one student's running course grade
+func (s *GradeService) GetCourseGrade(courseID string, studentID string) (*models.CourseGrade, error) {
+	_, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	grades, err := s.gradeRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	own := []models.Grade{}
+	for _, grade := range grades {
+		if grade.StudentID == studentID {
+			own = append(own, grade)
+		}
+	}
+
+	scheme, categories, err := s.gradingContext(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildCourseGrade(courseID, studentID, own, scheme, categories)
+}
+
+// gradingContext loads the course's scheme and the category of each assignment
+func (s *GradeService) gradingContext(courseID string) (*models.GradingScheme, map[string]string, error) {
+	scheme, err := s.GetGradingScheme(courseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	assignments, err := s.assignmentRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	categories := make(map[string]string, len(assignments))
+	for _, assignment := range assignments {
+		categories[assignment.ID] = assignment.Type
+	}
+
+	return scheme, categories, nil
+}
+
+func (s *GradeService) buildCourseGrade(
+	courseID string,
+	studentID string,
+	grades []models.Grade,
+	scheme *models.GradingScheme,
+	categories map[string]string,
+) (*models.CourseGrade, error) {
+	studentName := ""
+	if len(grades) > 0 {
+		studentName = grades[0].StudentName
+	} else {
+		student, err := s.userRepo.GetByID(studentID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if student != nil {
+			studentName = student.Name
+		}
+	}
+
+	breakdown, overall := weightedPercentage(grades, scheme.Weights, categories)
+
+	courseGrade := &models.CourseGrade{
+		CourseID:    courseID,
+		StudentID:   studentID,
+		StudentName: studentName,
+		Categories:  breakdown,
+		GradedItems: len(grades),
+		Percentage:  overall,
+	}
+	if len(grades) > 0 {
+		courseGrade.FinalGrade = gradeLabel(scheme, overall)
+	}
+
+	return courseGrade, nil
+}
+
+// weightedPercentage groups grades by category and combines the category
+// percentages using the weights. Without weights every point counts equally;
+// with weights, categories that have no weight are reported but not counted.
+func weightedPercentage(
+	grades []models.Grade,
+	weights models.CategoryWeights,
+	categories map[string]string,
+) ([]models.CategoryGrade, float64) {
+	totals := map[string]*models.CategoryGrade{}
+	order := []string{}
+	earned, possible := 0, 0
+
+	for _, grade := range grades {
+		category := generativeTaskCategory
+		if grade.Source != models.GradeSourceGenerativeTask {
+			category = categories[grade.AssignmentID]
+			if category == "" {
+				category = "other"
+			}
+		}
+
+		total, ok := totals[category]
+		if !ok {
+			total = &models.CategoryGrade{Category: category, Weight: weights[category]}
+			totals[category] = total
+			order = append(order, category)
+		}
+		total.PointsEarned += grade.Score
+		total.PointsPossible += grade.TotalPoints
+		earned += grade.Score
+		possible += grade.TotalPoints
+	}
+
+	sort.Strings(order)
+	breakdown := make([]models.CategoryGrade, 0, len(order))
+	weightedSum, weightTotal := 0.0, 0.0
+	for _, category := range order {
+		total := totals[category]
+		total.Percentage = percentage(total.PointsEarned, total.PointsPossible)
+		if total.Weight > 0 && total.PointsPossible > 0 {
+			weightedSum += total.Weight * total.Percentage
+			weightTotal += total.Weight
+		}
+		breakdown = append(breakdown, *total)
+	}
+
+	if len(weights) == 0 {
+		return breakdown, percentage(earned, possible)
+	}
+	if weightTotal == 0 {
+		return breakdown, 0
+	}
+	return breakdown, round2(weightedSum / weightTotal)
+}
+
+// gradeLabel converts a percentage to the label the scheme awards for it
+func gradeLabel(scheme *models.GradingScheme, percent float64) string {
+	if scheme.Type == models.GradingSchemePassFail {
+		if percent >= scheme.PassThreshold {
+			return "Pass"
+		}
+		return "Fail"
+	}
+
+	for _, cutoff := range scheme.Cutoffs {
+		if percent >= cutoff.Minimum {
+			return cutoff.Label
+		}
+	}
+	if scheme.Type == models.GradingSchemeLetter {
+		return "F"
+	}
+	return ""
+}
+
+func percentage(score int, totalPoints int) float64 {
+	if totalPoints <= 0 {
+		return 0
+	}
+	return round2(float64(score) / float64(totalPoints) * 100)
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidDueDate   = errors.New("due date must be a date or timestamp such as 2025-09-30 or 2025-09-30T23:59:00Z")
+	ErrSubmissionClosed = errors.New("the deadline for this assignment has passed")
+)
+
+// dueDateLayouts are the accepted due date formats. Layouts without a zone
+// are read as UTC.
+var dueDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// parseDueDate parses a due date. A plain date means the end of that day.
+func parseDueDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dueDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidDueDate, value)
+	}
+	return day.Add(24*time.Hour - time.Second).UTC(), nil
+}
+
+// daysLate returns how many started days after the deadline, including the
+// grace period, the work was submitted. Work submitted on time is 0 days late.
+func daysLate(assignment *models.Assignment, dueDate time.Time, submittedAt time.Time) int {
+	deadline := dueDate.Add(time.Duration(assignment.GracePeriodMinutes) * time.Minute)
+	if !submittedAt.After(deadline) {
+		return 0
+	}
+	return int(math.Ceil(submittedAt.Sub(deadline).Hours() / 24))
+}
+
+// acceptsLate reports whether the assignment's policy accepts work that is
+// the given number of days late
+func acceptsLate(assignment *models.Assignment, days int) bool {
+	if days == 0 {
+		return true
+	}
+	if assignment.LatePolicy == models.LatePolicyReject {
+		return false
+	}
+	return assignment.MaxLateDays <= 0 || days <= assignment.MaxLateDays
+}
+
+// latePenalty returns the percentage deducted from work that is the given
+// number of days late
+func latePenalty(assignment *models.Assignment, days int) float64 {
+	if assignment.LatePolicy != models.LatePolicyPenalty || days == 0 {
+		return 0
+	}
+	return math.Min(100, float64(days)*assignment.LatePenaltyPercent)
+}
+
+// applyPenalty deducts a percentage from a score, rounding to whole points
+func applyPenalty(score int, penalty float64) int {
+	return int(math.Round(float64(score) * (100 - penalty) / 100))
+}
+
+// effectiveDueDate returns the student's due date, taking an extension into
+// account
+func (s *AssignmentService) effectiveDueDate(assignment *models.Assignment, studentID string) (time.Time, error) {
+	extension, err := s.repo.GetExtension(assignment.ID, studentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return assignment.DueDate, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return extension.DueDate, nil
+}
+
+// GrantExtension gives the student a new due date for the assignment,
+// replacing any earlier extension. The student must be actively enrolled in
+// the assignment's course.
+func (s *AssignmentService) GrantExtension(
+	assignmentID string,
+	req *models.AssignmentExtensionRequest,
+	grantor *models.APIUser,
+	source *models.AuditSource,
+) (*models.AssignmentExtension, error) {
+	assignment, err := s.repo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	dueDate, err := parseDueDate(req.DueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	extension := &models.AssignmentExtension{
+		ID:           GenerateID(),
+		AssignmentID: assignment.ID,
+		StudentID:    req.StudentID,
+		DueDate:      dueDate,
+		Reason:       req.Reason,
+		GrantedBy:    grantor.ID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		_, err := tx.Users.GetByID(req.StudentID)
+		if err != nil {
+			return err
+		}
+		enrollment, err := tx.Enrollments.GetCurrent(assignment.CourseID, req.StudentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && enrollment.Status != models.EnrollmentActive) {
+			return ErrStudentNotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+
+		// Lock the assignment so the extension this one replaces is the one recorded
+		_, err = tx.Assignments.GetByIDForUpdate(assignment.ID)
+		if err != nil {
+			return err
+		}
+		before, err := tx.Assignments.GetExtension(assignment.ID, req.StudentID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		action := models.AuditCreate
+		if before != nil {
+			action = models.AuditUpdate
+			extension.ID = before.ID
+			extension.CreatedAt = before.CreatedAt
+		}
+
+		err = tx.Assignments.SaveExtension(extension)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, action, "assignment_extension", extension.ID, before, extension)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return extension, nil
+}
+
+func (s *AssignmentService) GetExtensions(assignmentID string) ([]models.AssignmentExtension, error) {
+	return s.repo.GetExtensions(assignmentID)
+}
+
+// withPenaltyNote appends a note about the late penalty to the feedback
+func withPenaltyNote(feedback string, submission *models.Submission) string {
+	if submission.LatePenalty == 0 {
+		return feedback
+	}
+	note := fmt.Sprintf("Late penalty: %g%% deducted for %d day(s) late.", submission.LatePenalty, submission.LateDays)
+	if feedback == "" {
+		return note
+	}
+	return feedback + "\n\n" + note
+}
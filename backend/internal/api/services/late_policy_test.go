@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+)
+
+func TestParseDueDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2025-09-30", want: time.Date(2025, 9, 30, 23, 59, 59, 0, time.UTC)},
+		{value: " 2025-09-30T12:00:00Z ", want: time.Date(2025, 9, 30, 12, 0, 0, 0, time.UTC)},
+		{value: "2025-09-30T12:00:00+02:00", want: time.Date(2025, 9, 30, 10, 0, 0, 0, time.UTC)},
+		{value: "2025-09-30T12:00", want: time.Date(2025, 9, 30, 12, 0, 0, 0, time.UTC)},
+		{value: "2025-09-30 12:00:30", want: time.Date(2025, 9, 30, 12, 0, 30, 0, time.UTC)},
+		{value: "30/09/2025", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseDueDate(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDueDate) {
+					t.Fatalf("err = %v, want ErrInvalidDueDate", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDueDate: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDueDate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaysLate(t *testing.T) {
+	due := time.Date(2025, 9, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		grace       int
+		submittedAt time.Time
+		want        int
+	}{
+		{name: "early", submittedAt: due.Add(-time.Hour), want: 0},
+		{name: "on the deadline", submittedAt: due, want: 0},
+		{name: "a second late", submittedAt: due.Add(time.Second), want: 1},
+		{name: "exactly a day late", submittedAt: due.Add(24 * time.Hour), want: 1},
+		{name: "into the second day", submittedAt: due.Add(25 * time.Hour), want: 2},
+		{name: "within the grace period", grace: 30, submittedAt: due.Add(30 * time.Minute), want: 0},
+		{name: "after the grace period", grace: 30, submittedAt: due.Add(31 * time.Minute), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignment := &models.Assignment{GracePeriodMinutes: tt.grace}
+			if got := daysLate(assignment, due, tt.submittedAt); got != tt.want {
+				t.Errorf("daysLate = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatePolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		assignment  models.Assignment
+		days        int
+		wantAccepts bool
+		wantPenalty float64
+	}{
+		{name: "on time under reject", assignment: models.Assignment{LatePolicy: models.LatePolicyReject}, days: 0, wantAccepts: true},
+		{name: "late under reject", assignment: models.Assignment{LatePolicy: models.LatePolicyReject}, days: 1},
+		{name: "late under accept", assignment: models.Assignment{LatePolicy: models.LatePolicyAccept, LatePenaltyPercent: 10}, days: 5, wantAccepts: true},
+		{name: "accept within the late days", assignment: models.Assignment{LatePolicy: models.LatePolicyAccept, MaxLateDays: 2}, days: 2, wantAccepts: true},
+		{name: "accept past the late days", assignment: models.Assignment{LatePolicy: models.LatePolicyAccept, MaxLateDays: 2}, days: 3},
+		{name: "penalty on time", assignment: models.Assignment{LatePolicy: models.LatePolicyPenalty, LatePenaltyPercent: 10}, days: 0, wantAccepts: true},
+		{name: "penalty per day", assignment: models.Assignment{LatePolicy: models.LatePolicyPenalty, LatePenaltyPercent: 10}, days: 3, wantAccepts: true, wantPenalty: 30},
+		{name: "penalty capped at everything", assignment: models.Assignment{LatePolicy: models.LatePolicyPenalty, LatePenaltyPercent: 40}, days: 3, wantAccepts: true, wantPenalty: 100},
+		{name: "penalty past the late days", assignment: models.Assignment{LatePolicy: models.LatePolicyPenalty, LatePenaltyPercent: 10, MaxLateDays: 1}, days: 2, wantPenalty: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := acceptsLate(&tt.assignment, tt.days); got != tt.wantAccepts {
+				t.Errorf("acceptsLate = %v, want %v", got, tt.wantAccepts)
+			}
+			if got := latePenalty(&tt.assignment, tt.days); got != tt.wantPenalty {
+				t.Errorf("latePenalty = %v, want %v", got, tt.wantPenalty)
+			}
+		})
+	}
+}
+
+func TestApplyPenalty(t *testing.T) {
+	tests := []struct {
+		score   int
+		penalty float64
+		want    int
+	}{
+		{score: 80, penalty: 0, want: 80},
+		{score: 80, penalty: 25, want: 60},
+		{score: 7, penalty: 10, want: 6},
+		{score: 5, penalty: 10, want: 5},
+		{score: 80, penalty: 100, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := applyPenalty(tt.score, tt.penalty); got != tt.want {
+			t.Errorf("applyPenalty(%d, %v) = %d, want %d", tt.score, tt.penalty, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+type MessageService struct {
+	repo           *repositories.MessageRepository
+	userRepo       *repositories.UserRepository
+	courseRepo     *repositories.CourseRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+	transactor     *repositories.Transactor
+}
+
+func NewMessageService(
+	repo *repositories.MessageRepository,
+	userRepo *repositories.UserRepository,
+	courseRepo *repositories.CourseRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	transactor *repositories.Transactor,
+) *MessageService {
+	return &MessageService{
+		repo:           repo,
+		userRepo:       userRepo,
+		courseRepo:     courseRepo,
+		enrollmentRepo: enrollmentRepo,
+		transactor:     transactor,
+	}
+}
+
+// GetConversations lists the viewer's conversations with their last message
+// and the viewer's unread count
+func (s *MessageService) GetConversations(viewer *models.APIUser, q *models.ListQuery) ([]models.Conversation, int64, error) {
+	conversations, total, err := s.repo.ListConversations(viewer.ID, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = s.summarize(viewer.ID, conversations)
+	if err != nil {
+		return nil, 0, err
+	}
+	return conversations, total, nil
+}
+
+// GetConversation returns a conversation the viewer takes part in
+func (s *MessageService) GetConversation(id string, viewer *models.APIUser) (*models.Conversation, error) {
+	conversation, err := s.getJoined(id, viewer)
+	if err != nil {
+		return nil, err
+	}
+	conversations := []models.Conversation{*conversation}
+	err = s.summarize(viewer.ID, conversations)
+	if err != nil {
+		return nil, err
+	}
+	return &conversations[0], nil
+}
+
+// CreateConversation starts a conversation between the viewer and the other
+// participants, sending the first message if the request has one. A direct
+// conversation the two users already have is reused.
+func (s *MessageService) CreateConversation(
+	req *models.ConversationCreateRequest,
+	viewer *models.APIUser,
+	source *models.AuditSource,
+) (*models.Conversation, error) {
+	others := []string{}
+	for _, id := range req.ParticipantIDs {
+		if id != viewer.ID && !slices.Contains(others, id) {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		return nil, ErrInvalidParticipants
+	}
+	users, err := s.userRepo.GetByIDs(others)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) != len(others) {
+		return nil, ErrInvalidParticipants
+	}
+
+	title := strings.TrimSpace(req.Title)
+	var conversation *models.Conversation
+	if len(others) == 1 && title == "" {
+		conversation, err = s.repo.GetDirectConversation(viewer.ID, others[0])
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	created := conversation == nil
+	if created {
+		now := time.Now()
+		conversation = &models.Conversation{
+			ID:             GenerateID(),
+			Type:           models.ConversationGroup,
+			Title:          title,
+			CreatedBy:      viewer.ID,
+			Participants:   []models.ConversationParticipant{newParticipant(viewer, now)},
+			LastActivityAt: now,
+		}
+		if len(others) == 1 && title == "" {
+			conversation.Type = models.ConversationDirect
+		}
+		for i := range users {
+			conversation.Participants = append(conversation.Participants, newParticipant(&users[i], now))
+		}
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		if created {
+			err := tx.Messages.CreateConversation(conversation)
+			if err != nil {
+				return err
+			}
+			err = recordAudit(tx, source, models.AuditCreate, "conversation", conversation.ID, nil, conversation)
+			if err != nil {
+				return err
+			}
+		}
+		if content := strings.TrimSpace(req.Content); content != "" {
+			_, err := s.send(tx, conversation, viewer, content, source)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetConversation(conversation.ID, viewer)
+}
+
+// GetMessages returns a page of a conversation's history with the read
+// receipts of every message
+func (s *MessageService) GetMessages(
+	conversationID string,
+	viewer *models.APIUser,
+	q *models.ListQuery,
+) ([]models.Message, int64, error) {
+	conversation, err := s.getJoined(conversationID, viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages, total, err := s.repo.ListMessages(conversationID, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range messages {
+		messages[i].ReadBy = readBy(conversation, &messages[i])
+	}
+	return messages, total, nil
+}
+
+// SendMessage adds the viewer's message to a conversation they take part in.
+// Only the course's instructor or an admin writes in course announcements.
+func (s *MessageService) SendMessage(
+	conversationID string,
+	req *models.MessageCreateRequest,
+	viewer *models.APIUser,
+	source *models.AuditSource,
+) (*models.Message, error) {
+	conversation, err := s.getJoined(conversationID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.Type == models.ConversationAnnouncement && viewer.Role != models.RoleAdmin {
+		course, err := s.courseRepo.GetByID(*conversation.CourseID)
+		if err != nil {
+			return nil, err
+		}
+		if course.InstructorID != viewer.ID {
+			return nil, ErrNotAnnouncer
+		}
+	}
+
+	var message *models.Message
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		message, err = s.send(tx, conversation, viewer, req.Content, source)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// PostAnnouncement sends a message to the course's announcement conversation,
+// starting it on the first announcement. The course's active students join
+// it before every announcement, and stay in it if they leave the course.
+func (s *MessageService) PostAnnouncement(
+	courseID string,
+	req *models.MessageCreateRequest,
+	viewer *models.APIUser,
+	source *models.AuditSource,
+) (*models.Message, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollments, err := s.enrollmentRepo.GetByCourseAndStatus(courseID, models.EnrollmentActive)
+	if err != nil {
+		return nil, err
+	}
+	members := []string{course.InstructorID, viewer.ID}
+	for _, enrollment := range enrollments {
+		members = append(members, enrollment.StudentID)
+	}
+
+	var message *models.Message
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		conversation, err := tx.Messages.GetCourseAnnouncements(courseID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			conversation = &models.Conversation{
+				ID:             GenerateID(),
+				Type:           models.ConversationAnnouncement,
+				Title:          fmt.Sprintf("%s announcements", course.Title),
+				CourseID:       &course.ID,
+				CreatedBy:      viewer.ID,
+				LastActivityAt: time.Now(),
+			}
+			err = tx.Messages.CreateConversation(conversation)
+			if err != nil {
+				return err
+			}
+			err = recordAudit(tx, source, models.AuditCreate, "conversation", conversation.ID, nil, conversation)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = s.join(tx, conversation, members)
+		if err != nil {
+			return err
+		}
+		message, err = s.send(tx, conversation, viewer, req.Content, source)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// MarkRead marks every message in the conversation read by the viewer
+func (s *MessageService) MarkRead(
+	conversationID string,
+	viewer *models.APIUser,
+	source *models.AuditSource,
+) (*models.Conversation, error) {
+	conversation, err := s.getJoined(conversationID, viewer)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(conversation.Participants, func(p models.ConversationParticipant) bool {
+		return p.UserID == viewer.ID
+	})
+	before := conversation.Participants[i]
+	after := before
+	now := time.Now()
+	if after.LastReadAt == nil || after.LastReadAt.Before(now) {
+		after.LastReadAt = &now
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Messages.MarkRead(conversationID, viewer.ID, now)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "conversation_read", conversationID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetConversation(conversationID, viewer)
+}
+
+// CountUnread counts the viewer's unread messages across all conversations
+func (s *MessageService) CountUnread(viewer *models.APIUser) (*models.UnreadCount, error) {
+	counts, err := s.repo.CountUnread(viewer.ID)
+	if err != nil {
+		return nil, err
+	}
+	unread := &models.UnreadCount{}
+	for _, count := range counts {
+		unread.Count += count
+	}
+	return unread, nil
+}
+
+// getJoined returns the conversation if the viewer takes part in it
+func (s *MessageService) getJoined(id string, viewer *models.APIUser) (*models.Conversation, error) {
+	conversation, err := s.repo.GetConversation(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, participant := range conversation.Participants {
+		if participant.UserID == viewer.ID {
+			return conversation, nil
+		}
+	}
+	return nil, ErrForbidden
+}
+
+// join adds the users who are not in the conversation yet
+func (s *MessageService) join(tx *repositories.TxRepositories, conversation *models.Conversation, userIDs []string) error {
+	missing := []string{}
+	for _, id := range userIDs {
+		joined := slices.ContainsFunc(conversation.Participants, func(p models.ConversationParticipant) bool {
+			return p.UserID == id
+		})
+		if !joined && !slices.Contains(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	users, err := tx.Users.GetByIDs(missing)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	participants := make([]models.ConversationParticipant, 0, len(users))
+	for i := range users {
+		p := newParticipant(&users[i], now)
+		p.ConversationID = conversation.ID
+		participants = append(participants, p)
+	}
+	err = tx.Messages.AddParticipants(participants)
+	if err != nil {
+		return err
+	}
+	conversation.Participants = append(conversation.Participants, participants...)
+	return nil
+}
+
+// send stores the message and counts it as read by its sender
+func (s *MessageService) send(
+	tx *repositories.TxRepositories,
+	conversation *models.Conversation,
+	sender *models.APIUser,
+	content string,
+	source *models.AuditSource,
+) (*models.Message, error) {
+	message := &models.Message{
+		ID:             GenerateID(),
+		ConversationID: conversation.ID,
+		SenderID:       sender.ID,
+		SenderName:     sender.Name,
+		Content:        content,
+		ReadBy:         []string{},
+		CreatedAt:      time.Now(),
+	}
+	err := tx.Messages.CreateMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Messages.MarkRead(conversation.ID, sender.ID, message.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	err = recordAudit(tx, source, models.AuditCreate, "message", message.ID, nil, message)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// summarize fills in the last message and the user's unread count of the
+// conversations
+func (s *MessageService) summarize(userID string, conversations []models.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(conversations))
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.ID)
+	}
+
+	last, err := s.repo.GetLastMessages(ids)
+	if err != nil {
+		return err
+	}
+	unread, err := s.repo.CountUnread(userID, ids...)
+	if err != nil {
+		return err
+	}
+
+	for i := range conversations {
+		conversation := &conversations[i]
+		if message, ok := last[conversation.ID]; ok {
+			message.ReadBy = readBy(conversation, &message)
+			conversation.LastMessage = &message
+		}
+		conversation.UnreadCount = unread[conversation.ID]
+	}
+	return nil
+}
+
+func newParticipant(user *models.APIUser, joinedAt time.Time) models.ConversationParticipant {
+	return models.ConversationParticipant{UserID: user.ID, UserName: user.Name, JoinedAt: joinedAt}
+}
+
+// readBy lists the participants other than the sender whose read receipt
+// covers the message
+func readBy(conversation *models.Conversation, message *models.Message) []string {
+	readers := []string{}
+	for _, participant := range conversation.Participants {
+		if participant.UserID == message.SenderID || participant.LastReadAt == nil {
+			continue
+		}
+		if !participant.LastReadAt.Before(message.CreatedAt) {
+			readers = append(readers, participant.UserID)
+		}
+	}
+	return readers
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/events"
+	"github.com/TheApostroff/skill-space/internal/mail"
+)
+
+// excerptLength is how much of a forum reply a notification quotes
+const excerptLength = 140
+
+type NotificationService struct {
+	repo           *repositories.NotificationRepository
+	userRepo       *repositories.UserRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+	assignmentRepo *repositories.AssignmentRepository
+	transactor     *repositories.Transactor
+	mailer         mail.Sender
+	bus            *events.Bus
+	cfg            *config.Notifications
+}
+
+func NewNotificationService(
+	repo *repositories.NotificationRepository,
+	userRepo *repositories.UserRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	assignmentRepo *repositories.AssignmentRepository,
+	transactor *repositories.Transactor,
+	mailer mail.Sender,
+	bus *events.Bus,
+	cfg *config.Notifications,
+) *NotificationService {
+	return &NotificationService{
+		repo:           repo,
+		userRepo:       userRepo,
+		enrollmentRepo: enrollmentRepo,
+		assignmentRepo: assignmentRepo,
+		transactor:     transactor,
+		mailer:         mailer,
+		bus:            bus,
+		cfg:            cfg,
+	}
+}
+
+// message is a notification before it is addressed to its recipients
+type message struct {
+	notificationType string
+	title            string
+	body             string
+	entityType       string
+	entityID         string
+}
+
+// Subscribe notifies users of the events they are interested in
+func (s *NotificationService) Subscribe() {
+	s.bus.Subscribe(models.EventAssignmentCreated, s.onAssignmentCreated)
+	s.bus.Subscribe(models.EventSubmissionGraded, s.onSubmissionGraded)
+	s.bus.Subscribe(models.EventForumReplyCreated, s.onForumReplyCreated)
+}
+
+// onAssignmentCreated notifies the students enrolled in the assignment's course
+func (s *NotificationService) onAssignmentCreated(event events.Event) {
+	assignment := event.Payload.(models.Assignment)
+
+	enrollments, err := s.enrollmentRepo.GetByCourseAndStatus(assignment.CourseID, models.EnrollmentActive)
+	if err != nil {
+		log.Printf("Failed to find the students to notify of assignment %s: %v", assignment.ID, err)
+		return
+	}
+	students := make([]string, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		students = append(students, enrollment.StudentID)
+	}
+
+	s.notify(students, message{
+		notificationType: models.NotificationAssignmentCreated,
+		title:            fmt.Sprintf("New assignment: %s", assignment.Title),
+		body:             fmt.Sprintf("%s is due %s.", assignment.Title, assignment.DueDate.Format("Mon, 02 Jan 2006 15:04 MST")),
+		entityType:       "assignment",
+		entityID:         assignment.ID,
+	})
+}
+
+// onSubmissionGraded notifies the student whose submission was graded
+func (s *NotificationService) onSubmissionGraded(event events.Event) {
+	graded := event.Payload.(models.SubmissionGradedEvent)
+	if graded.Submission.Score == nil {
+		return
+	}
+
+	s.notify([]string{graded.Submission.StudentID}, message{
+		notificationType: models.NotificationSubmissionGraded,
+		title:            fmt.Sprintf("%s was graded", graded.Assignment.Title),
+		body:             fmt.Sprintf("Your submission scored %d of %d points.", *graded.Submission.Score, graded.Assignment.TotalPoints),
+		entityType:       "submission",
+		entityID:         graded.Submission.ID,
+	})
+}
+
+// onForumReplyCreated notifies the author of the post that was replied to,
+// unless they replied themselves
+func (s *NotificationService) onForumReplyCreated(event events.Event) {
+	created := event.Payload.(models.ForumReplyCreatedEvent)
+	if created.Reply.AuthorID == created.Post.AuthorID {
+		return
+	}
+
+	s.notify([]string{created.Post.AuthorID}, message{
+		notificationType: models.NotificationForumReply,
+		title:            fmt.Sprintf("%s replied to %s", created.Reply.AuthorName, created.Post.Title),
+		body:             excerpt(created.Reply.Content),
+		entityType:       "forum_post",
+		entityID:         created.Reply.ID,
+	})
+}
+
+// notify addresses the message to the users through the channels each of
+// them wants for its type. Email only goes out if an SMTP server is configured.
+func (s *NotificationService) notify(userIDs []string, msg message) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	preferences, err := s.repo.GetPreferences(userIDs, msg.notificationType)
+	if err != nil {
+		log.Printf("Failed to load the notification preferences for %s: %v", msg.notificationType, err)
+		return
+	}
+	byUser := map[string]models.NotificationPreference{}
+	for _, preference := range preferences {
+		byUser[preference.UserID] = preference
+	}
+
+	now := time.Now()
+	notifications := []models.Notification{}
+	for _, userID := range userIDs {
+		preference, ok := byUser[userID]
+		if !ok {
+			preference = defaultPreference(userID, msg.notificationType)
+		}
+		email := preference.Email && s.mailer != nil
+		if !preference.InApp && !email {
+			continue
+		}
+
+		notifications = append(notifications, models.Notification{
+			ID:           GenerateID(),
+			UserID:       userID,
+			Type:         msg.notificationType,
+			Title:        msg.title,
+			Body:         msg.body,
+			EntityType:   msg.entityType,
+			EntityID:     msg.entityID,
+			InApp:        preference.InApp,
+			EmailPending: email,
+			CreatedAt:    now,
+		})
+	}
+
+	err = s.repo.CreateMany(notifications)
+	if err != nil {
+		log.Printf("Failed to store %s notifications for %s %s: %v", msg.notificationType, msg.entityType, msg.entityID, err)
+		return
+	}
+	if len(notifications) > 0 {
+		s.bus.Publish(models.EventNotificationCreated, notifications)
+	}
+}
+
+// GetInbox lists the user's in-app notifications, newest first unless the
+// query asks otherwise. The "unread" filter set to true leaves out read ones.
+func (s *NotificationService) GetInbox(userID string, q *models.ListQuery) ([]models.Notification, int64, error) {
+	return s.repo.ListInbox(userID, q.Filters["unread"] == "true", q)
+}
+
+func (s *NotificationService) CountUnread(userID string) (*models.UnreadCount, error) {
+	count, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UnreadCount{Count: count}, nil
+}
+
+// MarkRead marks a notification in the user's inbox read
+func (s *NotificationService) MarkRead(
+	userID string,
+	notificationID string,
+	source *models.AuditSource,
+) (*models.Notification, error) {
+	var notification *models.Notification
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		before, err := tx.Notifications.GetInboxItem(notificationID, userID)
+		if err != nil {
+			return err
+		}
+		notification = before
+		if before.ReadAt != nil {
+			return nil
+		}
+
+		err = tx.Notifications.MarkRead(notificationID, userID, time.Now())
+		if err != nil {
+			return err
+		}
+		notification, err = tx.Notifications.GetInboxItem(notificationID, userID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "notification", notificationID, before, notification)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// MarkAllRead marks the user's whole inbox read and returns what is left unread
+func (s *NotificationService) MarkAllRead(userID string, source *models.AuditSource) (*models.UnreadCount, error) {
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		read, err := tx.Notifications.MarkAllRead(userID, time.Now())
+		if err != nil || read == 0 {
+			return err
+		}
+		before := &models.UnreadCount{Count: read}
+		return recordAudit(tx, source, models.AuditUpdate, "notification_inbox", userID, before, &models.UnreadCount{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.CountUnread(userID)
+}
+
+// GetPreferences returns the user's preference for every notification type
+func (s *NotificationService) GetPreferences(userID string) ([]models.NotificationPreference, error) {
+	stored, err := s.repo.GetUserPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	byType := map[string]models.NotificationPreference{}
+	for _, preference := range stored {
+		byType[preference.Type] = preference
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		preference, ok := byType[notificationType]
+		if !ok {
+			preference = defaultPreference(userID, notificationType)
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences changes the channels of the given notification types
+func (s *NotificationService) UpdatePreferences(
+	userID string,
+	req *models.NotificationPreferencesRequest,
+	source *models.AuditSource,
+) ([]models.NotificationPreference, error) {
+	current, err := s.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	before := preferencesByType(current)
+	byType := map[string]*models.NotificationPreference{}
+	for i := range current {
+		byType[current[i].Type] = &current[i]
+	}
+
+	now := time.Now()
+	changed := []models.NotificationPreference{}
+	for _, update := range req.Preferences {
+		preference, ok := byType[update.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownNotification, update.Type)
+		}
+		if update.InApp != nil {
+			preference.InApp = *update.InApp
+		}
+		if update.Email != nil {
+			preference.Email = *update.Email
+		}
+		preference.UpdatedAt = now
+		changed = append(changed, *preference)
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Notifications.SavePreferences(changed)
+		if err != nil {
+			return err
+		}
+		after := preferencesByType(current)
+		return recordAudit(tx, source, models.AuditUpdate, "notification_preferences", userID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// preferencesByType keys the preferences by notification type, so the audit
+// trail lists the types whose channels changed
+func preferencesByType(preferences []models.NotificationPreference) map[string]models.NotificationPreference {
+	byType := make(map[string]models.NotificationPreference, len(preferences))
+	for _, preference := range preferences {
+		byType[preference.Type] = preference
+	}
+	return byType
+}
+
+func defaultPreference(userID string, notificationType string) models.NotificationPreference {
+	return models.NotificationPreference{UserID: userID, Type: notificationType, InApp: true, Email: true}
+}
+
+// StartDigests emails every user one digest of their pending notifications
+// at every digest interval. It does nothing if email is turned off or the
+// interval is not positive.
+func (s *NotificationService) StartDigests() {
+	if s.mailer == nil || s.cfg.DigestInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.DigestInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			err := s.sendDigests()
+			if err != nil {
+				log.Printf("Failed to send notification digests: %v", err)
+			}
+		}
+	}()
+}
+
+// sendDigests emails the pending notifications, one message per user. A user
+// whose digest fails keeps their notifications pending for the next round.
+func (s *NotificationService) sendDigests() error {
+	pending, err := s.repo.GetPendingEmails()
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(pending); {
+		end := start
+		for end < len(pending) && pending[end].UserID == pending[start].UserID {
+			end++
+		}
+		batch := pending[start:end]
+		start = end
+
+		err := s.sendDigest(batch)
+		if err != nil {
+			log.Printf("Failed to email the notification digest of user %s: %v", batch[0].UserID, err)
+		}
+	}
+	return nil
+}
+
+func (s *NotificationService) sendDigest(notifications []models.Notification) error {
+	user, err := s.userRepo.GetByID(notifications[0].UserID)
+	if err != nil {
+		return err
+	}
+
+	subject := notifications[0].Title
+	if len(notifications) > 1 {
+		subject = fmt.Sprintf("You have %d new notifications", len(notifications))
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nHere is what happened since our last email:\n", user.Name)
+	ids := make([]string, 0, len(notifications))
+	for _, notification := range notifications {
+		fmt.Fprintf(&body, "\n- %s\n", notification.Title)
+		if notification.Body != "" {
+			fmt.Fprintf(&body, "  %s\n", notification.Body)
+		}
+		ids = append(ids, notification.ID)
+	}
+	body.WriteString("\nYou can choose which notifications you get by email in your notification preferences.\n")
+
+	err = s.mailer.Send(user.Email, subject, body.String())
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkEmailed(ids, time.Now())
+}
+
+// StartReminders reminds students of assignments they have not submitted
+// that are due within the due-soon window, checking at every reminder
+// interval. A reminder interval that is not positive disables reminders.
+func (s *NotificationService) StartReminders() {
+	if s.cfg.ReminderInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.ReminderInterval)
+		defer ticker.Stop()
+		for {
+			err := s.remindDueSoon()
+			if err != nil {
+				log.Printf("Failed to send due date reminders: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// remindDueSoon notifies every actively enrolled student who has not
+// submitted an assignment due within the window yet, once per assignment
+func (s *NotificationService) remindDueSoon() error {
+	now := time.Now()
+	assignments, err := s.assignmentRepo.GetDueBetween(now, now.Add(s.cfg.DueSoonWindow))
+	if err != nil {
+		return err
+	}
+
+	for _, assignment := range assignments {
+		reminded, err := s.repo.GetNotifiedUserIDs(models.NotificationAssignmentDueSoon, assignment.ID)
+		if err != nil {
+			return err
+		}
+		enrollments, err := s.enrollmentRepo.GetByCourseAndStatus(assignment.CourseID, models.EnrollmentActive)
+		if err != nil {
+			return err
+		}
+
+		submitted := map[string]bool{}
+		for _, submission := range assignment.Submissions {
+			submitted[submission.StudentID] = true
+		}
+		students := []string{}
+		for _, enrollment := range enrollments {
+			if !submitted[enrollment.StudentID] && !slices.Contains(reminded, enrollment.StudentID) {
+				students = append(students, enrollment.StudentID)
+			}
+		}
+
+		s.notify(students, message{
+			notificationType: models.NotificationAssignmentDueSoon,
+			title:            fmt.Sprintf("%s is due soon", assignment.Title),
+			body:             fmt.Sprintf("%s is due %s and you have not submitted it yet.", assignment.Title, assignment.DueDate.Format("Mon, 02 Jan 2006 15:04 MST")),
+			entityType:       "assignment",
+			entityID:         assignment.ID,
+		})
+	}
+	return nil
+}
+
+// excerpt shortens text to excerptLength characters at most
+func excerpt(text string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= excerptLength {
+		return string(runes)
+	}
+	return string(runes[:excerptLength-1]) + "…"
+}
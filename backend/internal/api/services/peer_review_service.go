@@ -0,0 +1,426 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+type PeerReviewService struct {
+	repo              *repositories.PeerReviewRepository
+	assignmentRepo    *repositories.AssignmentRepository
+	assignmentService *AssignmentService
+	transactor        *repositories.Transactor
+}
+
+func NewPeerReviewService(
+	repo *repositories.PeerReviewRepository,
+	assignmentRepo *repositories.AssignmentRepository,
+	assignmentService *AssignmentService,
+	transactor *repositories.Transactor,
+) *PeerReviewService {
+	return &PeerReviewService{
+		repo:              repo,
+		assignmentRepo:    assignmentRepo,
+		assignmentService: assignmentService,
+		transactor:        transactor,
+	}
+}
+
+// AssignReviewers assigns every student's latest submission to
+// PeerReviewCount other students once the due date and grace period have
+// passed. Students are shuffled into a circle and each reviews the next
+// students along it, so nobody reviews their own work and every student
+// writes and receives the same number of reviews.
+func (s *PeerReviewService) AssignReviewers(assignmentID string, source *models.AuditSource) ([]models.PeerReview, error) {
+	assignment, err := s.assignmentRepo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.PeerReviewCount <= 0 {
+		return nil, ErrPeerReviewDisabled
+	}
+	if time.Now().Before(assignment.DueDate.Add(time.Duration(assignment.GracePeriodMinutes) * time.Minute)) {
+		return nil, ErrPeerReviewNotOpen
+	}
+
+	submissions := latestSubmissions(assignment.Submissions)
+	if len(submissions) < 2 {
+		return nil, fmt.Errorf("%w: at least two students must have submitted", ErrPeerReviewNotOpen)
+	}
+	rand.Shuffle(len(submissions), func(i, j int) { submissions[i], submissions[j] = submissions[j], submissions[i] })
+
+	now := time.Now()
+	perReviewer := min(assignment.PeerReviewCount, len(submissions)-1)
+	reviews := []models.PeerReview{}
+	for i, reviewer := range submissions {
+		for k := 1; k <= perReviewer; k++ {
+			reviewed := submissions[(i+k)%len(submissions)]
+			reviews = append(reviews, models.PeerReview{
+				ID:           GenerateID(),
+				AssignmentID: assignment.ID,
+				SubmissionID: reviewed.ID,
+				ReviewerID:   reviewer.StudentID,
+				Status:       models.PeerReviewAssigned,
+				Scores:       models.RubricScores{},
+				AssignedAt:   now,
+			})
+		}
+	}
+
+	// Lock the assignment so that concurrent requests cannot both assign reviewers
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		_, err := tx.Assignments.GetByIDForUpdate(assignment.ID)
+		if err != nil {
+			return err
+		}
+		assigned, err := tx.PeerReviews.CountByAssignmentID(assignment.ID)
+		if err != nil {
+			return err
+		}
+		if assigned > 0 {
+			return ErrPeerReviewsAssigned
+		}
+		err = tx.PeerReviews.CreateAll(reviews)
+		if err != nil {
+			return err
+		}
+		for i := range reviews {
+			err = recordAudit(tx, source, models.AuditCreate, "peer_review", reviews[i].ID, nil, &reviews[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// GetAssignedReviews returns the reviews the student has to write, each with
+// the submission to review but not its author. The attachments leave out who
+// uploaded them and the submission they belong to, since either identifies
+// the author.
+func (s *PeerReviewService) GetAssignedReviews(assignmentID string, reviewer *models.APIUser) ([]models.PeerReviewTask, error) {
+	assignment, err := s.assignmentRepo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := s.repo.GetByReviewer(assignment.ID, reviewer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rubric, err := s.optionalRubric(assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions := map[string]models.Submission{}
+	for _, submission := range assignment.Submissions {
+		submissions[submission.ID] = submission
+	}
+
+	tasks := make([]models.PeerReviewTask, len(reviews))
+	for i, review := range reviews {
+		submission := submissions[review.SubmissionID]
+		tasks[i] = models.PeerReviewTask{
+			PeerReview:  review,
+			Content:     submission.Content,
+			Attachments: anonymousAttachments(submission.Attachments),
+			Rubric:      rubric,
+		}
+	}
+
+	return tasks, nil
+}
+
+// anonymousAttachments copies the attachments without their uploader and submission
+func anonymousAttachments(attachments []models.Attachment) []models.Attachment {
+	anonymous := make([]models.Attachment, len(attachments))
+	for i, attachment := range attachments {
+		attachment.UploadedBy = ""
+		attachment.SubmissionID = nil
+		anonymous[i] = attachment
+	}
+	return anonymous
+}
+
+// SubmitReview stores the reviewer's review. With a rubric every criterion
+// must be graded and the score is computed from it; without one the reviewer
+// gives a score on the assignment's scale. Reviews can be revised until the
+// peer review due date.
+func (s *PeerReviewService) SubmitReview(
+	reviewID string,
+	req *models.PeerReviewRequest,
+	reviewer *models.APIUser,
+	source *models.AuditSource,
+) (*models.PeerReview, error) {
+	review, err := s.repo.GetByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.ReviewerID != reviewer.ID {
+		return nil, ErrForbidden
+	}
+
+	assignment, err := s.assignmentRepo.GetByID(review.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if assignment.PeerReviewDueDate != nil && now.After(*assignment.PeerReviewDueDate) {
+		return nil, ErrPeerReviewClosed
+	}
+
+	rubric, err := s.optionalRubric(assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := snapshot(review)
+	if err != nil {
+		return nil, err
+	}
+
+	var score float64
+	review.Scores = models.RubricScores{}
+	if rubric != nil {
+		scores, earned, possible, err := scoreRubric(rubric, req.Criteria)
+		if err != nil {
+			return nil, err
+		}
+		review.Scores = scores
+		score = earned / possible * float64(assignment.TotalPoints)
+	} else {
+		if req.Score == nil || *req.Score > float64(assignment.TotalPoints) {
+			return nil, ErrScoreOutOfRange
+		}
+		score = *req.Score
+	}
+
+	score = round2(score)
+	review.Score = &score
+	review.Comments = req.Comments
+	review.Status = models.PeerReviewCompleted
+	review.CompletedAt = &now
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.PeerReviews.Update(review)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "peer_review", review.ID, before, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// GetReceivedReviews returns the completed reviews of the student's
+// submissions without their reviewers
+func (s *PeerReviewService) GetReceivedReviews(assignmentID string, student *models.APIUser) ([]models.PeerReview, error) {
+	assignment, err := s.assignmentRepo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, submission := range assignment.Submissions {
+		if submission.StudentID == student.ID {
+			ids = append(ids, submission.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return []models.PeerReview{}, nil
+	}
+
+	reviews, err := s.repo.GetBySubmissionIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	received := []models.PeerReview{}
+	for _, review := range reviews {
+		if review.Status == models.PeerReviewCompleted {
+			review.ReviewerID = ""
+			received = append(received, review)
+		}
+	}
+	return received, nil
+}
+
+// GetSummaries aggregates the peer reviews of every reviewed submission
+func (s *PeerReviewService) GetSummaries(assignmentID string) ([]models.PeerReviewSummary, error) {
+	assignment, err := s.assignmentRepo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	return s.summaries(assignment)
+}
+
+// ApplyPeerScores stores the average peer score on every reviewed submission.
+// Graded submissions are graded again so the peer score is blended into their
+// score by the assignment's peer review weight; the others get it blended in
+// when they are graded.
+func (s *PeerReviewService) ApplyPeerScores(
+	assignmentID string,
+	grader *models.APIUser,
+	source *models.AuditSource,
+) ([]models.PeerReviewSummary, error) {
+	assignment, err := s.assignmentRepo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.PeerReviewCount <= 0 {
+		return nil, ErrPeerReviewDisabled
+	}
+
+	summaries, err := s.summaries(assignment)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions := map[string]*models.Submission{}
+	for i := range assignment.Submissions {
+		submissions[assignment.Submissions[i].ID] = &assignment.Submissions[i]
+	}
+
+	for _, summary := range summaries {
+		submission := submissions[summary.SubmissionID]
+		if submission == nil || summary.AverageScore == nil {
+			continue
+		}
+
+		if submission.Score == nil {
+			err = s.setPeerScore(submission, summary.AverageScore, source)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		submission.PeerScore = summary.AverageScore
+
+		score := *submission.Score
+		if submission.InstructorScore != nil {
+			score = *submission.InstructorScore
+		}
+		feedback := ""
+		if submission.Feedback != nil {
+			feedback = *submission.Feedback
+		}
+		err = s.assignmentService.saveGrade(assignment, submission, score, feedback, grader.ID, submission.Rubric, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return summaries, nil
+}
+
+// setPeerScore stores the peer score on a submission that is not graded yet
+func (s *PeerReviewService) setPeerScore(submission *models.Submission, peerScore *float64, source *models.AuditSource) error {
+	before, err := snapshot(submission)
+	if err != nil {
+		return err
+	}
+	submission.PeerScore = peerScore
+
+	return s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Assignments.UpdateSubmission(submission)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "submission", submission.ID, before, submission)
+	})
+}
+
+func (s *PeerReviewService) summaries(assignment *models.Assignment) ([]models.PeerReviewSummary, error) {
+	reviews, err := s.repo.GetByAssignmentID(assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	students := map[string]string{}
+	for _, submission := range assignment.Submissions {
+		students[submission.ID] = submission.StudentID
+	}
+
+	summaries := []models.PeerReviewSummary{}
+	index := map[string]int{}
+	for _, review := range reviews {
+		i, ok := index[review.SubmissionID]
+		if !ok {
+			i = len(summaries)
+			index[review.SubmissionID] = i
+			summaries = append(summaries, models.PeerReviewSummary{
+				SubmissionID: review.SubmissionID,
+				StudentID:    students[review.SubmissionID],
+				Reviews:      []models.PeerReview{},
+			})
+		}
+		summaries[i].Assigned++
+		summaries[i].Reviews = append(summaries[i].Reviews, review)
+	}
+
+	for i := range summaries {
+		total := 0.0
+		for _, review := range summaries[i].Reviews {
+			if review.Status == models.PeerReviewCompleted && review.Score != nil {
+				summaries[i].Completed++
+				total += *review.Score
+			}
+		}
+		if summaries[i].Completed > 0 {
+			average := round2(total / float64(summaries[i].Completed))
+			summaries[i].AverageScore = &average
+		}
+	}
+
+	return summaries, nil
+}
+
+// optionalRubric returns the assignment's rubric, or nil if it has none
+func (s *PeerReviewService) optionalRubric(assignmentID string) (*models.Rubric, error) {
+	rubric, err := s.assignmentRepo.GetRubric(assignmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return rubric, err
+}
+
+// latestSubmissions returns each student's latest attempt
+func latestSubmissions(submissions []models.Submission) []models.Submission {
+	latest := map[string]int{}
+	result := []models.Submission{}
+	for _, submission := range submissions {
+		i, ok := latest[submission.StudentID]
+		if !ok {
+			latest[submission.StudentID] = len(result)
+			result = append(result, submission)
+			continue
+		}
+		if submission.Attempt > result[i].Attempt {
+			result[i] = submission
+		}
+	}
+	return result
+}
+
+// peerWeighted is the score blended from an instructor score and a peer
+// score with the given weight in percent
+func peerWeighted(score int, peerScore float64, weight float64) int {
+	return int(math.Round((1-weight/100)*float64(score) + weight/100*peerScore))
+}
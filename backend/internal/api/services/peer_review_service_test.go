@@ -0,0 +1,28 @@
+package services
+
+import "testing"
+
+func TestPeerWeighted(t *testing.T) {
+	tests := []struct {
+		name      string
+		score     int
+		peerScore float64
+		weight    float64
+		want      int
+	}{
+		{name: "no peer weight", score: 80, peerScore: 40, weight: 0, want: 80},
+		{name: "only peers", score: 80, peerScore: 40, weight: 100, want: 40},
+		{name: "even split", score: 80, peerScore: 40, weight: 50, want: 60},
+		{name: "quarter peers", score: 80, peerScore: 60, weight: 25, want: 75},
+		{name: "rounds half up", score: 81, peerScore: 80, weight: 50, want: 81},
+		{name: "fractional peer average", score: 70, peerScore: 72.4, weight: 30, want: 71},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := peerWeighted(tt.score, tt.peerScore, tt.weight); got != tt.want {
+				t.Errorf("peerWeighted = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+type ProgressService struct {
+	completionRepo *repositories.ActivityCompletionRepository
+	enrollmentRepo *repositories.EnrollmentRepository
+	sectionRepo    *repositories.SectionRepository
+	activityRepo   *repositories.ActivityRepository
+	transactor     *repositories.Transactor
+}
+
+func NewProgressService(
+	completionRepo *repositories.ActivityCompletionRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	sectionRepo *repositories.SectionRepository,
+	activityRepo *repositories.ActivityRepository,
+	transactor *repositories.Transactor,
+) *ProgressService {
+	return &ProgressService{
+		completionRepo: completionRepo,
+		enrollmentRepo: enrollmentRepo,
+		sectionRepo:    sectionRepo,
+		activityRepo:   activityRepo,
+		transactor:     transactor,
+	}
+}
+
+// CompleteActivity marks the activity complete for the student and updates
+// their enrollment progress
+func (s *ProgressService) CompleteActivity(
+	activityID string,
+	student *models.APIUser,
+	source *models.AuditSource,
+) (*models.CourseProgress, error) {
+	courseID, enrollment, err := s.enrolledActivity(activityID, student.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		_, err := tx.ActivityCompletions.Get(activityID, student.ID)
+		if err == nil {
+			// Already complete
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		completion := &models.ActivityCompletion{
+			ID:          GenerateID(),
+			ActivityID:  activityID,
+			StudentID:   student.ID,
+			CourseID:    courseID,
+			CompletedAt: time.Now(),
+		}
+		err = tx.ActivityCompletions.Create(completion)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "activity_completion", activityID, nil, completion)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateProgress(enrollment)
+}
+
+// UncompleteActivity removes the student's completion of the activity
+func (s *ProgressService) UncompleteActivity(
+	activityID string,
+	student *models.APIUser,
+	source *models.AuditSource,
+) (*models.CourseProgress, error) {
+	_, enrollment, err := s.enrolledActivity(activityID, student.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		completion, err := tx.ActivityCompletions.Get(activityID, student.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.ActivityCompletions.Delete(activityID, student.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditDelete, "activity_completion", activityID, completion, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateProgress(enrollment)
+}
+
+// RecordAccess updates LastAccessed on the viewer's enrollment in the course
+// the activity belongs to. Viewers who are not enrolled are ignored.
+func (s *ProgressService) RecordAccess(activity *models.Activity, viewer *models.APIUser) error {
+	section, err := s.sectionRepo.GetByID(activity.SectionID)
+	if err != nil {
+		return err
+	}
+
+	enrollment, err := s.enrollmentRepo.GetCurrent(section.CourseID, viewer.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	enrollment.LastAccessed = &now
+	return s.enrollmentRepo.Update(enrollment)
+}
+
+// MarkCompleted sets Completed on each activity the student has completed
+func (s *ProgressService) MarkCompleted(courseID string, studentID string, activities []models.Activity) error {
+	completed, err := s.completedSet(courseID, studentID)
+	if err != nil {
+		return err
+	}
+
+	for i := range activities {
+		activities[i].Completed = completed[activities[i].ID]
+	}
+	return nil
+}
+
+// GetCourseProgress returns the student's progress through each visible
+// section of the course
+func (s *ProgressService) GetCourseProgress(courseID string, studentID string) (*models.CourseProgress, error) {
+	sections, err := s.sectionRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed, err := s.completedSet(courseID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := buildCourseProgress(courseID, studentID, sections, completed)
+
+	enrollment, err := s.enrollmentRepo.GetCurrent(courseID, studentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if enrollment != nil {
+		progress.LastAccessed = enrollment.LastAccessed
+	}
+
+	return progress, nil
+}
+
+// GetCourseProgressForAll returns the progress of every active or completed
+// student in the course
+func (s *ProgressService) GetCourseProgressForAll(courseID string) ([]models.CourseProgress, error) {
+	sections, err := s.sectionRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollments, err := s.enrollmentRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	completions, err := s.completionRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	byStudent := map[string]map[string]bool{}
+	for _, completion := range completions {
+		if byStudent[completion.StudentID] == nil {
+			byStudent[completion.StudentID] = map[string]bool{}
+		}
+		byStudent[completion.StudentID][completion.ActivityID] = true
+	}
+
+	result := []models.CourseProgress{}
+	for _, enrollment := range enrollments {
+		if enrollment.Status != models.EnrollmentActive && enrollment.Status != models.EnrollmentCompleted {
+			continue
+		}
+		progress := buildCourseProgress(courseID, enrollment.StudentID, sections, byStudent[enrollment.StudentID])
+		progress.LastAccessed = enrollment.LastAccessed
+		result = append(result, *progress)
+	}
+
+	return result, nil
+}
+
+// RecalculateCourse recomputes the progress of every current enrollment in
+// the course, e.g. after activities were added, removed or hidden
+func (s *ProgressService) RecalculateCourse(courseID string) error {
+	sections, err := s.sectionRepo.GetByCourseID(courseID)
+	if err != nil {
+		return err
+	}
+
+	enrollments, err := s.enrollmentRepo.GetByCourseAndStatus(courseID, models.EnrollmentActive)
+	if err != nil {
+		return err
+	}
+
+	for i := range enrollments {
+		completed, err := s.completedSet(courseID, enrollments[i].StudentID)
+		if err != nil {
+			return err
+		}
+
+		progress := buildCourseProgress(courseID, enrollments[i].StudentID, sections, completed)
+		if progress.Progress == enrollments[i].Progress {
+			continue
+		}
+		enrollments[i].Progress = progress.Progress
+		enrollments[i].UpdatedAt = time.Now()
+		err = s.enrollmentRepo.Update(&enrollments[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enrolledActivity returns the course the activity belongs to and the
+// student's active enrollment in it
+func (s *ProgressService) enrolledActivity(activityID string, studentID string) (string, *models.Enrollment, error) {
+	activity, err := s.activityRepo.GetByID(activityID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	section, err := s.sectionRepo.GetByID(activity.SectionID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	enrollment, err := s.enrollmentRepo.GetCurrent(section.CourseID, studentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && enrollment.Status != models.EnrollmentActive) {
+		return "", nil, ErrNotEnrolled
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	return section.CourseID, enrollment, nil
+}
+
+func (s *ProgressService) updateProgress(enrollment *models.Enrollment) (*models.CourseProgress, error) {
+	progress, err := s.GetCourseProgress(enrollment.CourseID, enrollment.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment.Progress = progress.Progress
+	enrollment.UpdatedAt = time.Now()
+	err = s.enrollmentRepo.Update(enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func (s *ProgressService) completedSet(courseID string, studentID string) (map[string]bool, error) {
+	completions, err := s.completionRepo.GetByCourseAndStudent(courseID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := map[string]bool{}
+	for _, completion := range completions {
+		completed[completion.ActivityID] = true
+	}
+	return completed, nil
+}
+
+// buildCourseProgress counts the completed activities of each visible
+// section. Hidden sections and activities are not part of the course's
+// progress.
+func buildCourseProgress(
+	courseID string,
+	studentID string,
+	sections []models.Section,
+	completed map[string]bool,
+) *models.CourseProgress {
+	sort.SliceStable(sections, func(i, j int) bool { return sections[i].Order < sections[j].Order })
+
+	progress := &models.CourseProgress{
+		CourseID:  courseID,
+		StudentID: studentID,
+		Sections:  []models.SectionProgress{},
+	}
+
+	for _, section := range sections {
+		if !section.Visible {
+			continue
+		}
+
+		sectionProgress := models.SectionProgress{
+			SectionID:    section.ID,
+			Title:        section.Title,
+			Order:        section.Order,
+			CompletedIDs: []string{},
+		}
+		for _, activity := range section.Activities {
+			if !activity.Visible {
+				continue
+			}
+			sectionProgress.TotalActivities++
+			if completed[activity.ID] {
+				sectionProgress.CompletedActivities++
+				sectionProgress.CompletedIDs = append(sectionProgress.CompletedIDs, activity.ID)
+			}
+		}
+		sectionProgress.Percentage = percentOf(sectionProgress.CompletedActivities, sectionProgress.TotalActivities)
+
+		progress.TotalActivities += sectionProgress.TotalActivities
+		progress.CompletedActivities += sectionProgress.CompletedActivities
+		progress.Sections = append(progress.Sections, sectionProgress)
+	}
+	progress.Progress = percentOf(progress.CompletedActivities, progress.TotalActivities)
+
+	return progress
+}
+
+// percentOf returns part as a whole-number percentage of total
+func percentOf(part int, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
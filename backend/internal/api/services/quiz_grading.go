@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+)
+
+var ErrInvalidQuestion = errors.New("invalid question")
+
+// validateQuestion checks that the question's options and answer key fit its
+// type
+func validateQuestion(question *models.Question) error {
+	options, err := optionSet(question.Options)
+	if err != nil {
+		return err
+	}
+	answer := question.Answer
+
+	switch question.Type {
+	case models.QuestionMultipleChoice, models.QuestionMultiSelect:
+		if len(options) < 2 {
+			return fmt.Errorf("%w: at least two options are required", ErrInvalidQuestion)
+		}
+		if len(answer.OptionIDs) == 0 || question.Type == models.QuestionMultipleChoice && len(answer.OptionIDs) != 1 {
+			return fmt.Errorf("%w: the answer must name the correct option(s)", ErrInvalidQuestion)
+		}
+		for _, id := range answer.OptionIDs {
+			if !options[id] {
+				return fmt.Errorf("%w: answer refers to unknown option %q", ErrInvalidQuestion, id)
+			}
+		}
+	case models.QuestionTrueFalse:
+		if answer.Boolean == nil {
+			return fmt.Errorf("%w: the answer must set boolean", ErrInvalidQuestion)
+		}
+	case models.QuestionShortAnswer:
+		if len(question.AcceptedAnswers) == 0 {
+			return fmt.Errorf("%w: at least one accepted answer is required", ErrInvalidQuestion)
+		}
+	case models.QuestionNumeric:
+		if answer.Number == nil {
+			return fmt.Errorf("%w: the answer must set number", ErrInvalidQuestion)
+		}
+	case models.QuestionMatching:
+		targets, err := optionSet(question.MatchTargets)
+		if err != nil {
+			return err
+		}
+		if len(options) < 2 || len(targets) == 0 {
+			return fmt.Errorf("%w: at least two options and one match target are required", ErrInvalidQuestion)
+		}
+		for id := range answer.Pairs {
+			if !options[id] {
+				return fmt.Errorf("%w: answer pairs unknown option %q", ErrInvalidQuestion, id)
+			}
+		}
+		for id := range options {
+			if !targets[answer.Pairs[id]] {
+				return fmt.Errorf("%w: option %q must be paired with a match target", ErrInvalidQuestion, id)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidQuestion, question.Type)
+	}
+
+	return nil
+}
+
+func optionSet(options models.QuestionOptions) (map[string]bool, error) {
+	set := map[string]bool{}
+	for _, option := range options {
+		if option.ID == "" || set[option.ID] {
+			return nil, fmt.Errorf("%w: options need unique, non-empty ids", ErrInvalidQuestion)
+		}
+		set[option.ID] = true
+	}
+	return set, nil
+}
+
+// scoreAnswer returns the points the response earns and whether it is fully
+// correct. Multi-select and matching questions give partial credit: each
+// correct choice or pair earns its share of the points, and each wrong
+// multi-select choice takes one share away.
+func scoreAnswer(question *models.Question, response models.Answer) (float64, bool) {
+	key := question.Answer
+	fraction := 0.0
+
+	switch question.Type {
+	case models.QuestionMultipleChoice:
+		if len(response.OptionIDs) == 1 && response.OptionIDs[0] == key.OptionIDs[0] {
+			fraction = 1
+		}
+	case models.QuestionMultiSelect:
+		correct := map[string]bool{}
+		for _, id := range key.OptionIDs {
+			correct[id] = true
+		}
+		hits := 0
+		for _, id := range uniqueStrings(response.OptionIDs) {
+			if correct[id] {
+				hits++
+			} else {
+				hits--
+			}
+		}
+		fraction = math.Max(0, float64(hits)/float64(len(correct)))
+	case models.QuestionTrueFalse:
+		if response.Boolean != nil && *response.Boolean == *key.Boolean {
+			fraction = 1
+		}
+	case models.QuestionShortAnswer:
+		given := normalizeAnswer(response.Text)
+		for _, accepted := range question.AcceptedAnswers {
+			if given != "" && given == normalizeAnswer(accepted) {
+				fraction = 1
+				break
+			}
+		}
+	case models.QuestionNumeric:
+		if response.Number != nil && math.Abs(*response.Number-*key.Number) <= question.Tolerance {
+			fraction = 1
+		}
+	case models.QuestionMatching:
+		hits := 0
+		for option, target := range key.Pairs {
+			if response.Pairs[option] == target {
+				hits++
+			}
+		}
+		if len(key.Pairs) > 0 {
+			fraction = float64(hits) / float64(len(key.Pairs))
+		}
+	}
+
+	return round2(fraction * question.Points), fraction == 1
+}
+
+// normalizeAnswer makes short answers comparable regardless of case and
+// spacing
+func normalizeAnswer(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
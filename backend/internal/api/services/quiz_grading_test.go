@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+)
+
+func TestScoreAnswer(t *testing.T) {
+	yes, no := true, false
+	number := func(value float64) *float64 { return &value }
+
+	multipleChoice := &models.Question{Type: models.QuestionMultipleChoice, Points: 2,
+		Answer: models.Answer{OptionIDs: []string{"a"}}}
+	multiSelect := &models.Question{Type: models.QuestionMultiSelect, Points: 3,
+		Answer: models.Answer{OptionIDs: []string{"a", "b", "c"}}}
+	trueFalse := &models.Question{Type: models.QuestionTrueFalse, Points: 1,
+		Answer: models.Answer{Boolean: &yes}}
+	shortAnswer := &models.Question{Type: models.QuestionShortAnswer, Points: 1,
+		AcceptedAnswers: models.StringSlice{"Ada Lovelace", "Lovelace"}}
+	numeric := &models.Question{Type: models.QuestionNumeric, Points: 4, Tolerance: 0.05,
+		Answer: models.Answer{Number: number(3.14)}}
+	matching := &models.Question{Type: models.QuestionMatching, Points: 1,
+		Answer: models.Answer{Pairs: map[string]string{"fr": "paris", "de": "berlin", "it": "rome"}}}
+
+	tests := []struct {
+		name        string
+		question    *models.Question
+		response    models.Answer
+		wantPoints  float64
+		wantCorrect bool
+	}{
+		{name: "multiple choice correct", question: multipleChoice, response: models.Answer{OptionIDs: []string{"a"}}, wantPoints: 2, wantCorrect: true},
+		{name: "multiple choice wrong", question: multipleChoice, response: models.Answer{OptionIDs: []string{"b"}}},
+		{name: "multiple choice with two picks", question: multipleChoice, response: models.Answer{OptionIDs: []string{"a", "b"}}},
+		{name: "multiple choice unanswered", question: multipleChoice},
+		{name: "multi-select all correct", question: multiSelect, response: models.Answer{OptionIDs: []string{"c", "b", "a"}}, wantPoints: 3, wantCorrect: true},
+		{name: "multi-select partial", question: multiSelect, response: models.Answer{OptionIDs: []string{"a", "b"}}, wantPoints: 2},
+		{name: "multi-select wrong pick takes a share away", question: multiSelect, response: models.Answer{OptionIDs: []string{"a", "b", "x"}}, wantPoints: 1},
+		{name: "multi-select never negative", question: multiSelect, response: models.Answer{OptionIDs: []string{"x", "y"}}},
+		{name: "multi-select duplicates count once", question: multiSelect, response: models.Answer{OptionIDs: []string{"a", "a", "a"}}, wantPoints: 1},
+		{name: "true false correct", question: trueFalse, response: models.Answer{Boolean: &yes}, wantPoints: 1, wantCorrect: true},
+		{name: "true false wrong", question: trueFalse, response: models.Answer{Boolean: &no}},
+		{name: "true false unanswered", question: trueFalse},
+		{name: "short answer ignores case and spacing", question: shortAnswer, response: models.Answer{Text: "  ada   LOVELACE "}, wantPoints: 1, wantCorrect: true},
+		{name: "short answer second accepted answer", question: shortAnswer, response: models.Answer{Text: "lovelace"}, wantPoints: 1, wantCorrect: true},
+		{name: "short answer wrong", question: shortAnswer, response: models.Answer{Text: "Babbage"}},
+		{name: "short answer empty", question: shortAnswer, response: models.Answer{Text: "   "}},
+		{name: "numeric exact", question: numeric, response: models.Answer{Number: number(3.14)}, wantPoints: 4, wantCorrect: true},
+		{name: "numeric within tolerance", question: numeric, response: models.Answer{Number: number(3.18)}, wantPoints: 4, wantCorrect: true},
+		{name: "numeric outside tolerance", question: numeric, response: models.Answer{Number: number(3.2)}},
+		{name: "numeric unanswered", question: numeric},
+		{name: "matching all pairs", question: matching, response: models.Answer{Pairs: map[string]string{"fr": "paris", "de": "berlin", "it": "rome"}}, wantPoints: 1, wantCorrect: true},
+		{name: "matching partial rounds to cents", question: matching, response: models.Answer{Pairs: map[string]string{"fr": "paris", "de": "rome"}}, wantPoints: 0.33},
+		{name: "matching unanswered", question: matching},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, correct := scoreAnswer(tt.question, tt.response)
+			if points != tt.wantPoints || correct != tt.wantCorrect {
+				t.Errorf("scoreAnswer = %v, %v, want %v, %v", points, correct, tt.wantPoints, tt.wantCorrect)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/qti"
+)
+
+// ImportQTI adds the items of a QTI 2.1 package to the course's question bank
+// and creates a quiz activity in the section that lists them, along with a
+// graded quiz of those questions. Items that are not supported or fail
+// validation are skipped and listed in the report; if none are left, nothing
+// is created.
+func (s *QuizService) ImportQTI(
+	sectionID string,
+	file io.ReaderAt,
+	size int64,
+	req *models.QTIImportRequest,
+	author *models.APIUser,
+	source *models.AuditSource,
+) (*models.QTIImportResult, error) {
+	section, err := s.sectionRepo.GetByID(sectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, read, err := qti.Read(file, size)
+	if err != nil {
+		return nil, err
+	}
+	report := qtiReport(read)
+
+	now := time.Now()
+	questions := []models.Question{}
+	questionIDs := []string{}
+	for i := range pkg.Items {
+		item := &pkg.Items[i]
+		question := questionFromItem(item)
+		question.ID = GenerateID()
+		question.CourseID = section.CourseID
+		question.CreatedBy = author.ID
+		question.CreatedAt = now
+		question.UpdatedAt = now
+
+		err := validateQuestion(question)
+		if err != nil {
+			report.Skipped = append(report.Skipped, models.QTIIssue{Identifier: item.Identifier, Message: err.Error()})
+			continue
+		}
+		questions = append(questions, *question)
+		questionIDs = append(questionIDs, question.ID)
+	}
+	report.Imported = len(questions)
+
+	result := &models.QTIImportResult{Questions: questions, Report: report}
+	if len(questions) == 0 {
+		return result, nil
+	}
+
+	title := req.Title
+	if title == "" {
+		title = pkg.Title
+	}
+	if title == "" {
+		title = "Imported quiz"
+	}
+	activity := &models.Activity{
+		ID:          GenerateID(),
+		SectionID:   section.ID,
+		Title:       title,
+		Description: req.Description,
+		Type:        "quiz",
+		Order:       req.Order,
+		Visible:     req.Visible,
+		Metadata: models.ActivityMetadata{
+			"questionIds": questionIDs,
+			"source":      "qti",
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	assignment, quiz, err := s.newQuiz(section.CourseID, questionIDs, &models.QuizCreateRequest{
+		Title:              title,
+		Description:        req.Description,
+		ActivityID:         &activity.ID,
+		ShuffleQuestions:   req.ShuffleQuestions,
+		ShuffleOptions:     req.ShuffleOptions,
+		TimeLimitMinutes:   req.TimeLimitMinutes,
+		ShowCorrectAnswers: req.ShowCorrectAnswers,
+		TotalPoints:        req.TotalPoints,
+		DueDate:            req.DueDate,
+		MaxAttempts:        req.MaxAttempts,
+		AttemptPolicy:      req.AttemptPolicy,
+		LatePolicy:         req.LatePolicy,
+		LatePenaltyPercent: req.LatePenaltyPercent,
+		GracePeriodMinutes: req.GracePeriodMinutes,
+	}, author)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		for i := range questions {
+			err := tx.Questions.Create(&questions[i])
+			if err != nil {
+				return err
+			}
+			err = recordAudit(tx, source, models.AuditCreate, "question", questions[i].ID, nil, &questions[i])
+			if err != nil {
+				return err
+			}
+		}
+		err := tx.Activities.Create(activity)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(tx, source, models.AuditCreate, "activity", activity.ID, nil, activity)
+		if err != nil {
+			return err
+		}
+		err = tx.Assignments.Create(assignment)
+		if err != nil {
+			return err
+		}
+		err = tx.Quizzes.Create(quiz)
+		if err != nil {
+			return err
+		}
+		return recordQuizCreated(tx, source, assignment, quiz)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.assignmentService.bus.Publish(models.EventAssignmentCreated, *assignment)
+
+	err = s.progressService.RecalculateCourse(section.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Activity = activity
+	result.Quiz = quiz
+	return result, nil
+}
+
+// qtiReport copies the report of the QTI reader into the one returned by the
+// API
+func qtiReport(read *qti.Report) *models.QTIReport {
+	report := &models.QTIReport{
+		Total:    read.Total,
+		Imported: read.Imported,
+		Skipped:  []models.QTIIssue{},
+	}
+	for _, issue := range read.Skipped {
+		report.Skipped = append(report.Skipped, models.QTIIssue{
+			File:       issue.File,
+			Identifier: issue.Identifier,
+			Message:    issue.Message,
+		})
+	}
+	return report
+}
+
+// ExportActivityQTI packages the questions of a quiz activity: those of the
+// quizzes attached to it and those listed in its metadata
+func (s *QuizService) ExportActivityQTI(activityID string) (*StoredFile, error) {
+	activity, err := s.activityRepo.GetByID(activityID)
+	if err != nil {
+		return nil, err
+	}
+
+	quizzes, err := s.quizRepo.GetByActivityID(activity.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, quiz := range quizzes {
+		ids = append(ids, quiz.QuestionIDs...)
+	}
+	if listed, ok := activity.Metadata["questionIds"].([]interface{}); ok {
+		for _, id := range listed {
+			if id, ok := id.(string); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	ids = uniqueStrings(ids)
+
+	found, err := s.questionRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]models.Question{}
+	for _, question := range found {
+		byID[question.ID] = question
+	}
+	questions := []models.Question{}
+	for _, id := range ids {
+		if question, ok := byID[id]; ok {
+			questions = append(questions, question)
+		}
+	}
+
+	return exportQTI(activity.Title, questions)
+}
+
+// ExportCourseQTI packages the course's whole question bank
+func (s *QuizService) ExportCourseQTI(courseID string) (*StoredFile, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	questions, err := s.questionRepo.GetByCourseID(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return exportQTI(course.Title+" question bank", questions)
+}
+
+func exportQTI(title string, questions []models.Question) (*StoredFile, error) {
+	if len(questions) == 0 {
+		return nil, ErrQuizHasNoQuestions
+	}
+
+	pkg := &qti.Package{Title: title}
+	for i := range questions {
+		pkg.Items = append(pkg.Items, itemFromQuestion(&questions[i]))
+	}
+
+	var buf bytes.Buffer
+	err := qti.Write(&buf, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StoredFile{
+		Body:     io.NopCloser(&buf),
+		FileName: fileSlug(title) + ".zip",
+		MimeType: "application/zip",
+		Size:     int64(buf.Len()),
+	}, nil
+}
+
+func questionFromItem(item *qti.Item) *models.Question {
+	question := &models.Question{
+		Prompt:          item.Prompt,
+		Points:          item.Points,
+		Options:         models.QuestionOptions{},
+		MatchTargets:    models.QuestionOptions{},
+		AcceptedAnswers: models.StringSlice{},
+		Tags:            models.StringSlice{},
+	}
+	if question.Prompt == "" {
+		question.Prompt = item.Title
+	}
+
+	switch item.Type {
+	case qti.SingleChoice, qti.MultipleChoice:
+		question.Type = models.QuestionMultipleChoice
+		if item.Type == qti.MultipleChoice {
+			question.Type = models.QuestionMultiSelect
+		}
+		question.Options = questionOptions(item.Choices)
+		question.Answer.OptionIDs = item.Correct
+	case qti.TrueFalse:
+		question.Type = models.QuestionTrueFalse
+		value := item.Correct[0] == "true"
+		question.Answer.Boolean = &value
+	case qti.TextEntry:
+		question.Type = models.QuestionShortAnswer
+		question.AcceptedAnswers = models.StringSlice(item.Correct)
+	case qti.Numeric:
+		question.Type = models.QuestionNumeric
+		number := item.Number
+		question.Answer.Number = &number
+		question.Tolerance = item.Tolerance
+	case qti.Match:
+		question.Type = models.QuestionMatching
+		question.Options = questionOptions(item.Choices)
+		question.MatchTargets = questionOptions(item.Targets)
+		question.Answer.Pairs = item.Pairs
+	}
+
+	return question
+}
+
+func itemFromQuestion(question *models.Question) qti.Item {
+	item := qti.Item{
+		Identifier: question.ID,
+		Title:      itemTitle(question.Prompt),
+		Prompt:     question.Prompt,
+		Points:     question.Points,
+		Choices:    qtiChoices(question.Options),
+		Targets:    qtiChoices(question.MatchTargets),
+	}
+
+	switch question.Type {
+	case models.QuestionMultipleChoice:
+		item.Type = qti.SingleChoice
+		item.Correct = question.Answer.OptionIDs
+	case models.QuestionMultiSelect:
+		item.Type = qti.MultipleChoice
+		item.Correct = question.Answer.OptionIDs
+	case models.QuestionTrueFalse:
+		item.Type = qti.TrueFalse
+		item.Correct = []string{"false"}
+		if question.Answer.Boolean != nil && *question.Answer.Boolean {
+			item.Correct = []string{"true"}
+		}
+	case models.QuestionShortAnswer:
+		item.Type = qti.TextEntry
+		item.Correct = question.AcceptedAnswers
+	case models.QuestionNumeric:
+		item.Type = qti.Numeric
+		if question.Answer.Number != nil {
+			item.Number = *question.Answer.Number
+		}
+		item.Tolerance = question.Tolerance
+	case models.QuestionMatching:
+		item.Type = qti.Match
+		item.Pairs = question.Answer.Pairs
+	}
+
+	return item
+}
+
+func questionOptions(choices []qti.Choice) models.QuestionOptions {
+	options := make(models.QuestionOptions, len(choices))
+	for i, choice := range choices {
+		options[i] = models.QuestionOption{ID: choice.ID, Text: choice.Text}
+	}
+	return options
+}
+
+func qtiChoices(options models.QuestionOptions) []qti.Choice {
+	choices := make([]qti.Choice, len(options))
+	for i, option := range options {
+		choices[i] = qti.Choice{ID: option.ID, Text: option.Text}
+	}
+	return choices
+}
+
+// itemTitle shortens the first line of a prompt to an item title
+func itemTitle(prompt string) string {
+	title := []rune(strings.SplitN(prompt, "\n", 2)[0])
+	if len(title) > 80 {
+		return string(title[:77]) + "..."
+	}
+	return string(title)
+}
+
+// fileSlug turns a title into a file name without spaces or punctuation
+func fileSlug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "quiz"
+	}
+	return slug
+}
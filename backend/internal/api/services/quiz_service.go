@@ -0,0 +1,829 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+// quizSubmitGrace is how long after a timed attempt expires its answers are
+// still accepted, to allow for network delay
+const quizSubmitGrace = 30 * time.Second
+
+var (
+	ErrQuizHasNoQuestions = errors.New("quiz has no questions")
+	ErrAttemptClosed      = errors.New("quiz attempt has already been submitted or has expired")
+)
+
+type QuizService struct {
+	questionRepo      *repositories.QuestionRepository
+	quizRepo          *repositories.QuizRepository
+	courseRepo        *repositories.CourseRepository
+	activityRepo      *repositories.ActivityRepository
+	sectionRepo       *repositories.SectionRepository
+	access            courseAccess
+	assignmentService *AssignmentService
+	progressService   *ProgressService
+	transactor        *repositories.Transactor
+}
+
+func NewQuizService(
+	questionRepo *repositories.QuestionRepository,
+	quizRepo *repositories.QuizRepository,
+	courseRepo *repositories.CourseRepository,
+	activityRepo *repositories.ActivityRepository,
+	sectionRepo *repositories.SectionRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	assignmentService *AssignmentService,
+	progressService *ProgressService,
+	transactor *repositories.Transactor,
+) *QuizService {
+	return &QuizService{
+		questionRepo:      questionRepo,
+		quizRepo:          quizRepo,
+		courseRepo:        courseRepo,
+		activityRepo:      activityRepo,
+		sectionRepo:       sectionRepo,
+		assignmentService: assignmentService,
+		progressService:   progressService,
+		transactor:        transactor,
+		access: courseAccess{
+			courseRepo:     courseRepo,
+			sectionRepo:    sectionRepo,
+			activityRepo:   activityRepo,
+			enrollmentRepo: enrollmentRepo,
+		},
+	}
+}
+
+// GetQuestionInstructorID returns the instructor of the course the question belongs to
+func (s *QuizService) GetQuestionInstructorID(questionID string) (string, error) {
+	question, err := s.questionRepo.GetByID(questionID)
+	if err != nil {
+		return "", err
+	}
+	course, err := s.courseRepo.GetByID(question.CourseID)
+	if err != nil {
+		return "", err
+	}
+	return course.InstructorID, nil
+}
+
+func (s *QuizService) GetQuestions(courseID string, q *models.ListQuery) ([]models.Question, int64, error) {
+	return s.questionRepo.ListByCourseID(courseID, q)
+}
+
+func (s *QuizService) CreateQuestion(
+	courseID string,
+	req *models.QuestionRequest,
+	author *models.APIUser,
+	source *models.AuditSource,
+) (*models.Question, error) {
+	question := &models.Question{
+		ID:        GenerateID(),
+		CourseID:  courseID,
+		CreatedBy: author.ID,
+		CreatedAt: time.Now(),
+	}
+	applyQuestionRequest(question, req)
+
+	err := validateQuestion(question)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Questions.Create(question)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "question", question.ID, nil, question)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+func (s *QuizService) UpdateQuestion(
+	questionID string,
+	req *models.QuestionRequest,
+	source *models.AuditSource,
+) (*models.Question, error) {
+	var question *models.Question
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		var err error
+		question, err = tx.Questions.GetByID(questionID)
+		if err != nil {
+			return err
+		}
+		before, err := snapshot(question)
+		if err != nil {
+			return err
+		}
+		applyQuestionRequest(question, req)
+
+		err = validateQuestion(question)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Questions.Update(question)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "question", questionID, before, question)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+func (s *QuizService) DeleteQuestion(questionID string, source *models.AuditSource) error {
+	return s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		question, err := tx.Questions.GetByID(questionID)
+		if err != nil {
+			return err
+		}
+		err = tx.Questions.Delete(questionID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditDelete, "question", questionID, question, nil)
+	})
+}
+
+func applyQuestionRequest(question *models.Question, req *models.QuestionRequest) {
+	question.Type = req.Type
+	question.Prompt = req.Prompt
+	question.Options = models.QuestionOptions(req.Options)
+	question.MatchTargets = models.QuestionOptions(req.MatchTargets)
+	question.Answer = req.Answer
+	question.AcceptedAnswers = models.StringSlice(req.AcceptedAnswers)
+	question.Tolerance = req.Tolerance
+	question.Points = req.Points
+	question.Explanation = req.Explanation
+	question.Tags = models.StringSlice(req.Tags)
+	question.UpdatedAt = time.Now()
+}
+
+// CreateQuiz creates a quiz and the "quiz" assignment that carries its due
+// date, attempt rules and gradebook entry
+func (s *QuizService) CreateQuiz(
+	courseID string,
+	req *models.QuizCreateRequest,
+	instructor *models.APIUser,
+	source *models.AuditSource,
+) (*models.Quiz, error) {
+	if len(req.QuestionIDs) == 0 && req.RandomCount == 0 {
+		return nil, ErrQuizHasNoQuestions
+	}
+
+	questionIDs := uniqueStrings(req.QuestionIDs)
+	questions, err := s.questionRepo.GetByIDs(questionIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) != len(questionIDs) {
+		return nil, fmt.Errorf("%w: some questions do not exist", ErrInvalidQuestion)
+	}
+	for _, question := range questions {
+		if question.CourseID != courseID {
+			return nil, fmt.Errorf("%w: question %s belongs to another course", ErrInvalidQuestion, question.ID)
+		}
+	}
+
+	if req.ActivityID != nil {
+		err = s.checkQuizActivity(*req.ActivityID, courseID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	assignment, quiz, err := s.newQuiz(courseID, questionIDs, req, instructor)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Assignments.Create(assignment)
+		if err != nil {
+			return err
+		}
+		err = tx.Quizzes.Create(quiz)
+		if err != nil {
+			return err
+		}
+		return recordQuizCreated(tx, source, assignment, quiz)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.assignmentService.bus.Publish(models.EventAssignmentCreated, *assignment)
+	return quiz, nil
+}
+
+// recordQuizCreated records a new quiz and its assignment in the audit trail
+func recordQuizCreated(
+	tx *repositories.TxRepositories,
+	source *models.AuditSource,
+	assignment *models.Assignment,
+	quiz *models.Quiz,
+) error {
+	err := recordAudit(tx, source, models.AuditCreate, "assignment", assignment.ID, nil, assignment)
+	if err != nil {
+		return err
+	}
+	return recordAudit(tx, source, models.AuditCreate, "quiz", quiz.ID, nil, quiz)
+}
+
+// newQuiz builds a quiz and the assignment it is graded through, without
+// saving them, so that callers can create both in one transaction
+func (s *QuizService) newQuiz(
+	courseID string,
+	questionIDs []string,
+	req *models.QuizCreateRequest,
+	instructor *models.APIUser,
+) (*models.Assignment, *models.Quiz, error) {
+	assignment, err := s.assignmentService.newAssignment(&models.AssignmentCreateRequest{
+		Title:              req.Title,
+		Description:        req.Description,
+		CourseID:           courseID,
+		Type:               "quiz",
+		TotalPoints:        req.TotalPoints,
+		DueDate:            req.DueDate,
+		LatePolicy:         req.LatePolicy,
+		LatePenaltyPercent: req.LatePenaltyPercent,
+		GracePeriodMinutes: req.GracePeriodMinutes,
+		MaxAttempts:        req.MaxAttempts,
+		AttemptPolicy:      req.AttemptPolicy,
+	}, instructor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	quiz := &models.Quiz{
+		ID:                 GenerateID(),
+		CourseID:           courseID,
+		AssignmentID:       assignment.ID,
+		ActivityID:         req.ActivityID,
+		Title:              req.Title,
+		Description:        req.Description,
+		QuestionIDs:        models.StringSlice(questionIDs),
+		RandomCount:        req.RandomCount,
+		RandomTags:         models.StringSlice(req.RandomTags),
+		ShuffleQuestions:   req.ShuffleQuestions,
+		ShuffleOptions:     req.ShuffleOptions,
+		TimeLimitMinutes:   req.TimeLimitMinutes,
+		ShowCorrectAnswers: req.ShowCorrectAnswers,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	return assignment, quiz, nil
+}
+
+func (s *QuizService) GetQuiz(quizID string) (*models.Quiz, error) {
+	return s.quizRepo.GetByID(quizID)
+}
+
+func (s *QuizService) GetCourseQuizzes(courseID string) ([]models.Quiz, error) {
+	return s.quizRepo.GetByCourseID(courseID)
+}
+
+// checkQuizActivity makes sure a quiz is attached to a quiz activity of its
+// own course
+func (s *QuizService) checkQuizActivity(activityID string, courseID string) error {
+	activity, err := s.activityRepo.GetByID(activityID)
+	if err != nil {
+		return err
+	}
+	section, err := s.sectionRepo.GetByID(activity.SectionID)
+	if err != nil {
+		return err
+	}
+	if activity.Type != "quiz" || section.CourseID != courseID {
+		return fmt.Errorf("%w: activity %s is not a quiz activity of this course", ErrInvalidQuestion, activityID)
+	}
+	return nil
+}
+
+// StartAttempt returns the student's open attempt at the quiz, or starts a
+// new one with freshly drawn and shuffled questions. Only students actively
+// enrolled in the course can take its quizzes, and a quiz whose activity or
+// section is hidden does not exist for them.
+func (s *QuizService) StartAttempt(
+	quizID string,
+	student *models.APIUser,
+	source *models.AuditSource,
+) (*models.QuizAttemptView, error) {
+	quiz, err := s.quizRepo.GetByID(quizID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkAvailable(quiz, student)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := s.quizRepo.GetOpenAttempt(quiz.ID, student.ID)
+	if err == nil {
+		if !attemptExpired(open, time.Now()) {
+			return s.view(quiz, open)
+		}
+		// Grade what was saved before time ran out, then start afresh
+		_, err = s.finish(quiz, open.ID, nil, source)
+		if err != nil && !errors.Is(err, ErrAttemptClosed) {
+			return nil, err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	assignment, err := s.assignmentService.GetAssignmentByID(quiz.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	left, err := s.assignmentService.AttemptsLeft(assignment, student.ID)
+	if err != nil {
+		return nil, err
+	}
+	if left == 0 {
+		return nil, ErrNoAttemptsLeft
+	}
+
+	items, err := s.drawQuestions(quiz)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	attempt := &models.QuizAttempt{
+		ID:        GenerateID(),
+		QuizID:    quiz.ID,
+		StudentID: student.ID,
+		Status:    models.QuizAttemptInProgress,
+		Items:     items,
+		Responses: models.QuizResponses{},
+		Results:   models.QuestionResults{},
+		StartedAt: now,
+	}
+	if quiz.TimeLimitMinutes > 0 {
+		expiresAt := now.Add(time.Duration(quiz.TimeLimitMinutes) * time.Minute)
+		attempt.ExpiresAt = &expiresAt
+	}
+
+	// Lock the quiz's assignment so that concurrent requests cannot both
+	// start an attempt; the later one gets the attempt the first started
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		_, err := tx.Assignments.GetByIDForUpdate(quiz.AssignmentID)
+		if err != nil {
+			return err
+		}
+		open, err := tx.Quizzes.GetOpenAttempt(quiz.ID, student.ID)
+		if err == nil {
+			attempt = open
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		err = tx.Quizzes.CreateAttempt(attempt)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "quiz_attempt", attempt.ID, nil, attempt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.view(quiz, attempt)
+}
+
+// checkAvailable lets students take the quiz if they are actively enrolled in
+// its course and its activity and section are visible
+func (s *QuizService) checkAvailable(quiz *models.Quiz, student *models.APIUser) error {
+	course, err := s.courseRepo.GetByID(quiz.CourseID)
+	if err != nil {
+		return err
+	}
+	err = s.access.check(course, student)
+	if err != nil || isModerator(course, student) || quiz.ActivityID == nil {
+		return err
+	}
+
+	activity, err := s.activityRepo.GetByID(*quiz.ActivityID)
+	if err != nil {
+		return err
+	}
+	section, err := s.sectionRepo.GetByID(activity.SectionID)
+	if err != nil {
+		return err
+	}
+	if !activity.Visible || !section.Visible {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetAttempt returns the attempt as its student sees it. An expired attempt
+// is graded with the answers saved before time ran out.
+func (s *QuizService) GetAttempt(
+	attemptID string,
+	viewer *models.APIUser,
+	source *models.AuditSource,
+) (*models.QuizAttemptView, error) {
+	quiz, attempt, err := s.ownAttempt(attemptID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempt.Status == models.QuizAttemptInProgress && attemptExpired(attempt, time.Now()) {
+		attempt, err = s.finish(quiz, attempt.ID, nil, source)
+		if errors.Is(err, ErrAttemptClosed) {
+			attempt, err = s.quizRepo.GetAttemptByID(attemptID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.view(quiz, attempt)
+}
+
+// SaveResponses stores answers of an open attempt without submitting it
+func (s *QuizService) SaveResponses(
+	attemptID string,
+	req *models.QuizResponsesRequest,
+	student *models.APIUser,
+	source *models.AuditSource,
+) (*models.QuizAttemptView, error) {
+	quiz, attempt, err := s.ownAttempt(attemptID, student)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		// Lock the attempt so answers are not saved into one being submitted
+		attempt, err = tx.Quizzes.GetAttemptByIDForUpdate(attempt.ID)
+		if err != nil {
+			return err
+		}
+		if attempt.Status != models.QuizAttemptInProgress || attemptExpired(attempt, time.Now()) {
+			return ErrAttemptClosed
+		}
+		before, err := snapshot(attempt)
+		if err != nil {
+			return err
+		}
+
+		mergeResponses(attempt, req.Responses)
+		err = tx.Quizzes.UpdateAttempt(attempt)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "quiz_attempt", attempt.ID, before, attempt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.view(quiz, attempt)
+}
+
+// SubmitAttempt grades the attempt and records it as a submission of the
+// quiz's assignment. Answers sent after a timed attempt has expired are
+// ignored; the answers saved in time are graded instead.
+func (s *QuizService) SubmitAttempt(
+	attemptID string,
+	req *models.QuizResponsesRequest,
+	student *models.APIUser,
+	source *models.AuditSource,
+) (*models.QuizAttemptView, error) {
+	quiz, attempt, err := s.ownAttempt(attemptID, student)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt, err = s.finish(quiz, attempt.ID, req.Responses, source)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.view(quiz, attempt)
+}
+
+func (s *QuizService) ownAttempt(attemptID string, viewer *models.APIUser) (*models.Quiz, *models.QuizAttempt, error) {
+	attempt, err := s.quizRepo.GetAttemptByID(attemptID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attempt.StudentID != viewer.ID {
+		return nil, nil, ErrForbidden
+	}
+
+	quiz, err := s.quizRepo.GetByID(attempt.QuizID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quiz, attempt, nil
+}
+
+// finish adds the responses to the open attempt unless it has expired, grades
+// it, stores the result as a graded submission of the quiz's assignment and
+// closes the attempt. It all happens in one transaction that holds the
+// attempt's row, so an attempt is graded once. If the assignment no longer
+// takes the submission, because it is past due under a reject policy or no
+// attempts are left, the attempt is closed graded but without a submission.
+func (s *QuizService) finish(
+	quiz *models.Quiz,
+	attemptID string,
+	responses map[string]models.Answer,
+	source *models.AuditSource,
+) (*models.QuizAttempt, error) {
+	assignment, err := s.assignmentService.GetAssignmentByID(quiz.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attempt *models.QuizAttempt
+	var submission *models.Submission
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		attempt, err = tx.Quizzes.GetAttemptByIDForUpdate(attemptID)
+		if err != nil {
+			return err
+		}
+		if attempt.Status != models.QuizAttemptInProgress {
+			return ErrAttemptClosed
+		}
+		before, err := snapshot(attempt)
+		if err != nil {
+			return err
+		}
+		if !attemptExpired(attempt, time.Now()) {
+			mergeResponses(attempt, responses)
+		}
+
+		results, earned, possible, err := s.grade(quiz, attempt)
+		if err != nil {
+			return err
+		}
+		score := 0
+		if possible > 0 {
+			score = int(math.Round(earned / possible * float64(assignment.TotalPoints)))
+		}
+
+		content, err := json.Marshal(attempt.Responses)
+		if err != nil {
+			return err
+		}
+		feedback := fmt.Sprintf("Quiz auto-graded: %g of %g points.", round2(earned), round2(possible))
+		submission, err = s.assignmentService.SubmitGraded(tx, assignment, attempt.StudentID, string(content), score, feedback, source)
+		if err != nil && !errors.Is(err, ErrSubmissionClosed) && !errors.Is(err, ErrNoAttemptsLeft) {
+			return err
+		}
+
+		now := time.Now()
+		attempt.Status = models.QuizAttemptSubmitted
+		attempt.Results = results
+		attempt.PointsEarned = round2(earned)
+		attempt.PointsPossible = round2(possible)
+		attempt.Score = &score
+		attempt.SubmittedAt = &now
+		if submission != nil {
+			attempt.SubmissionID = &submission.ID
+		}
+		err = tx.Quizzes.UpdateAttempt(attempt)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "quiz_attempt", attempt.ID, before, attempt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if submission != nil {
+		s.assignmentService.AnnounceGraded(assignment, submission)
+	}
+	return attempt, nil
+}
+
+// grade scores each answer of the attempt and returns the results with the
+// points earned and possible
+func (s *QuizService) grade(quiz *models.Quiz, attempt *models.QuizAttempt) (models.QuestionResults, float64, float64, error) {
+	questions, err := s.attemptQuestions(attempt)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	results := models.QuestionResults{}
+	earned, possible := 0.0, 0.0
+	for _, item := range attempt.Items {
+		question, ok := questions[item.QuestionID]
+		if !ok {
+			continue
+		}
+		points, correct := scoreAnswer(question, attempt.Responses[item.QuestionID])
+		result := models.QuestionResult{
+			QuestionID:   question.ID,
+			PointsEarned: points,
+			Points:       question.Points,
+			Correct:      correct,
+		}
+		if quiz.ShowCorrectAnswers {
+			answer := question.Answer
+			result.CorrectAnswer = &answer
+			result.Explanation = question.Explanation
+		}
+		results = append(results, result)
+		earned += points
+		possible += question.Points
+	}
+	return results, earned, possible, nil
+}
+
+// drawQuestions picks the quiz's fixed questions plus RandomCount questions
+// drawn from the course bank, optionally limited to RandomTags, and shuffles
+// them as the quiz asks
+func (s *QuizService) drawQuestions(quiz *models.Quiz) (models.AttemptItems, error) {
+	questions, err := s.questionRepo.GetByCourseID(quiz.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]models.Question{}
+	for _, question := range questions {
+		byID[question.ID] = question
+	}
+
+	chosen := []models.Question{}
+	fixed := map[string]bool{}
+	for _, id := range quiz.QuestionIDs {
+		if question, ok := byID[id]; ok {
+			chosen = append(chosen, question)
+			fixed[id] = true
+		}
+	}
+
+	if quiz.RandomCount > 0 {
+		pool := []models.Question{}
+		for _, question := range questions {
+			if !fixed[question.ID] && hasAnyTag(question.Tags, quiz.RandomTags) {
+				pool = append(pool, question)
+			}
+		}
+		rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		chosen = append(chosen, pool[:min(quiz.RandomCount, len(pool))]...)
+	}
+
+	if len(chosen) == 0 {
+		return nil, ErrQuizHasNoQuestions
+	}
+	if quiz.ShuffleQuestions {
+		rand.Shuffle(len(chosen), func(i, j int) { chosen[i], chosen[j] = chosen[j], chosen[i] })
+	}
+
+	items := make(models.AttemptItems, len(chosen))
+	for i, question := range chosen {
+		items[i] = models.AttemptItem{
+			QuestionID:  question.ID,
+			OptionOrder: optionOrder(question.Options, quiz.ShuffleOptions),
+			TargetOrder: optionOrder(question.MatchTargets, quiz.ShuffleOptions),
+		}
+	}
+	return items, nil
+}
+
+func (s *QuizService) attemptQuestions(attempt *models.QuizAttempt) (map[string]*models.Question, error) {
+	ids := make([]string, len(attempt.Items))
+	for i, item := range attempt.Items {
+		ids[i] = item.QuestionID
+	}
+
+	questions, err := s.questionRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*models.Question{}
+	for i := range questions {
+		byID[questions[i].ID] = &questions[i]
+	}
+	return byID, nil
+}
+
+// view builds the student's view of the attempt, hiding answer keys
+func (s *QuizService) view(quiz *models.Quiz, attempt *models.QuizAttempt) (*models.QuizAttemptView, error) {
+	questions, err := s.attemptQuestions(attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	views := []models.QuizQuestionView{}
+	for _, item := range attempt.Items {
+		question, ok := questions[item.QuestionID]
+		if !ok {
+			continue
+		}
+		views = append(views, models.QuizQuestionView{
+			ID:           question.ID,
+			Type:         question.Type,
+			Prompt:       question.Prompt,
+			Options:      orderOptions(question.Options, item.OptionOrder),
+			MatchTargets: orderOptions(question.MatchTargets, item.TargetOrder),
+			Points:       question.Points,
+		})
+	}
+
+	return &models.QuizAttemptView{
+		QuizAttempt: *attempt,
+		Title:       quiz.Title,
+		Questions:   views,
+	}, nil
+}
+
+func attemptExpired(attempt *models.QuizAttempt, now time.Time) bool {
+	return attempt.ExpiresAt != nil && now.After(attempt.ExpiresAt.Add(quizSubmitGrace))
+}
+
+// mergeResponses stores the answers to questions that are part of the attempt
+func mergeResponses(attempt *models.QuizAttempt, responses map[string]models.Answer) {
+	if attempt.Responses == nil {
+		attempt.Responses = models.QuizResponses{}
+	}
+	for _, item := range attempt.Items {
+		if response, ok := responses[item.QuestionID]; ok {
+			attempt.Responses[item.QuestionID] = response
+		}
+	}
+}
+
+func optionOrder(options models.QuestionOptions, shuffle bool) []string {
+	if len(options) == 0 {
+		return nil
+	}
+	order := make([]string, len(options))
+	for i, option := range options {
+		order[i] = option.ID
+	}
+	if shuffle {
+		rand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	return order
+}
+
+// orderOptions returns the options in the given order. Options added to the
+// question after the attempt started are shown last.
+func orderOptions(options models.QuestionOptions, order []string) []models.QuestionOption {
+	if len(options) == 0 {
+		return nil
+	}
+
+	byID := map[string]models.QuestionOption{}
+	for _, option := range options {
+		byID[option.ID] = option
+	}
+
+	ordered := []models.QuestionOption{}
+	for _, id := range order {
+		if option, ok := byID[id]; ok {
+			ordered = append(ordered, option)
+			delete(byID, id)
+		}
+	}
+	for _, option := range options {
+		if _, ok := byID[option.ID]; ok {
+			ordered = append(ordered, option)
+		}
+	}
+	return ordered
+}
+
+// hasAnyTag reports whether the question carries one of the tags. An empty
+// tag list matches every question.
+func hasAnyTag(tags models.StringSlice, wanted models.StringSlice) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		for _, want := range wanted {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidRubric = errors.New("invalid rubric")
+
+func (s *AssignmentService) GetRubric(assignmentID string) (*models.Rubric, error) {
+	return s.repo.GetRubric(assignmentID)
+}
+
+// SetRubric creates the assignment's rubric or replaces it. Submissions
+// already graded by the old rubric keep their assessments and scores.
+func (s *AssignmentService) SetRubric(
+	assignmentID string,
+	req *models.RubricRequest,
+	author *models.APIUser,
+	source *models.AuditSource,
+) (*models.Rubric, error) {
+	assignment, err := s.repo.GetByID(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	criteria := models.RubricCriteria{}
+	criterionIDs := map[string]bool{}
+	maxPoints := 0.0
+	for _, c := range req.Criteria {
+		criterion := models.RubricCriterion{
+			ID:          c.ID,
+			Title:       c.Title,
+			Description: c.Description,
+			Levels:      []models.RubricLevel{},
+		}
+		if criterion.ID == "" {
+			criterion.ID = GenerateID()
+		}
+		if criterionIDs[criterion.ID] {
+			return nil, fmt.Errorf("%w: duplicate criterion id %q", ErrInvalidRubric, criterion.ID)
+		}
+		criterionIDs[criterion.ID] = true
+
+		levelIDs := map[string]bool{}
+		best := 0.0
+		for _, l := range c.Levels {
+			level := models.RubricLevel{
+				ID:          l.ID,
+				Title:       l.Title,
+				Description: l.Description,
+				Points:      l.Points,
+			}
+			if level.ID == "" {
+				level.ID = GenerateID()
+			}
+			if levelIDs[level.ID] {
+				return nil, fmt.Errorf("%w: duplicate level id %q in criterion %q", ErrInvalidRubric, level.ID, criterion.Title)
+			}
+			levelIDs[level.ID] = true
+			best = math.Max(best, level.Points)
+			criterion.Levels = append(criterion.Levels, level)
+		}
+
+		maxPoints += best
+		criteria = append(criteria, criterion)
+	}
+	if maxPoints <= 0 {
+		return nil, fmt.Errorf("%w: the rubric must be worth more than 0 points", ErrInvalidRubric)
+	}
+
+	now := time.Now()
+	rubric := &models.Rubric{
+		ID:           GenerateID(),
+		AssignmentID: assignment.ID,
+		Title:        req.Title,
+		Criteria:     criteria,
+		MaxPoints:    round2(maxPoints),
+		CreatedBy:    author.ID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	var saved *models.Rubric
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		// Lock the assignment so the rubric it replaces is the one recorded
+		_, err := tx.Assignments.GetByIDForUpdate(assignment.ID)
+		if err != nil {
+			return err
+		}
+		before, err := tx.Assignments.GetRubric(assignment.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		action := models.AuditUpdate
+		if before == nil {
+			action = models.AuditCreate
+		}
+
+		err = tx.Assignments.SaveRubric(rubric)
+		if err != nil {
+			return err
+		}
+
+		// The stored rubric keeps its ID when replaced
+		saved, err = tx.Assignments.GetRubric(assignment.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, action, "rubric", assignment.ID, before, saved)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+func (s *AssignmentService) DeleteRubric(assignmentID string, source *models.AuditSource) error {
+	return s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		rubric, err := tx.Assignments.GetRubric(assignmentID)
+		if err != nil {
+			return err
+		}
+		err = tx.Assignments.DeleteRubric(assignmentID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditDelete, "rubric", assignmentID, rubric, nil)
+	})
+}
+
+// GradeWithRubric grades the submission by choosing a level for every
+// criterion of the assignment's rubric. The rubric's points are scaled to the
+// assignment's total points to give the score.
+func (s *AssignmentService) GradeWithRubric(
+	req *models.RubricGradeRequest,
+	grader *models.APIUser,
+	source *models.AuditSource,
+) (*models.Submission, error) {
+	assignment, submission, err := s.gradableSubmission(req.SubmissionID, grader)
+	if err != nil {
+		return nil, err
+	}
+
+	rubric, err := s.repo.GetRubric(assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores, earned, possible, err := scoreRubric(rubric, req.Criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	score := int(math.Round(earned / possible * float64(assignment.TotalPoints)))
+
+	now := time.Now()
+	assessment := &models.RubricAssessment{
+		ID:             GenerateID(),
+		SubmissionID:   submission.ID,
+		RubricID:       rubric.ID,
+		Scores:         scores,
+		PointsEarned:   round2(earned),
+		PointsPossible: round2(possible),
+		GradedBy:       grader.ID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	err = s.saveGrade(assignment, submission, score, req.Feedback, grader.ID, assessment, source)
+	if err != nil {
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// scoreRubric looks up the chosen level of every criterion of the rubric and
+// returns the filled-in scores with the points earned and possible
+func scoreRubric(rubric *models.Rubric, grades []models.RubricCriterionGrade) (models.RubricScores, float64, float64, error) {
+	chosen := map[string]models.RubricCriterionGrade{}
+	for _, grade := range grades {
+		if _, ok := chosen[grade.CriterionID]; ok {
+			return nil, 0, 0, fmt.Errorf("%w: criterion %q is graded twice", ErrInvalidRubric, grade.CriterionID)
+		}
+		chosen[grade.CriterionID] = grade
+	}
+
+	scores := models.RubricScores{}
+	earned, possible := 0.0, 0.0
+	for _, criterion := range rubric.Criteria {
+		grade, ok := chosen[criterion.ID]
+		if !ok {
+			return nil, 0, 0, fmt.Errorf("%w: criterion %q is not graded", ErrInvalidRubric, criterion.Title)
+		}
+		delete(chosen, criterion.ID)
+
+		var level *models.RubricLevel
+		best := 0.0
+		for i := range criterion.Levels {
+			if criterion.Levels[i].ID == grade.LevelID {
+				level = &criterion.Levels[i]
+			}
+			best = math.Max(best, criterion.Levels[i].Points)
+		}
+		if level == nil {
+			return nil, 0, 0, fmt.Errorf("%w: criterion %q has no level %q", ErrInvalidRubric, criterion.Title, grade.LevelID)
+		}
+
+		scores = append(scores, models.RubricScore{
+			CriterionID:    criterion.ID,
+			CriterionTitle: criterion.Title,
+			LevelID:        level.ID,
+			LevelTitle:     level.Title,
+			Points:         level.Points,
+			MaxPoints:      best,
+			Comment:        grade.Comment,
+		})
+		earned += level.Points
+		possible += best
+	}
+	for id := range chosen {
+		return nil, 0, 0, fmt.Errorf("%w: the rubric has no criterion %q", ErrInvalidRubric, id)
+	}
+
+	return scores, earned, possible, nil
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/similarity"
+	"gorm.io/gorm"
+)
+
+// similarityThreshold is the percentage of either submission's fingerprint
+// the other must contain for a pair to be reported
+const similarityThreshold = 20.0
+
+// SimilarityService checks submissions for similarity in the background. Checks
+// wait in a bounded queue for a fixed number of workers, so a burst of
+// submissions cannot start an unbounded number of comparisons.
+type SimilarityService struct {
+	repo               *repositories.SimilarityRepository
+	assignmentRepo     *repositories.AssignmentRepository
+	generativeTaskRepo *repositories.GenerativeTaskRepository
+	activityService    *ActivityService
+	transactor         *repositories.Transactor
+	workers            int
+	queue              chan models.SimilarityCheck
+	done               chan struct{}
+	mu                 sync.RWMutex
+	stopped            bool
+	wg                 sync.WaitGroup
+}
+
+func NewSimilarityService(
+	repo *repositories.SimilarityRepository,
+	assignmentRepo *repositories.AssignmentRepository,
+	generativeTaskRepo *repositories.GenerativeTaskRepository,
+	activityService *ActivityService,
+	transactor *repositories.Transactor,
+	cfg *config.Similarity,
+) *SimilarityService {
+	return &SimilarityService{
+		repo:               repo,
+		assignmentRepo:     assignmentRepo,
+		generativeTaskRepo: generativeTaskRepo,
+		activityService:    activityService,
+		transactor:         transactor,
+		workers:            max(cfg.Workers, 1),
+		queue:              make(chan models.SimilarityCheck, max(cfg.QueueSize, 1)),
+		done:               make(chan struct{}),
+	}
+}
+
+// StartWorkers starts the workers that run queued checks
+func (s *SimilarityService) StartWorkers() {
+	for range s.workers {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for check := range s.queue {
+				select {
+				case <-s.done:
+					s.fail(check, ErrShuttingDown)
+				default:
+					s.run(check)
+				}
+			}
+		}()
+	}
+}
+
+// Stop stops accepting checks and waits for the running ones to finish.
+// Checks still queued are marked failed, so they can be rechecked later.
+func (s *SimilarityService) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	close(s.done)
+	close(s.queue)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// document is a submission's content with what it is compared by
+type document struct {
+	submissionID string
+	studentID    string
+	content      string
+	submittedAt  time.Time
+}
+
+// GetSubmissionInstructorID returns the instructor of the submission's assignment
+func (s *SimilarityService) GetSubmissionInstructorID(submissionID string) (string, error) {
+	submission, err := s.assignmentRepo.GetSubmissionByID(submissionID)
+	if err != nil {
+		return "", err
+	}
+	assignment, err := s.assignmentRepo.GetByID(submission.AssignmentID)
+	if err != nil {
+		return "", err
+	}
+	return assignment.InstructorID, nil
+}
+
+// GetTaskSubmissionInstructorID returns the instructor of the course the
+// generative task submission's activity belongs to
+func (s *SimilarityService) GetTaskSubmissionInstructorID(submissionID string) (string, error) {
+	submission, err := s.generativeTaskRepo.GetSubmissionByID(submissionID)
+	if err != nil {
+		return "", err
+	}
+	task, err := s.generativeTaskRepo.GetByID(submission.TaskID)
+	if err != nil {
+		return "", err
+	}
+	return s.activityService.GetActivityInstructorID(task.ActivityID)
+}
+
+// Check starts a similarity check of the new submission against the earlier
+// submissions of other students. The check runs in the background and the
+// submission's report is pending until it finishes; if too many checks are
+// waiting, it is marked failed and can be rechecked later. Failing to start
+// it does not fail the submission.
+func (s *SimilarityService) Check(kind string, submissionID string) {
+	_, err := s.start(kind, submissionID)
+	if err != nil {
+		log.Printf("Failed to start the similarity check of %s %s: %v", kind, submissionID, err)
+	}
+}
+
+// Recheck checks the submission again, replacing its earlier report
+func (s *SimilarityService) Recheck(kind string, submissionID string, source *models.AuditSource) (*models.SimilarityCheck, error) {
+	_, err := s.document(kind, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	check := newCheck(kind, submissionID)
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		before, err := tx.Similarity.GetCheck(submissionID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		err = tx.Similarity.SaveCheck(check)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "similarity_check", submissionID, before, check)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.queueCheck(check)
+}
+
+// GetReport returns the submission's similarity report
+func (s *SimilarityService) GetReport(kind string, submissionID string) (*models.SimilarityReport, error) {
+	check, err := s.repo.GetCheck(submissionID)
+	if err != nil {
+		return nil, err
+	}
+	if check.Kind != kind {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	doc, err := s.document(kind, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.repo.GetMatches(submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.SimilarityReport{
+		SimilarityCheck: *check,
+		StudentID:       doc.studentID,
+		Content:         doc.content,
+		Matches:         make([]models.SimilarityMatch, len(matches)),
+	}
+	for i, match := range matches {
+		if match.SubmissionID != submissionID {
+			match = match.Reversed()
+		}
+		report.Matches[i] = match
+		report.MaxScore = max(report.MaxScore, match.Score)
+	}
+	sort.SliceStable(report.Matches, func(i, j int) bool {
+		return report.Matches[i].Score > report.Matches[j].Score
+	})
+
+	return report, nil
+}
+
+func (s *SimilarityService) start(kind string, submissionID string) (*models.SimilarityCheck, error) {
+	check := newCheck(kind, submissionID)
+	err := s.repo.SaveCheck(check)
+	if err != nil {
+		return nil, err
+	}
+	return s.queueCheck(check)
+}
+
+// newCheck returns a pending check of the submission
+func newCheck(kind string, submissionID string) *models.SimilarityCheck {
+	return &models.SimilarityCheck{
+		SubmissionID: submissionID,
+		Kind:         kind,
+		Status:       models.SimilarityPending,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// queueCheck hands the saved check to the workers, marking it failed if they
+// cannot take it
+func (s *SimilarityService) queueCheck(check *models.SimilarityCheck) (*models.SimilarityCheck, error) {
+	err := s.enqueue(*check)
+	if err != nil {
+		s.fail(*check, err)
+		return nil, err
+	}
+
+	return check, nil
+}
+
+// enqueue hands the check to the workers without waiting for room in the
+// queue
+func (s *SimilarityService) enqueue(check models.SimilarityCheck) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopped {
+		return ErrShuttingDown
+	}
+	select {
+	case s.queue <- check:
+		return nil
+	default:
+		return ErrSimilarityBusy
+	}
+}
+
+func (s *SimilarityService) run(check models.SimilarityCheck) {
+	matches, err := s.compare(check.Kind, check.SubmissionID)
+	if err != nil {
+		log.Printf("Similarity check of %s %s failed: %v", check.Kind, check.SubmissionID, err)
+		s.fail(check, err)
+		return
+	}
+
+	now := time.Now()
+	check.CheckedAt = &now
+	check.Status = models.SimilarityCompleted
+	check.Error = ""
+	err = s.repo.CompleteCheck(&check, matches)
+	if err != nil {
+		log.Printf("Failed to store the similarity check of %s %s: %v", check.Kind, check.SubmissionID, err)
+	}
+}
+
+// fail records that the check did not run to completion
+func (s *SimilarityService) fail(check models.SimilarityCheck, reason error) {
+	now := time.Now()
+	check.CheckedAt = &now
+	check.Status = models.SimilarityFailed
+	check.Error = reason.Error()
+	err := s.repo.SaveCheck(&check)
+	if err != nil {
+		log.Printf("Failed to store the similarity check of %s %s: %v", check.Kind, check.SubmissionID, err)
+	}
+}
+
+// compare fingerprints the submission and every earlier submission by another
+// student, and returns the pairs similar enough to report
+func (s *SimilarityService) compare(kind string, submissionID string) ([]models.SimilarityMatch, error) {
+	doc, err := s.document(kind, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	others, mode, err := s.candidates(kind, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := similarity.New(doc.content, mode)
+	now := time.Now()
+	matches := []models.SimilarityMatch{}
+	for _, other := range others {
+		if other.studentID == doc.studentID || !other.submittedAt.Before(doc.submittedAt) {
+			continue
+		}
+
+		match := similarity.Compare(fingerprint, similarity.New(other.content, mode))
+		score, matchedScore := round2(match.Score*100), round2(match.OtherScore*100)
+		if score < similarityThreshold && matchedScore < similarityThreshold {
+			continue
+		}
+
+		matches = append(matches, models.SimilarityMatch{
+			ID:                  GenerateID(),
+			Kind:                kind,
+			SubmissionID:        doc.submissionID,
+			StudentID:           doc.studentID,
+			MatchedSubmissionID: other.submissionID,
+			MatchedStudentID:    other.studentID,
+			Score:               score,
+			MatchedScore:        matchedScore,
+			Spans:               match.Spans,
+			MatchedSpans:        match.OtherSpans,
+			CreatedAt:           now,
+		})
+	}
+
+	return matches, nil
+}
+
+func (s *SimilarityService) document(kind string, submissionID string) (*document, error) {
+	switch kind {
+	case models.SimilarityKindSubmission:
+		submission, err := s.assignmentRepo.GetSubmissionByID(submissionID)
+		if err != nil {
+			return nil, err
+		}
+		return &document{submission.ID, submission.StudentID, submission.Content, submission.SubmittedAt}, nil
+	case models.SimilarityKindGenerativeTask:
+		submission, err := s.generativeTaskRepo.GetSubmissionByID(submissionID)
+		if err != nil {
+			return nil, err
+		}
+		return &document{submission.ID, submission.StudentID, submission.Code, submission.CreatedAt}, nil
+	}
+	return nil, fmt.Errorf("unknown similarity kind %q", kind)
+}
+
+// candidates returns the submissions the submission is compared with: those
+// for the same assignment, or for any task generated from the same activity,
+// since every student gets their own task
+func (s *SimilarityService) candidates(kind string, submissionID string) ([]document, similarity.Mode, error) {
+	docs := []document{}
+	switch kind {
+	case models.SimilarityKindSubmission:
+		submission, err := s.assignmentRepo.GetSubmissionByID(submissionID)
+		if err != nil {
+			return nil, "", err
+		}
+		assignment, err := s.assignmentRepo.GetByID(submission.AssignmentID)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, other := range assignment.Submissions {
+			docs = append(docs, document{other.ID, other.StudentID, other.Content, other.SubmittedAt})
+		}
+		return docs, similarityMode(assignment), nil
+	case models.SimilarityKindGenerativeTask:
+		submission, err := s.generativeTaskRepo.GetSubmissionByID(submissionID)
+		if err != nil {
+			return nil, "", err
+		}
+		task, err := s.generativeTaskRepo.GetByID(submission.TaskID)
+		if err != nil {
+			return nil, "", err
+		}
+		submissions, err := s.generativeTaskRepo.GetActivitySubmissions(task.ActivityID)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, other := range submissions {
+			docs = append(docs, document{other.ID, other.StudentID, other.Code, other.CreatedAt})
+		}
+		return docs, similarity.Code, nil
+	}
+	return nil, "", fmt.Errorf("unknown similarity kind %q", kind)
+}
+
+// similarityMode fingerprints code assignments as code and the rest as prose
+func similarityMode(assignment *models.Assignment) similarity.Mode {
+	if assignment.Type == models.AssignmentTypeCode {
+		return similarity.Code
+	}
+	return similarity.Text
+}
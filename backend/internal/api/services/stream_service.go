@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/events"
+	"github.com/TheApostroff/skill-space/internal/realtime"
+)
+
+// StreamService pushes domain events to the clients subscribed to the topics
+// they concern:
+//   - user:<id> gets the user's notifications, grades and enrollment changes,
+//     and a course instructor's also gets the enrollment changes of their courses
+//   - course:<id> gets the course's new assignments
+//   - forum:<id> gets the forum's new posts and replies
+type StreamService struct {
+	hub    *realtime.Hub
+	bus    *events.Bus
+	access courseAccess
+}
+
+func NewStreamService(
+	hub *realtime.Hub,
+	bus *events.Bus,
+	courseRepo *repositories.CourseRepository,
+	sectionRepo *repositories.SectionRepository,
+	activityRepo *repositories.ActivityRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+) *StreamService {
+	return &StreamService{
+		hub: hub,
+		bus: bus,
+		access: courseAccess{
+			courseRepo:     courseRepo,
+			sectionRepo:    sectionRepo,
+			activityRepo:   activityRepo,
+			enrollmentRepo: enrollmentRepo,
+		},
+	}
+}
+
+// Subscribe forwards the domain events clients can follow to the hub
+func (s *StreamService) Subscribe() {
+	s.bus.Subscribe(models.EventAssignmentCreated, func(event events.Event) {
+		assignment := event.Payload.(models.Assignment)
+		s.push(realtime.CourseTopic(assignment.CourseID), event.Type, assignment)
+	})
+	s.bus.Subscribe(models.EventSubmissionGraded, func(event events.Event) {
+		graded := event.Payload.(models.SubmissionGradedEvent)
+		s.push(realtime.UserTopic(graded.Submission.StudentID), event.Type, graded.Submission)
+	})
+	s.bus.Subscribe(models.EventEnrollmentChanged, func(event events.Event) {
+		changed := event.Payload.(models.EnrollmentChangedEvent)
+		s.push(realtime.UserTopic(changed.Enrollment.StudentID), event.Type, changed.Enrollment)
+		s.push(realtime.UserTopic(changed.Course.InstructorID), event.Type, changed.Enrollment)
+	})
+	s.bus.Subscribe(models.EventForumPostCreated, func(event events.Event) {
+		post := event.Payload.(models.ForumPost)
+		s.push(realtime.ForumTopic(post.ForumID), event.Type, post)
+	})
+	s.bus.Subscribe(models.EventForumReplyCreated, func(event events.Event) {
+		created := event.Payload.(models.ForumReplyCreatedEvent)
+		s.push(realtime.ForumTopic(created.Reply.ForumID), event.Type, created.Reply)
+	})
+	s.bus.Subscribe(models.EventNotificationCreated, func(event events.Event) {
+		for _, notification := range event.Payload.([]models.Notification) {
+			if notification.InApp {
+				s.push(realtime.UserTopic(notification.UserID), event.Type, notification)
+			}
+		}
+	})
+}
+
+// Open subscribes the viewer to their own user topic and the requested
+// topics they may follow
+func (s *StreamService) Open(topics []string, viewer *models.APIUser) (*realtime.Subscription, error) {
+	allowed := []string{realtime.UserTopic(viewer.ID)}
+	for _, topic := range topics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" || slices.Contains(allowed, topic) {
+			continue
+		}
+		err := s.authorize(topic, viewer)
+		if err != nil {
+			return nil, err
+		}
+		allowed = append(allowed, topic)
+	}
+	return s.hub.Subscribe(allowed...), nil
+}
+
+// Recheck authorizes the subscription's topics again, for when the viewer's
+// access may have changed. The stream should be closed if it fails.
+func (s *StreamService) Recheck(subscription *realtime.Subscription, viewer *models.APIUser) error {
+	for _, topic := range subscription.Topics() {
+		err := s.authorize(topic, viewer)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorize checks that the viewer may follow the topic: their own user
+// topic, and the courses and course forums they teach or are actively
+// enrolled in. Admins may follow any topic.
+func (s *StreamService) authorize(topic string, viewer *models.APIUser) error {
+	kind, id, _ := strings.Cut(topic, ":")
+	if id == "" {
+		return fmt.Errorf("%w: %q", ErrUnknownTopic, topic)
+	}
+
+	var course *models.Course
+	var err error
+	switch topic {
+	case realtime.UserTopic(id):
+		if id == viewer.ID || viewer.Role == models.RoleAdmin {
+			return nil
+		}
+		return ErrForbidden
+	case realtime.CourseTopic(id):
+		course, err = s.access.courseRepo.GetByID(id)
+	case realtime.ForumTopic(id):
+		_, course, err = s.access.activityCourse(id)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownTopic, kind)
+	}
+	if err != nil {
+		return err
+	}
+	return s.access.check(course, viewer)
+}
+
+func (s *StreamService) push(topic string, eventType string, data any) {
+	err := s.hub.Publish(topic, eventType, data)
+	if err != nil {
+		log.Printf("Failed to push %s to %s: %v", eventType, topic, err)
+	}
+}
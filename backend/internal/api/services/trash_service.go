@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/storage"
+)
+
+type TrashService struct {
+	repo         *repositories.TrashRepository
+	courseRepo   *repositories.CourseRepository
+	sectionRepo  *repositories.SectionRepository
+	activityRepo *repositories.ActivityRepository
+	storage      storage.Storage
+	cfg          *config.Trash
+}
+
+func NewTrashService(
+	repo *repositories.TrashRepository,
+	courseRepo *repositories.CourseRepository,
+	sectionRepo *repositories.SectionRepository,
+	activityRepo *repositories.ActivityRepository,
+	storage storage.Storage,
+	cfg *config.Trash,
+) *TrashService {
+	return &TrashService{
+		repo:         repo,
+		courseRepo:   courseRepo,
+		sectionRepo:  sectionRepo,
+		activityRepo: activityRepo,
+		storage:      storage,
+		cfg:          cfg,
+	}
+}
+
+// GetCourseInstructorID returns the instructor who owns the course, which may be deleted
+func (s *TrashService) GetCourseInstructorID(courseID string) (string, error) {
+	course, err := s.courseRepo.GetByIDWithDeleted(courseID)
+	if err != nil {
+		return "", err
+	}
+	return course.InstructorID, nil
+}
+
+// GetActivityInstructorID returns the instructor of the course the activity
+// belongs to, any of which may be deleted
+func (s *TrashService) GetActivityInstructorID(activityID string) (string, error) {
+	activity, err := s.activityRepo.GetByIDWithDeleted(activityID)
+	if err != nil {
+		return "", err
+	}
+	section, err := s.sectionRepo.GetByIDWithDeleted(activity.SectionID)
+	if err != nil {
+		return "", err
+	}
+	return s.GetCourseInstructorID(section.CourseID)
+}
+
+// GetDeletedCourses returns the deleted courses the viewer can restore: their
+// own, or every deleted course for an admin
+func (s *TrashService) GetDeletedCourses(viewer *models.APIUser) ([]models.Course, error) {
+	instructorID := viewer.ID
+	if viewer.Role == models.RoleAdmin {
+		instructorID = ""
+	}
+	return s.repo.GetDeletedCourses(instructorID)
+}
+
+// GetCourseTrash returns the deleted content of the course, with the course
+// itself if it is deleted
+func (s *TrashService) GetCourseTrash(courseID string) (*models.CourseTrash, error) {
+	course, err := s.courseRepo.GetByIDWithDeleted(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	trash, err := s.repo.GetCourseTrash(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.DeletedAt.Valid {
+		trash.Course = course
+	}
+	return trash, nil
+}
+
+// Purge permanently deletes what has been in the trash longer than the
+// retention period, including the files of purged resources and attachments.
+// A file that cannot be deleted is only logged, as its row is gone already.
+func (s *TrashService) Purge() (*models.TrashPurge, error) {
+	purged, storageKeys, err := s.repo.Purge(time.Now().Add(-s.cfg.Retention))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range storageKeys {
+		err = s.storage.Delete(context.Background(), key)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Failed to delete purged file %s: %v", key, err)
+		}
+	}
+	purged.Files = int64(len(storageKeys))
+	return purged, nil
+}
+
+// StartPurge purges the trash now and then at every purge interval. A purge
+// interval that is not positive disables purging.
+func (s *TrashService) StartPurge() {
+	if s.cfg.PurgeInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.PurgeInterval)
+		defer ticker.Stop()
+		for {
+			purged, err := s.Purge()
+			if err != nil {
+				log.Printf("Failed to purge the trash: %v", err)
+			} else if *purged != (models.TrashPurge{}) {
+				log.Printf("Purged the trash: %d courses, %d sections, %d activities, %d assignments, %d enrollments, %d forum posts, %d files",
+					purged.Courses, purged.Sections, purged.Activities, purged.Assignments, purged.Enrollments, purged.ForumPosts, purged.Files)
+			}
+			<-ticker.C
+		}
+	}()
+}
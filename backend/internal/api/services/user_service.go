@@ -1,76 +1,728 @@
 package services
 
 import (
+	"cmp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/TheApostroff/skill-space/internal/api/models"
 	"github.com/TheApostroff/skill-space/internal/api/repositories"
+	"github.com/TheApostroff/skill-space/internal/events"
 )
 
 type ForumService struct {
-	repo *repositories.ForumRepository
+	repo       *repositories.ForumRepository
+	access     courseAccess
+	transactor *repositories.Transactor
+	bus        *events.Bus
 }
 
-func NewForumService(repo *repositories.ForumRepository) *ForumService {
-	return &ForumService{repo: repo}
+func NewForumService(
+	repo *repositories.ForumRepository,
+	courseRepo *repositories.CourseRepository,
+	sectionRepo *repositories.SectionRepository,
+	activityRepo *repositories.ActivityRepository,
+	enrollmentRepo *repositories.EnrollmentRepository,
+	transactor *repositories.Transactor,
+	bus *events.Bus,
+) *ForumService {
+	return &ForumService{
+		repo: repo,
+		access: courseAccess{
+			courseRepo:     courseRepo,
+			sectionRepo:    sectionRepo,
+			activityRepo:   activityRepo,
+			enrollmentRepo: enrollmentRepo,
+		},
+		transactor: transactor,
+		bus:        bus,
+	}
 }
 
-func (s *ForumService) GetForumPosts(forumID string) ([]models.ForumPost, error) {
-	return s.repo.GetByForumID(forumID)
+// GetForumPosts returns a page of the forum's threads, pinned ones first, each
+// with its whole tree of replies
+func (s *ForumService) GetForumPosts(forumID string, viewer *models.APIUser, q *models.ListQuery) ([]models.ForumPost, int64, error) {
+	activity, err := s.joinForum(forumID, viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	posts, total, err := s.repo.ListByForumID(forumID, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	threadIDs := make([]string, 0, len(posts))
+	for _, post := range posts {
+		threadIDs = append(threadIDs, post.ID)
+	}
+	replies, err := s.repo.GetThreadReplies(threadIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	children := childrenByParent(replies)
+	for i := range posts {
+		attachReplies(&posts[i], children)
+		if models.IsQAForum(activity) {
+			sortAnswers(&posts[i])
+		}
+	}
+	err = s.markVoted(posts, viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// GetUnansweredQuestions returns a page of the threads in the course's Q&A
+// forums that have no accepted answer yet
+func (s *ForumService) GetUnansweredQuestions(
+	courseID string,
+	viewer *models.APIUser,
+	q *models.ListQuery,
+) ([]models.ForumPost, int64, error) {
+	course, err := s.access.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = s.access.check(course, viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sections, err := s.access.sectionRepo.GetByCourseID(courseID)
+	if err != nil {
+		return nil, 0, err
+	}
+	forumIDs := []string{}
+	for _, section := range sections {
+		for _, activity := range section.Activities {
+			if models.IsQAForum(&activity) {
+				forumIDs = append(forumIDs, activity.ID)
+			}
+		}
+	}
+	if len(forumIDs) == 0 {
+		return []models.ForumPost{}, 0, nil
+	}
+
+	posts, total, err := s.repo.ListUnanswered(forumIDs, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	err = s.markVoted(posts, viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// GetPost returns a post with the replies below it
+func (s *ForumService) GetPost(postID string, viewer *models.APIUser) (*models.ForumPost, error) {
+	post, err := s.repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := s.joinForum(post.ForumID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := s.repo.GetThreadReplies([]string{post.ThreadID})
+	if err != nil {
+		return nil, err
+	}
+	attachReplies(post, childrenByParent(replies))
+	if post.ParentID == nil && models.IsQAForum(activity) {
+		sortAnswers(post)
+	}
+
+	posts := []models.ForumPost{*post}
+	err = s.markVoted(posts, viewer)
+	if err != nil {
+		return nil, err
+	}
+	return &posts[0], nil
 }
 
-func (s *ForumService) CreatePost(req *models.ForumPostCreateRequest) (*models.ForumPost, error) {
+// CreatePost starts a thread in a forum activity of a course the author takes part in
+func (s *ForumService) CreatePost(
+	req *models.ForumPostCreateRequest,
+	author *models.APIUser,
+	source *models.AuditSource,
+) (*models.ForumPost, error) {
+	activity, err := s.joinForum(req.ForumID, author)
+	if err != nil {
+		return nil, err
+	}
+	if activity.Type != models.ActivityTypeForum {
+		return nil, ErrNotAForum
+	}
+
+	id := GenerateID()
 	post := &models.ForumPost{
-		ID:         GenerateID(),
-		ForumID:    req.ForumID,
-		AuthorID:   req.AuthorID,
-		AuthorName: req.AuthorName,
-		Title:      req.Title,
-		Content:    req.Content,
-		IsPinned:   false,
-		Replies:    []models.ForumPost{},
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ID:             id,
+		ForumID:        req.ForumID,
+		ThreadID:       id,
+		AuthorID:       author.ID,
+		AuthorName:     author.Name,
+		Title:          req.Title,
+		Content:        req.Content,
+		IsPinned:       false,
+		Replies:        []models.ForumPost{},
+		LastActivityAt: time.Now(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
-	err := s.repo.Create(post)
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Forum.Create(post)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "forum_post", post.ID, nil, post)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	s.bus.Publish(models.EventForumPostCreated, *post)
 	return post, nil
 }
 
-func (s *ForumService) CreateReply(req *models.ForumReplyCreateRequest) (*models.ForumPost, error) {
+// CreateReply answers any post of a thread that is not locked
+func (s *ForumService) CreateReply(
+	req *models.ForumReplyCreateRequest,
+	author *models.APIUser,
+	source *models.AuditSource,
+) (*models.ForumPost, error) {
+	post, err := s.repo.GetByID(req.PostID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.joinForum(post.ForumID, author)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkUnlocked(post)
+	if err != nil {
+		return nil, err
+	}
+
 	reply := &models.ForumPost{
-		ID:         GenerateID(),
-		AuthorID:   req.AuthorID,
-		AuthorName: req.AuthorName,
-		Content:    req.Content,
-		ParentID:   &req.PostID,
-		IsPinned:   false,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
+		ID:             GenerateID(),
+		ForumID:        post.ForumID,
+		ThreadID:       post.ThreadID,
+		AuthorID:       author.ID,
+		AuthorName:     author.Name,
+		Content:        req.Content,
+		ParentID:       &req.PostID,
+		IsPinned:       false,
+		Replies:        []models.ForumPost{},
+		LastActivityAt: time.Now(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
-	err := s.repo.CreateReply(reply)
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Forum.CreateReply(reply)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "forum_post", reply.ID, nil, reply)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	s.bus.Publish(models.EventForumReplyCreated, models.ForumReplyCreatedEvent{Post: *post, Reply: *reply})
 	return reply, nil
 }
 
+// UpdatePost lets the author edit their post while its thread is not locked,
+// keeping the version it replaces
+func (s *ForumService) UpdatePost(
+	postID string,
+	req *models.ForumPostUpdateRequest,
+	editor *models.APIUser,
+	source *models.AuditSource,
+) (*models.ForumPost, error) {
+	post, err := s.repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.AuthorID != editor.ID {
+		return nil, ErrForbidden
+	}
+	err = s.checkUnlocked(post)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := snapshot(post)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	revision := &models.ForumPostRevision{
+		ID:       GenerateID(),
+		PostID:   post.ID,
+		Title:    post.Title,
+		Content:  post.Content,
+		EditedBy: editor.ID,
+		EditedAt: now,
+	}
+	if req.Title != nil && post.ParentID == nil {
+		post.Title = *req.Title
+	}
+	if req.Content != nil {
+		post.Content = *req.Content
+	}
+	post.EditedAt = &now
+	post.UpdatedAt = now
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Forum.Edit(post, revision)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "forum_post", post.ID, before, post)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPost(post.ID, editor)
+}
+
+// GetRevisions returns the earlier versions of a post, oldest first
+func (s *ForumService) GetRevisions(postID string, viewer *models.APIUser) ([]models.ForumPostRevision, error) {
+	post, err := s.repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.joinForum(post.ForumID, viewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetRevisions(postID)
+}
+
+// GetInstructorID returns the instructor of the course whose forum has the
+// post, which may be deleted
+func (s *ForumService) GetInstructorID(postID string) (string, error) {
+	post, err := s.repo.GetByIDWithDeleted(postID)
+	if err != nil {
+		return "", err
+	}
+	_, course, err := s.access.activityCourse(post.ForumID)
+	if err != nil {
+		return "", err
+	}
+	return course.InstructorID, nil
+}
+
+// SetPinned pins a thread to the top of its forum or unpins it
+func (s *ForumService) SetPinned(postID string, pinned bool, source *models.AuditSource) (*models.ForumPost, error) {
+	return s.setFlags(postID, func(post *models.ForumPost) {
+		post.IsPinned = pinned
+	}, source)
+}
+
+// SetLocked locks a thread against replies and edits or unlocks it
+func (s *ForumService) SetLocked(postID string, locked bool, source *models.AuditSource) (*models.ForumPost, error) {
+	return s.setFlags(postID, func(post *models.ForumPost) {
+		post.IsLocked = locked
+	}, source)
+}
+
+func (s *ForumService) setFlags(
+	postID string,
+	set func(post *models.ForumPost),
+	source *models.AuditSource,
+) (*models.ForumPost, error) {
+	post, err := s.repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.ParentID != nil {
+		return nil, ErrNotAThread
+	}
+
+	before, err := snapshot(post)
+	if err != nil {
+		return nil, err
+	}
+	set(post)
+	post.UpdatedAt = time.Now()
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Forum.SetFlags(post)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "forum_post", post.ID, before, post)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// AcceptAnswer marks a reply in a Q&A forum as the answer to its thread, in
+// place of any earlier one. The thread's author and the course's moderators
+// decide which reply answers it.
+func (s *ForumService) AcceptAnswer(replyID string, viewer *models.APIUser, source *models.AuditSource) (*models.ForumPost, error) {
+	reply, thread, err := s.getAnswerable(replyID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.setAcceptedAnswer(thread, &reply.ID, source)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPost(thread.ID, viewer)
+}
+
+// UnacceptAnswer takes back the reply's acceptance as its thread's answer
+func (s *ForumService) UnacceptAnswer(replyID string, viewer *models.APIUser, source *models.AuditSource) (*models.ForumPost, error) {
+	reply, thread, err := s.getAnswerable(replyID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	if thread.AcceptedAnswerID != nil && *thread.AcceptedAnswerID == reply.ID {
+		err = s.setAcceptedAnswer(thread, nil, source)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.GetPost(thread.ID, viewer)
+}
+
+// setAcceptedAnswer saves which reply, if any, answers the thread
+func (s *ForumService) setAcceptedAnswer(thread *models.ForumPost, answerID *string, source *models.AuditSource) error {
+	before, err := snapshot(thread)
+	if err != nil {
+		return err
+	}
+	thread.AcceptedAnswerID = answerID
+	thread.UpdatedAt = time.Now()
+	return s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Forum.SetAcceptedAnswer(thread)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "forum_post", thread.ID, before, thread)
+	})
+}
+
+// Vote upvotes a post on behalf of the viewer, who may not upvote their own
+// posts. Voting twice counts once.
+func (s *ForumService) Vote(postID string, viewer *models.APIUser, source *models.AuditSource) (*models.ForumPost, error) {
+	post, err := s.repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.joinForum(post.ForumID, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if post.AuthorID == viewer.ID {
+		return nil, ErrVoteOwnPost
+	}
+
+	before, err := snapshot(post)
+	if err != nil {
+		return nil, err
+	}
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		added, err := tx.Forum.Vote(postID, viewer.ID)
+		if err != nil || !added {
+			return err
+		}
+		post.VoteCount++
+		return recordAudit(tx, source, models.AuditUpdate, "forum_post", postID, before, post)
+	})
+	if err != nil {
+		return nil, err
+	}
+	post.Voted = true
+	return post, nil
+}
+
+// Unvote takes back the viewer's upvote of a post
+func (s *ForumService) Unvote(postID string, viewer *models.APIUser, source *models.AuditSource) (*models.ForumPost, error) {
+	post, err := s.repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.joinForum(post.ForumID, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := snapshot(post)
+	if err != nil {
+		return nil, err
+	}
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		removed, err := tx.Forum.Unvote(postID, viewer.ID)
+		if err != nil || !removed {
+			return err
+		}
+		post.VoteCount--
+		return recordAudit(tx, source, models.AuditUpdate, "forum_post", postID, before, post)
+	})
+	if err != nil {
+		return nil, err
+	}
+	post.Voted = false
+	return post, nil
+}
+
+// DeletePost soft-deletes the post together with the replies below it. Posts
+// are deleted by their author or moderated by the course's instructor. A
+// thread whose accepted answer goes with them is no longer answered.
+func (s *ForumService) DeletePost(postID string, viewer *models.APIUser, source *models.AuditSource) error {
+	post, err := s.getOwnOrModerated(postID, viewer)
+	if err != nil {
+		return err
+	}
+	return s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Forum.Delete(postID, viewer.ID)
+		if err != nil {
+			return err
+		}
+		err = recordAudit(tx, source, models.AuditDelete, "forum_post", postID, post, nil)
+		if err != nil || post.ParentID == nil {
+			return err
+		}
+
+		thread, err := tx.Forum.GetByID(post.ThreadID)
+		if err != nil {
+			return err
+		}
+		err = tx.Forum.ClearDeletedAnswer(thread.ID)
+		if err != nil {
+			return err
+		}
+		answered, err := tx.Forum.GetByID(thread.ID)
+		if err != nil {
+			return err
+		}
+		if thread.AcceptedAnswerID == nil || answered.AcceptedAnswerID != nil {
+			return nil
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "forum_post", thread.ID, thread, answered)
+	})
+}
+
+// RestorePost brings back the deleted post and the replies deleted with it.
+// A reply can only be restored while the post it answers is not deleted.
+// Moderators restore any post; authors only the posts they deleted
+// themselves, and only while the thread is not locked.
+func (s *ForumService) RestorePost(postID string, viewer *models.APIUser, source *models.AuditSource) (*models.ForumPost, error) {
+	post, err := s.repo.GetByIDWithDeleted(postID)
+	if err != nil {
+		return nil, err
+	}
+	_, course, err := s.access.activityCourse(post.ForumID)
+	if err != nil {
+		return nil, err
+	}
+	moderator := isModerator(course, viewer)
+	if !moderator && (post.DeletedBy == nil || *post.DeletedBy != viewer.ID) {
+		return nil, ErrForbidden
+	}
+
+	if post.ParentID != nil {
+		parent, err := s.repo.GetByIDWithDeleted(*post.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.DeletedAt.Valid {
+			return nil, ErrParentDeleted
+		}
+	}
+	if !moderator {
+		err = s.checkUnlocked(post)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var restored *models.ForumPost
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Forum.Restore(postID)
+		if err != nil {
+			return err
+		}
+		restored, err = tx.Forum.GetByIDWithDeleted(postID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "forum_post", postID, post, restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// joinForum returns the forum's activity if the viewer takes part in its course
+func (s *ForumService) joinForum(forumID string, viewer *models.APIUser) (*models.Activity, error) {
+	activity, course, err := s.access.activityCourse(forumID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.access.check(course, viewer)
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// getOwnOrModerated returns the post if the viewer wrote it or moderates its forum
+func (s *ForumService) getOwnOrModerated(postID string, viewer *models.APIUser) (*models.ForumPost, error) {
+	post, err := s.repo.GetByID(postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.AuthorID == viewer.ID {
+		return post, nil
+	}
+	_, course, err := s.access.activityCourse(post.ForumID)
+	if err != nil {
+		return nil, err
+	}
+	if !isModerator(course, viewer) {
+		return nil, ErrForbidden
+	}
+	return post, nil
+}
+
+// getAnswerable returns the reply and its thread if the thread is a question
+// in a Q&A forum that the viewer asked or moderates
+func (s *ForumService) getAnswerable(replyID string, viewer *models.APIUser) (*models.ForumPost, *models.ForumPost, error) {
+	reply, err := s.repo.GetByID(replyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reply.ParentID == nil {
+		return nil, nil, ErrNotAReply
+	}
+	thread, err := s.repo.GetByID(reply.ThreadID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	activity, course, err := s.access.activityCourse(thread.ForumID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !models.IsQAForum(activity) {
+		return nil, nil, ErrNotQAForum
+	}
+	if thread.AuthorID != viewer.ID && !isModerator(course, viewer) {
+		return nil, nil, ErrForbidden
+	}
+	return reply, thread, nil
+}
+
+// markVoted sets Voted on the posts, and the replies below them, that the
+// viewer has upvoted
+func (s *ForumService) markVoted(posts []models.ForumPost, viewer *models.APIUser) error {
+	var postIDs []string
+	var collect func(posts []models.ForumPost)
+	collect = func(posts []models.ForumPost) {
+		for _, post := range posts {
+			postIDs = append(postIDs, post.ID)
+			collect(post.Replies)
+		}
+	}
+	collect(posts)
+
+	voted, err := s.repo.GetVotedPostIDs(viewer.ID, postIDs)
+	if err != nil {
+		return err
+	}
+	var mark func(posts []models.ForumPost)
+	mark = func(posts []models.ForumPost) {
+		for i := range posts {
+			posts[i].Voted = voted[posts[i].ID]
+			mark(posts[i].Replies)
+		}
+	}
+	mark(posts)
+	return nil
+}
+
+// checkUnlocked fails if the post's thread is locked
+func (s *ForumService) checkUnlocked(post *models.ForumPost) error {
+	thread := post
+	if post.ThreadID != post.ID {
+		var err error
+		thread, err = s.repo.GetByID(post.ThreadID)
+		if err != nil {
+			return err
+		}
+	}
+	if thread.IsLocked {
+		return ErrThreadLocked
+	}
+	return nil
+}
+
+// childrenByParent groups replies by the post they answer
+func childrenByParent(replies []models.ForumPost) map[string][]models.ForumPost {
+	children := map[string][]models.ForumPost{}
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+	return children
+}
+
+// attachReplies fills in the replies below the post, at every depth. Replies
+// to a deleted post are left out with it.
+func attachReplies(post *models.ForumPost, children map[string][]models.ForumPost) {
+	post.Replies = children[post.ID]
+	if post.Replies == nil {
+		post.Replies = []models.ForumPost{}
+	}
+	for i := range post.Replies {
+		attachReplies(&post.Replies[i], children)
+	}
+}
+
+// sortAnswers orders the replies to a question with the accepted answer first,
+// then by votes, keeping the oldest first among equals
+func sortAnswers(thread *models.ForumPost) {
+	accepted := func(reply models.ForumPost) bool {
+		return thread.AcceptedAnswerID != nil && *thread.AcceptedAnswerID == reply.ID
+	}
+	slices.SortStableFunc(thread.Replies, func(a, b models.ForumPost) int {
+		if accepted(a) != accepted(b) {
+			if accepted(a) {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(b.VoteCount, a.VoteCount)
+	})
+}
+
 type UserService struct {
-	repo *repositories.UserRepository
+	repo       *repositories.UserRepository
+	transactor *repositories.Transactor
 }
 
-func NewUserService(repo *repositories.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo *repositories.UserRepository, transactor *repositories.Transactor) *UserService {
+	return &UserService{repo: repo, transactor: transactor}
 }
 
-func (s *UserService) GetAllUsers() ([]models.APIUser, error) {
-	return s.repo.GetAll()
+func (s *UserService) GetAllUsers(q *models.ListQuery) ([]models.APIUser, int64, error) {
+	return s.repo.List(q)
 }
 
 func (s *UserService) GetUserByID(id string) (*models.APIUser, error) {
@@ -81,17 +733,33 @@ func (s *UserService) GetUserByEmail(email string) (*models.APIUser, error) {
 	return s.repo.GetByEmail(email)
 }
 
-func (s *UserService) CreateUser(req *models.APIUser) (*models.APIUser, error) {
+func (s *UserService) CreateUser(req *models.UserCreateRequest, source *models.AuditSource) (*models.APIUser, error) {
 	user := &models.APIUser{
 		ID:        GenerateID(),
 		Name:      req.Name,
-		Email:     req.Email,
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		Role:      req.Role,
+		Avatar:    req.Avatar,
 		Profile:   req.Profile,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	err := s.repo.Create(user)
+	if req.Password != "" {
+		hash, err := HashPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = hash
+	}
+
+	err := s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Users.Create(user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditCreate, "user", user.ID, nil, user)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -99,11 +767,15 @@ func (s *UserService) CreateUser(req *models.APIUser) (*models.APIUser, error) {
 	return user, nil
 }
 
-func (s *UserService) UpdateUser(id string, req *models.UserUpdateRequest) (*models.APIUser, error) {
+func (s *UserService) UpdateUser(id string, req *models.UserUpdateRequest, source *models.AuditSource) (*models.APIUser, error) {
 	user, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	before, err := snapshot(user)
+	if err != nil {
+		return nil, err
+	}
 
 	if req.Name != nil {
 		user.Name = *req.Name
@@ -117,7 +789,44 @@ func (s *UserService) UpdateUser(id string, req *models.UserUpdateRequest) (*mod
 
 	user.UpdatedAt = time.Now()
 
-	err = s.repo.Update(user)
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Users.Update(user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "user", user.ID, before, user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) UpdateUserRole(
+	id string,
+	req *models.UserRoleUpdateRequest,
+	source *models.AuditSource,
+) (*models.APIUser, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	before, err := snapshot(user)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = req.Role
+	user.UpdatedAt = time.Now()
+
+	err = s.transactor.Do(func(tx *repositories.TxRepositories) error {
+		err := tx.Users.Update(user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, source, models.AuditUpdate, "user", user.ID, before, user)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1,25 +1,121 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Port   string `yaml:"port" env:"PORT" env-default:"8080"`
-	Host   string `yaml:"host" env:"HOST" env-default:"127.0.0.1"`
-	Server Server `yaml:"server"`
+	Port          string        `yaml:"port" env:"PORT" env-default:"8080"`
+	Host          string        `yaml:"host" env:"HOST" env-default:"127.0.0.1"`
+	Server        Server        `yaml:"server"`
+	Auth          Auth          `yaml:"auth"`
+	Sandbox       Sandbox       `yaml:"sandbox"`
+	Generator     Generator     `yaml:"generator"`
+	Storage       Storage       `yaml:"storage"`
+	Trash         Trash         `yaml:"trash"`
+	Notifications Notifications `yaml:"notifications"`
+	Similarity    Similarity    `yaml:"similarity"`
 }
 
 type Server struct {
-	Port     string `yaml:"port" env:"PORT" env-default:"8080"`
-	Host     string `yaml:"host" env:"DB_HOST" env-default:"localhost"`
-	User     string `yaml:"user" env:"DB_USER" env-default:"postgres"`
-	Password string `yaml:"password" env:"PASSWORD" env-default:"postgres"`
-	Database string `yaml:"database" env:"DATABASE" env-default:"postgres"`
+	Port           string `yaml:"port" env:"PORT" env-default:"8080"`
+	Host           string `yaml:"host" env:"DB_HOST" env-default:"localhost"`
+	User           string `yaml:"user" env:"DB_USER" env-default:"postgres"`
+	Password       string `yaml:"password" env:"PASSWORD" env-default:"postgres"`
+	Database       string `yaml:"database" env:"DATABASE" env-default:"postgres"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" env:"DB_MIGRATE_ON_START" env-default:"false"`
+}
+
+type Auth struct {
+	JWTSecret string        `yaml:"jwt_secret" env:"JWT_SECRET"`
+	TokenTTL  time.Duration `yaml:"token_ttl" env:"TOKEN_TTL" env-default:"24h"`
+	Issuer    string        `yaml:"issuer" env:"JWT_ISSUER" env-default:"skill-space"`
+}
+
+// placeholderJWTSecret is the secret older example configs shipped with
+const placeholderJWTSecret = "change-me"
+
+// Validate refuses a JWT secret that is empty or the example placeholder,
+// since anyone who knows the secret can sign a token for any user
+func (a Auth) Validate() error {
+	if a.JWTSecret == "" || a.JWTSecret == placeholderJWTSecret {
+		return errors.New("auth.jwt_secret (JWT_SECRET) must be set to a random secret")
+	}
+	return nil
+}
+
+type Sandbox struct {
+	TimeLimit      time.Duration `yaml:"time_limit" env:"SANDBOX_TIME_LIMIT" env-default:"5s"`
+	MemoryLimitMB  int           `yaml:"memory_limit_mb" env:"SANDBOX_MEMORY_LIMIT_MB" env-default:"256"`
+	MaxProcesses   int           `yaml:"max_processes" env:"SANDBOX_MAX_PROCESSES" env-default:"64"`
+	MaxFileSizeKB  int           `yaml:"max_file_size_kb" env:"SANDBOX_MAX_FILE_SIZE_KB" env-default:"1024"`
+	MaxOutputBytes int           `yaml:"max_output_bytes" env:"SANDBOX_MAX_OUTPUT_BYTES" env-default:"65536"`
+	IsolateNetwork bool          `yaml:"isolate_network" env:"SANDBOX_ISOLATE_NETWORK" env-default:"true"`
+	WorkDir        string        `yaml:"work_dir" env:"SANDBOX_WORK_DIR"`
+	ScratchSizeKB  int           `yaml:"scratch_size_kb" env:"SANDBOX_SCRATCH_SIZE_KB" env-default:"4096"`
+	MaxParallel    int           `yaml:"max_parallel" env:"SANDBOX_MAX_PARALLEL" env-default:"4"`
+	UIDBase        int           `yaml:"uid_base" env:"SANDBOX_UID_BASE" env-default:"60000"`
+	ReadOnlyPaths  []string      `yaml:"read_only_paths" env:"SANDBOX_READ_ONLY_PATHS" env-separator:"," env-default:"/usr,/bin,/lib,/lib32,/lib64,/libx32,/etc/alternatives,/etc/ld.so.cache"`
+}
+
+type Generator struct {
+	Provider        string        `yaml:"provider" env:"TASK_GENERATOR" env-default:"template"`
+	Endpoint        string        `yaml:"endpoint" env:"TASK_GENERATOR_ENDPOINT"`
+	APIKey          string        `yaml:"api_key" env:"TASK_GENERATOR_API_KEY"`
+	Timeout         time.Duration `yaml:"timeout" env:"TASK_GENERATOR_TIMEOUT" env-default:"30s"`
+	DefaultLanguage string        `yaml:"default_language" env:"TASK_GENERATOR_LANGUAGE" env-default:"python"`
+	RateLimit       int           `yaml:"rate_limit" env:"TASK_GENERATOR_RATE_LIMIT" env-default:"10"`
+	RateWindow      time.Duration `yaml:"rate_window" env:"TASK_GENERATOR_RATE_WINDOW" env-default:"1h"`
+}
+
+type Storage struct {
+	Provider       string        `yaml:"provider" env:"STORAGE_PROVIDER" env-default:"local"`
+	LocalDir       string        `yaml:"local_dir" env:"STORAGE_LOCAL_DIR" env-default:"./uploads"`
+	MaxUploadMB    int           `yaml:"max_upload_mb" env:"STORAGE_MAX_UPLOAD_MB" env-default:"20"`
+	AllowedTypes   []string      `yaml:"allowed_types" env:"STORAGE_ALLOWED_TYPES" env-separator:"," env-default:"application/pdf,application/zip,application/json,text/plain,text/markdown,text/csv,text/x-python,text/javascript,image/png,image/jpeg,image/gif,application/vnd.openxmlformats-officedocument.wordprocessingml.document,application/vnd.openxmlformats-officedocument.presentationml.presentation,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"`
+	URLSecret      string        `yaml:"url_secret" env:"STORAGE_URL_SECRET"`
+	DownloadURLTTL time.Duration `yaml:"download_url_ttl" env:"STORAGE_DOWNLOAD_URL_TTL" env-default:"15m"`
+	S3             S3            `yaml:"s3"`
+}
+
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+}
+
+type Similarity struct {
+	Workers   int `yaml:"workers" env:"SIMILARITY_WORKERS" env-default:"2"`
+	QueueSize int `yaml:"queue_size" env:"SIMILARITY_QUEUE_SIZE" env-default:"256"`
+}
+
+type Notifications struct {
+	DigestInterval   time.Duration `yaml:"digest_interval" env:"NOTIFICATION_DIGEST_INTERVAL" env-default:"1h"`
+	ReminderInterval time.Duration `yaml:"reminder_interval" env:"NOTIFICATION_REMINDER_INTERVAL" env-default:"15m"`
+	DueSoonWindow    time.Duration `yaml:"due_soon_window" env:"NOTIFICATION_DUE_SOON_WINDOW" env-default:"24h"`
+	SMTP             SMTP          `yaml:"smtp"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT" env-default:"587"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" env:"SMTP_FROM" env-default:"SkillSpace <no-reply@skillspace.local>"`
+}
+
+type S3 struct {
+	Endpoint     string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Region       string `yaml:"region" env:"S3_REGION" env-default:"us-east-1"`
+	Bucket       string `yaml:"bucket" env:"S3_BUCKET"`
+	AccessKey    string `yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey    string `yaml:"secret_key" env:"S3_SECRET_KEY"`
+	UsePathStyle bool   `yaml:"use_path_style" env:"S3_USE_PATH_STYLE" env-default:"true"`
 }
 
 func NewConfig() *Config {
@@ -31,7 +127,7 @@ func NewConfig() *Config {
 		log.Fatalf("Configuration error: %v", err)
 	}
 
-	log.Printf("Configuration: %+v", cfg)
+	log.Printf("Configuration loaded from %s", path)
 
 	return &cfg
 }
@@ -0,0 +1,57 @@
+// Package events carries domain events from the services that raise them to
+// the subscribers that react to them, so neither has to know the other.
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Event is something that happened, with the data subscribers need about it
+type Event struct {
+	Type       string
+	Payload    any
+	OccurredAt time.Time
+}
+
+// Handler reacts to an event
+type Handler func(event Event)
+
+// Bus hands published events to the handlers subscribed to their type
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers a handler for events of the given type
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish hands the event to every subscribed handler in the background, so
+// the publisher never waits for or fails because of a subscriber. A handler
+// that panics is logged and does not affect the others.
+func (b *Bus) Publish(eventType string, payload any) {
+	b.mu.RLock()
+	handlers := b.handlers[eventType]
+	b.mu.RUnlock()
+
+	event := Event{Type: eventType, Payload: payload, OccurredAt: time.Now()}
+	for _, handler := range handlers {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Handler of %s event panicked: %v", eventType, r)
+				}
+			}()
+			handler(event)
+		}()
+	}
+}
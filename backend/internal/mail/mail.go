@@ -0,0 +1,73 @@
+// Package mail sends plain-text email through an SMTP server.
+package mail
+
+import (
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/config"
+)
+
+// Sender delivers email
+type Sender interface {
+	Send(to string, subject string, body string) error
+}
+
+// New returns an SMTP sender for the configuration, or nil if no SMTP host is
+// configured and email is turned off
+func New(cfg *config.SMTP) (Sender, error) {
+	if cfg.Host == "" {
+		return nil, nil
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+	return &SMTPSender{cfg: cfg, from: from}, nil
+}
+
+// SMTPSender sends email through an SMTP server, using STARTTLS when the
+// server offers it and authenticating when a username is configured
+type SMTPSender struct {
+	cfg  *config.SMTP
+	from *mail.Address
+}
+
+func (s *SMTPSender) Send(to string, subject string, body string) error {
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address %q: %w", to, err)
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	addr := s.cfg.Host + ":" + strconv.Itoa(s.cfg.Port)
+	return smtp.SendMail(addr, auth, s.from.Address, []string{recipient.Address}, message(s.from, recipient, subject, body))
+}
+
+// message formats a plain-text email. Line breaks are removed from the
+// subject so it cannot inject headers.
+func message(from *mail.Address, to *mail.Address, subject string, body string) []byte {
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(body)
+	return []byte(b.String())
+}
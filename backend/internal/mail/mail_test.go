@@ -0,0 +1,233 @@
+package mail
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/TheApostroff/skill-space/internal/config"
+)
+
+// fakeSMTP is an SMTP server that accepts one session and records it
+type fakeSMTP struct {
+	listener net.Listener
+	// refuse is the reply to the end of the message data, for a server that
+	// refuses the message; empty accepts it
+	refuse string
+	done   chan session
+}
+
+// session is what a client sent in one SMTP session
+type session struct {
+	auth       string
+	from       string
+	recipients []string
+	data       string
+	err        error
+}
+
+func newFakeSMTP(t *testing.T, refuse string) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeSMTP{listener: listener, refuse: refuse, done: make(chan session, 1)}
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTP) config(username string) *config.SMTP {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return &config.SMTP{
+		Host:     "127.0.0.1",
+		Port:     addr.Port,
+		Username: username,
+		Password: "secret",
+		From:     "SkillSpace <no-reply@skillspace.local>",
+	}
+}
+
+func (s *fakeSMTP) serve() {
+	var recorded session
+	defer func() { s.done <- recorded }()
+
+	conn, err := s.listener.Accept()
+	if err != nil {
+		recorded.err = err
+		return
+	}
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+
+	reply := func(line string) {
+		if err := text.PrintfLine("%s", line); err != nil && recorded.err == nil {
+			recorded.err = err
+		}
+	}
+	reply("220 fake ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				recorded.err = err
+			}
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-fake")
+			reply("250-8BITMIME")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			recorded.auth = arg
+			reply("235 2.7.0 authenticated")
+		case "MAIL":
+			recorded.from = arg
+			reply("250 2.1.0 ok")
+		case "RCPT":
+			recorded.recipients = append(recorded.recipients, arg)
+			reply("250 2.1.5 ok")
+		case "DATA":
+			reply("354 go ahead")
+			// The data is read raw, so its line endings can be checked
+			var data strings.Builder
+			for {
+				line, err := text.R.ReadString('\n')
+				if err != nil {
+					recorded.err = err
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			recorded.data = data.String()
+			if s.refuse != "" {
+				reply(s.refuse)
+			} else {
+				reply("250 2.0.0 queued")
+			}
+		case "QUIT":
+			reply("221 2.0.0 bye")
+			return
+		default:
+			reply("502 5.5.2 not implemented")
+		}
+	}
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	server := newFakeSMTP(t, "")
+	sender, err := New(server.config(""))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	err = sender.Send("Ada Lovelace <ada@example.com>", "Grade posted: Übung 1\r\nBcc: eve@example.com", "Your work was graded.\nWell done!")
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	got := <-server.done
+	if got.err != nil {
+		t.Fatalf("server: %v", got.err)
+	}
+
+	if got.auth != "" {
+		t.Errorf("auth = %q, want none without a username", got.auth)
+	}
+	if !strings.HasPrefix(got.from, "FROM:<no-reply@skillspace.local>") {
+		t.Errorf("MAIL %s, want the sender's bare address", got.from)
+	}
+	if len(got.recipients) != 1 || got.recipients[0] != "TO:<ada@example.com>" {
+		t.Errorf("RCPT %v, want only the recipient's bare address", got.recipients)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	headers := map[string]string{
+		"From":         `"SkillSpace" <no-reply@skillspace.local>`,
+		"To":           `"Ada Lovelace" <ada@example.com>`,
+		"Content-Type": "text/plain; charset=UTF-8",
+		"Bcc":          "",
+	}
+	for name, want := range headers {
+		if got := msg.Header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if want := "Grade posted: Übung 1  Bcc: eve@example.com"; subject != want {
+		t.Errorf("Subject = %q, want %q", subject, want)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	if want := "Your work was graded.\r\nWell done!\r\n"; string(body) != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+}
+
+func TestSMTPSenderAuth(t *testing.T) {
+	server := newFakeSMTP(t, "")
+	sender, err := New(server.config("mailer"))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	err = sender.Send("ada@example.com", "Hello", "Hi")
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	got := <-server.done
+	if got.err != nil {
+		t.Fatalf("server: %v", got.err)
+	}
+
+	want := "PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00secret"))
+	if got.auth != want {
+		t.Errorf("AUTH %s, want %s", got.auth, want)
+	}
+}
+
+func TestSMTPSenderRefused(t *testing.T) {
+	server := newFakeSMTP(t, "554 5.7.1 message refused")
+	sender, err := New(server.config(""))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	err = sender.Send("ada@example.com", "Hello", "Hi")
+	var refused *textproto.Error
+	if !errors.As(err, &refused) || refused.Code != 554 {
+		t.Fatalf("Send = %v, want the server's 554", err)
+	}
+	<-server.done
+}
+
+func TestSMTPSenderInvalidRecipient(t *testing.T) {
+	sender, err := New(&config.SMTP{Host: "127.0.0.1", Port: 1, From: "no-reply@skillspace.local"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	err = sender.Send("not an address", "Hello", "Hi")
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient address") {
+		t.Errorf("Send = %v, want an invalid recipient error", err)
+	}
+}
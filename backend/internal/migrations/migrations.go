@@ -0,0 +1,272 @@
+// Package migrations versions the database schema with numbered SQL
+// migrations that are embedded in the binary.
+//
+// Every migration is a pair of files in sql/ named
+// <version>_<name>.up.sql and <version>_<name>.down.sql. Applied versions are
+// recorded in the schema_migrations table, and each migration runs in its own
+// transaction together with that record.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+var ErrUnknownVersion = errors.New("the database has a migration this build does not know")
+
+// lockName names the advisory lock migration runs hold
+const lockName = "schema_migrations"
+
+// Migration is one step of the schema's history
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied, if it was
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt"`
+}
+
+// SchemaMigration records an applied migration
+type SchemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// Migrator applies and reverts the embedded migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the embedded migrations
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in order and returns those it applied
+func (m *Migrator) Up() ([]Migration, error) {
+	done := []Migration{}
+	err := m.locked(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the given number of most recently applied migrations and
+// returns those it reverted
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	done := []Migration{}
+	err := m.locked(func(db *gorm.DB) error {
+		applied, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every migration with the time it was applied
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that are not applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// locked runs fn on a connection that holds a database-wide advisory lock, so
+// that servers starting together and the migrate command run migrations one
+// after the other. The migrations fn applies are read under the lock.
+func (m *Migrator) locked(fn func(db *gorm.DB) error) error {
+	return m.db.Connection(func(conn *gorm.DB) error {
+		err := conn.Exec("SELECT pg_advisory_lock(hashtext(?))", lockName).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock schema_migrations: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(hashtext(?))", lockName)
+		return fn(conn)
+	})
+}
+
+// applied returns the applied migrations by version, creating the
+// schema_migrations table on first use. A version missing from this build
+// means the database was migrated by a newer one, which is an error.
+func (m *Migrator) applied(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var records []SchemaMigration
+	err = db.Order("version").Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[int64]bool{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+	}
+	applied := map[int64]SchemaMigration{}
+	for _, record := range records {
+		if !known[record.Version] {
+			return nil, fmt.Errorf("%w: %d_%s", ErrUnknownVersion, record.Version, record.Name)
+		}
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// load reads the migrations from the file system, ordered by version. Every
+// version needs both an up and a down file.
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range names {
+		base := path.Base(file)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", base)
+		}
+
+		prefix, name, ok := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>.%s.sql", base, direction)
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name:  "no migrations",
+			files: fstest.MapFS{},
+			want:  []Migration{},
+		},
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"sql/0010_tenth.up.sql":    {Data: []byte("up 10")},
+				"sql/0010_tenth.down.sql":  {Data: []byte("down 10")},
+				"sql/0002_second.up.sql":   {Data: []byte("up 2")},
+				"sql/0002_second.down.sql": {Data: []byte("down 2")},
+			},
+			want: []Migration{
+				{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+				{Version: 10, Name: "tenth", Up: "up 10", Down: "down 10"},
+			},
+		},
+		{
+			name: "names may contain underscores",
+			files: fstest.MapFS{
+				"sql/0001_add_users.up.sql":   {Data: []byte("up")},
+				"sql/0001_add_users.down.sql": {Data: []byte("down")},
+			},
+			want: []Migration{{Version: 1, Name: "add_users", Up: "up", Down: "down"}},
+		},
+		{
+			name: "missing down file",
+			files: fstest.MapFS{
+				"sql/0001_init.up.sql": {Data: []byte("up")},
+			},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "empty up file",
+			files: fstest.MapFS{
+				"sql/0001_init.up.sql":   {Data: []byte("")},
+				"sql/0001_init.down.sql": {Data: []byte("down")},
+			},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "neither up nor down",
+			files: fstest.MapFS{
+				"sql/0001_init.sql": {Data: []byte("up")},
+			},
+			wantErr: "neither .up.sql nor .down.sql",
+		},
+		{
+			name: "version is not a number",
+			files: fstest.MapFS{
+				"sql/first_init.up.sql": {Data: []byte("up")},
+			},
+			wantErr: "must be named <version>_<name>.up.sql",
+		},
+		{
+			name: "version is zero",
+			files: fstest.MapFS{
+				"sql/0000_init.up.sql": {Data: []byte("up")},
+			},
+			wantErr: "must be named <version>_<name>.up.sql",
+		},
+		{
+			name: "no name",
+			files: fstest.MapFS{
+				"sql/0001.down.sql": {Data: []byte("down")},
+			},
+			wantErr: "must be named <version>_<name>.down.sql",
+		},
+		{
+			name: "two names for one version",
+			files: fstest.MapFS{
+				"sql/0001_init.up.sql":      {Data: []byte("up")},
+				"sql/0001_initial.down.sql": {Data: []byte("down")},
+			},
+			wantErr: "has two names",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d migrations, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("migration %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %d_%s, want version %d: versions must have no gaps", migration.Version, migration.Name, i+1)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS api_users;
+DROP TABLE IF EXISTS forum_posts;
+DROP TABLE IF EXISTS enrollments;
+DROP TABLE IF EXISTS grading_schemes;
+DROP TABLE IF EXISTS grades;
+DROP TABLE IF EXISTS test_cases;
+DROP TABLE IF EXISTS similarity_matches;
+DROP TABLE IF EXISTS similarity_checks;
+DROP TABLE IF EXISTS generative_task_submissions;
+DROP TABLE IF EXISTS generative_tasks;
+DROP TABLE IF EXISTS submissions;
+DROP TABLE IF EXISTS attachments;
+DROP TABLE IF EXISTS quiz_attempts;
+DROP TABLE IF EXISTS quizzes;
+DROP TABLE IF EXISTS questions;
+DROP TABLE IF EXISTS peer_reviews;
+DROP TABLE IF EXISTS rubric_assessments;
+DROP TABLE IF EXISTS rubrics;
+DROP TABLE IF EXISTS assignment_extensions;
+DROP TABLE IF EXISTS assignments;
+DROP TABLE IF EXISTS activity_completions;
+DROP TABLE IF EXISTS activities;
+DROP TABLE IF EXISTS sections;
+DROP TABLE IF EXISTS resources;
+DROP TABLE IF EXISTS courses;
@@ -0,0 +1,714 @@
+-- The schema as AutoMigrate last created it. Everything is created only if it
+-- does not exist, so databases AutoMigrate managed adopt the migrations. A
+-- table an older AutoMigrate created may lack columns added since, so each
+-- table's columns are added as well where they are missing.
+
+CREATE TABLE IF NOT EXISTS courses (
+    id text PRIMARY KEY,
+    title text,
+    description text,
+    instructor_id text,
+    category text,
+    level text,
+    duration text,
+    enrolled_students text,
+    max_students bigint,
+    start_date text,
+    end_date text,
+    status text,
+    syllabus text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+ALTER TABLE courses
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS instructor_id text,
+    ADD COLUMN IF NOT EXISTS category text,
+    ADD COLUMN IF NOT EXISTS level text,
+    ADD COLUMN IF NOT EXISTS duration text,
+    ADD COLUMN IF NOT EXISTS enrolled_students text,
+    ADD COLUMN IF NOT EXISTS max_students bigint,
+    ADD COLUMN IF NOT EXISTS start_date text,
+    ADD COLUMN IF NOT EXISTS end_date text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS syllabus text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_courses_deleted_at ON courses (deleted_at);
+
+CREATE TABLE IF NOT EXISTS resources (
+    id text PRIMARY KEY,
+    course_id text,
+    title text,
+    type text,
+    url text,
+    description text,
+    file_name text,
+    storage_key text,
+    size bigint,
+    mime_type text,
+    checksum text,
+    uploaded_by text,
+    uploaded_at timestamptz
+);
+ALTER TABLE resources
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS url text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS file_name text,
+    ADD COLUMN IF NOT EXISTS storage_key text,
+    ADD COLUMN IF NOT EXISTS size bigint,
+    ADD COLUMN IF NOT EXISTS mime_type text,
+    ADD COLUMN IF NOT EXISTS checksum text,
+    ADD COLUMN IF NOT EXISTS uploaded_by text,
+    ADD COLUMN IF NOT EXISTS uploaded_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS sections (
+    id text PRIMARY KEY,
+    course_id text,
+    title text,
+    description text,
+    "order" bigint,
+    visible boolean,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+ALTER TABLE sections
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS "order" bigint,
+    ADD COLUMN IF NOT EXISTS visible boolean,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_sections_deleted_at ON sections (deleted_at);
+
+CREATE TABLE IF NOT EXISTS activities (
+    id text PRIMARY KEY,
+    section_id text,
+    title text,
+    description text,
+    type text,
+    "order" bigint,
+    visible boolean,
+    due_date text,
+    available_from text,
+    available_until text,
+    metadata text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+ALTER TABLE activities
+    ADD COLUMN IF NOT EXISTS section_id text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS "order" bigint,
+    ADD COLUMN IF NOT EXISTS visible boolean,
+    ADD COLUMN IF NOT EXISTS due_date text,
+    ADD COLUMN IF NOT EXISTS available_from text,
+    ADD COLUMN IF NOT EXISTS available_until text,
+    ADD COLUMN IF NOT EXISTS metadata text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_activities_deleted_at ON activities (deleted_at);
+
+CREATE TABLE IF NOT EXISTS activity_completions (
+    id text PRIMARY KEY,
+    activity_id text,
+    student_id text,
+    course_id text,
+    completed_at timestamptz
+);
+ALTER TABLE activity_completions
+    ADD COLUMN IF NOT EXISTS activity_id text,
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS completed_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_activity_completions_course_id ON activity_completions (course_id);
+CREATE INDEX IF NOT EXISTS idx_activity_completions_student_id ON activity_completions (student_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_activity_completion ON activity_completions (activity_id,student_id);
+
+CREATE TABLE IF NOT EXISTS assignments (
+    id text PRIMARY KEY,
+    title text,
+    description text,
+    course_id text,
+    instructor_id text,
+    type text,
+    total_points bigint,
+    due_date timestamptz,
+    late_policy text,
+    late_penalty_percent decimal,
+    grace_period_minutes bigint,
+    max_late_days bigint,
+    max_attempts bigint,
+    attempt_policy text,
+    peer_review_count bigint,
+    peer_review_weight decimal,
+    peer_review_due_date timestamptz,
+    status text,
+    instructions text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+ALTER TABLE assignments
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS instructor_id text,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS total_points bigint,
+    ADD COLUMN IF NOT EXISTS due_date timestamptz,
+    ADD COLUMN IF NOT EXISTS late_policy text,
+    ADD COLUMN IF NOT EXISTS late_penalty_percent decimal,
+    ADD COLUMN IF NOT EXISTS grace_period_minutes bigint,
+    ADD COLUMN IF NOT EXISTS max_late_days bigint,
+    ADD COLUMN IF NOT EXISTS max_attempts bigint,
+    ADD COLUMN IF NOT EXISTS attempt_policy text,
+    ADD COLUMN IF NOT EXISTS peer_review_count bigint,
+    ADD COLUMN IF NOT EXISTS peer_review_weight decimal,
+    ADD COLUMN IF NOT EXISTS peer_review_due_date timestamptz,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS instructions text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_assignments_deleted_at ON assignments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS assignment_extensions (
+    id text PRIMARY KEY,
+    assignment_id text,
+    student_id text,
+    due_date timestamptz,
+    reason text,
+    granted_by text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE assignment_extensions
+    ADD COLUMN IF NOT EXISTS assignment_id text,
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS due_date timestamptz,
+    ADD COLUMN IF NOT EXISTS reason text,
+    ADD COLUMN IF NOT EXISTS granted_by text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_assignment_extension ON assignment_extensions (assignment_id,student_id);
+
+CREATE TABLE IF NOT EXISTS rubrics (
+    id text PRIMARY KEY,
+    assignment_id text,
+    title text,
+    criteria text,
+    max_points decimal,
+    created_by text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE rubrics
+    ADD COLUMN IF NOT EXISTS assignment_id text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS criteria text,
+    ADD COLUMN IF NOT EXISTS max_points decimal,
+    ADD COLUMN IF NOT EXISTS created_by text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rubrics_assignment_id ON rubrics (assignment_id);
+
+CREATE TABLE IF NOT EXISTS rubric_assessments (
+    id text PRIMARY KEY,
+    submission_id text,
+    rubric_id text,
+    scores text,
+    points_earned decimal,
+    points_possible decimal,
+    graded_by text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE rubric_assessments
+    ADD COLUMN IF NOT EXISTS submission_id text,
+    ADD COLUMN IF NOT EXISTS rubric_id text,
+    ADD COLUMN IF NOT EXISTS scores text,
+    ADD COLUMN IF NOT EXISTS points_earned decimal,
+    ADD COLUMN IF NOT EXISTS points_possible decimal,
+    ADD COLUMN IF NOT EXISTS graded_by text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_rubric_assessments_submission_id ON rubric_assessments (submission_id);
+
+CREATE TABLE IF NOT EXISTS peer_reviews (
+    id text PRIMARY KEY,
+    assignment_id text,
+    submission_id text,
+    reviewer_id text,
+    status text,
+    scores text,
+    score decimal,
+    comments text,
+    assigned_at timestamptz,
+    completed_at timestamptz
+);
+ALTER TABLE peer_reviews
+    ADD COLUMN IF NOT EXISTS assignment_id text,
+    ADD COLUMN IF NOT EXISTS submission_id text,
+    ADD COLUMN IF NOT EXISTS reviewer_id text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS scores text,
+    ADD COLUMN IF NOT EXISTS score decimal,
+    ADD COLUMN IF NOT EXISTS comments text,
+    ADD COLUMN IF NOT EXISTS assigned_at timestamptz,
+    ADD COLUMN IF NOT EXISTS completed_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_peer_reviews_reviewer_id ON peer_reviews (reviewer_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_peer_review ON peer_reviews (submission_id,reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_peer_reviews_assignment_id ON peer_reviews (assignment_id);
+
+CREATE TABLE IF NOT EXISTS questions (
+    id text PRIMARY KEY,
+    course_id text,
+    type text,
+    prompt text,
+    options text,
+    match_targets text,
+    answer text,
+    accepted_answers text,
+    tolerance decimal,
+    points decimal,
+    explanation text,
+    tags text,
+    created_by text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS prompt text,
+    ADD COLUMN IF NOT EXISTS options text,
+    ADD COLUMN IF NOT EXISTS match_targets text,
+    ADD COLUMN IF NOT EXISTS answer text,
+    ADD COLUMN IF NOT EXISTS accepted_answers text,
+    ADD COLUMN IF NOT EXISTS tolerance decimal,
+    ADD COLUMN IF NOT EXISTS points decimal,
+    ADD COLUMN IF NOT EXISTS explanation text,
+    ADD COLUMN IF NOT EXISTS tags text,
+    ADD COLUMN IF NOT EXISTS created_by text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_questions_course_id ON questions (course_id);
+
+CREATE TABLE IF NOT EXISTS quizzes (
+    id text PRIMARY KEY,
+    course_id text,
+    assignment_id text,
+    activity_id text,
+    title text,
+    description text,
+    question_ids text,
+    random_count bigint,
+    random_tags text,
+    shuffle_questions boolean,
+    shuffle_options boolean,
+    time_limit_minutes bigint,
+    show_correct_answers boolean,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE quizzes
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS assignment_id text,
+    ADD COLUMN IF NOT EXISTS activity_id text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS question_ids text,
+    ADD COLUMN IF NOT EXISTS random_count bigint,
+    ADD COLUMN IF NOT EXISTS random_tags text,
+    ADD COLUMN IF NOT EXISTS shuffle_questions boolean,
+    ADD COLUMN IF NOT EXISTS shuffle_options boolean,
+    ADD COLUMN IF NOT EXISTS time_limit_minutes bigint,
+    ADD COLUMN IF NOT EXISTS show_correct_answers boolean,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_quizzes_course_id ON quizzes (course_id);
+
+CREATE TABLE IF NOT EXISTS quiz_attempts (
+    id text PRIMARY KEY,
+    quiz_id text,
+    student_id text,
+    status text,
+    items text,
+    responses text,
+    results text,
+    points_earned decimal,
+    points_possible decimal,
+    score bigint,
+    submission_id text,
+    started_at timestamptz,
+    expires_at timestamptz,
+    submitted_at timestamptz
+);
+ALTER TABLE quiz_attempts
+    ADD COLUMN IF NOT EXISTS quiz_id text,
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS items text,
+    ADD COLUMN IF NOT EXISTS responses text,
+    ADD COLUMN IF NOT EXISTS results text,
+    ADD COLUMN IF NOT EXISTS points_earned decimal,
+    ADD COLUMN IF NOT EXISTS points_possible decimal,
+    ADD COLUMN IF NOT EXISTS score bigint,
+    ADD COLUMN IF NOT EXISTS submission_id text,
+    ADD COLUMN IF NOT EXISTS started_at timestamptz,
+    ADD COLUMN IF NOT EXISTS expires_at timestamptz,
+    ADD COLUMN IF NOT EXISTS submitted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_quiz_attempts_student_id ON quiz_attempts (student_id);
+CREATE INDEX IF NOT EXISTS idx_quiz_attempts_quiz_id ON quiz_attempts (quiz_id);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id text PRIMARY KEY,
+    assignment_id text,
+    submission_id text,
+    title text,
+    type text,
+    url text,
+    description text,
+    file_name text,
+    storage_key text,
+    size bigint,
+    mime_type text,
+    checksum text,
+    uploaded_by text,
+    uploaded_at timestamptz
+);
+ALTER TABLE attachments
+    ADD COLUMN IF NOT EXISTS assignment_id text,
+    ADD COLUMN IF NOT EXISTS submission_id text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS url text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS file_name text,
+    ADD COLUMN IF NOT EXISTS storage_key text,
+    ADD COLUMN IF NOT EXISTS size bigint,
+    ADD COLUMN IF NOT EXISTS mime_type text,
+    ADD COLUMN IF NOT EXISTS checksum text,
+    ADD COLUMN IF NOT EXISTS uploaded_by text,
+    ADD COLUMN IF NOT EXISTS uploaded_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS submissions (
+    id text PRIMARY KEY,
+    assignment_id text,
+    student_id text,
+    attempt bigint,
+    content text,
+    score bigint,
+    instructor_score bigint,
+    peer_score decimal,
+    feedback text,
+    status text,
+    late_days bigint,
+    late_penalty decimal,
+    submitted_at timestamptz,
+    graded_at timestamptz
+);
+ALTER TABLE submissions
+    ADD COLUMN IF NOT EXISTS assignment_id text,
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS attempt bigint,
+    ADD COLUMN IF NOT EXISTS content text,
+    ADD COLUMN IF NOT EXISTS score bigint,
+    ADD COLUMN IF NOT EXISTS instructor_score bigint,
+    ADD COLUMN IF NOT EXISTS peer_score decimal,
+    ADD COLUMN IF NOT EXISTS feedback text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS late_days bigint,
+    ADD COLUMN IF NOT EXISTS late_penalty decimal,
+    ADD COLUMN IF NOT EXISTS submitted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS graded_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS generative_tasks (
+    id text PRIMARY KEY,
+    activity_id text,
+    student_id text,
+    title text,
+    description text,
+    requirements text,
+    difficulty text,
+    language text,
+    estimated_time bigint,
+    hints text,
+    tests text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE generative_tasks
+    ADD COLUMN IF NOT EXISTS activity_id text,
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS description text,
+    ADD COLUMN IF NOT EXISTS requirements text,
+    ADD COLUMN IF NOT EXISTS difficulty text,
+    ADD COLUMN IF NOT EXISTS language text,
+    ADD COLUMN IF NOT EXISTS estimated_time bigint,
+    ADD COLUMN IF NOT EXISTS hints text,
+    ADD COLUMN IF NOT EXISTS tests text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS generative_task_submissions (
+    id text PRIMARY KEY,
+    task_id text,
+    student_id text,
+    code text,
+    score bigint,
+    feedback text,
+    created_at timestamptz
+);
+ALTER TABLE generative_task_submissions
+    ADD COLUMN IF NOT EXISTS task_id text,
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS code text,
+    ADD COLUMN IF NOT EXISTS score bigint,
+    ADD COLUMN IF NOT EXISTS feedback text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS similarity_checks (
+    submission_id text PRIMARY KEY,
+    kind text,
+    status text,
+    error text,
+    created_at timestamptz,
+    checked_at timestamptz
+);
+ALTER TABLE similarity_checks
+    ADD COLUMN IF NOT EXISTS kind text,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS error text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS checked_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS similarity_matches (
+    id text PRIMARY KEY,
+    kind text,
+    submission_id text,
+    student_id text,
+    matched_submission_id text,
+    matched_student_id text,
+    score decimal,
+    matched_score decimal,
+    spans text,
+    matched_spans text,
+    created_at timestamptz
+);
+ALTER TABLE similarity_matches
+    ADD COLUMN IF NOT EXISTS kind text,
+    ADD COLUMN IF NOT EXISTS submission_id text,
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS matched_submission_id text,
+    ADD COLUMN IF NOT EXISTS matched_student_id text,
+    ADD COLUMN IF NOT EXISTS score decimal,
+    ADD COLUMN IF NOT EXISTS matched_score decimal,
+    ADD COLUMN IF NOT EXISTS spans text,
+    ADD COLUMN IF NOT EXISTS matched_spans text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_similarity_matches_matched_submission_id ON similarity_matches (matched_submission_id);
+CREATE INDEX IF NOT EXISTS idx_similarity_matches_submission_id ON similarity_matches (submission_id);
+
+CREATE TABLE IF NOT EXISTS test_cases (
+    id text PRIMARY KEY,
+    submission_id text,
+    name text,
+    passed boolean,
+    stdout text,
+    stderr text,
+    exit_code bigint,
+    timed_out boolean,
+    duration_ms bigint
+);
+ALTER TABLE test_cases
+    ADD COLUMN IF NOT EXISTS submission_id text,
+    ADD COLUMN IF NOT EXISTS name text,
+    ADD COLUMN IF NOT EXISTS passed boolean,
+    ADD COLUMN IF NOT EXISTS stdout text,
+    ADD COLUMN IF NOT EXISTS stderr text,
+    ADD COLUMN IF NOT EXISTS exit_code bigint,
+    ADD COLUMN IF NOT EXISTS timed_out boolean,
+    ADD COLUMN IF NOT EXISTS duration_ms bigint;
+
+CREATE TABLE IF NOT EXISTS grades (
+    id text PRIMARY KEY,
+    student_id text,
+    student_name text,
+    assignment_id text,
+    assignment_title text,
+    source text,
+    course_id text,
+    course_name text,
+    score bigint,
+    total_points bigint,
+    letter_grade text,
+    feedback text,
+    graded_by text,
+    graded_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE grades
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS student_name text,
+    ADD COLUMN IF NOT EXISTS assignment_id text,
+    ADD COLUMN IF NOT EXISTS assignment_title text,
+    ADD COLUMN IF NOT EXISTS source text,
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS course_name text,
+    ADD COLUMN IF NOT EXISTS score bigint,
+    ADD COLUMN IF NOT EXISTS total_points bigint,
+    ADD COLUMN IF NOT EXISTS letter_grade text,
+    ADD COLUMN IF NOT EXISTS feedback text,
+    ADD COLUMN IF NOT EXISTS graded_by text,
+    ADD COLUMN IF NOT EXISTS graded_at timestamptz,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS grading_schemes (
+    course_id text PRIMARY KEY,
+    type text,
+    cutoffs text,
+    pass_threshold decimal,
+    weights text,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE grading_schemes
+    ADD COLUMN IF NOT EXISTS type text,
+    ADD COLUMN IF NOT EXISTS cutoffs text,
+    ADD COLUMN IF NOT EXISTS pass_threshold decimal,
+    ADD COLUMN IF NOT EXISTS weights text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS enrollments (
+    id text PRIMARY KEY,
+    student_id text,
+    course_id text,
+    enrolled_at timestamptz,
+    status text,
+    progress bigint,
+    last_accessed timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+ALTER TABLE enrollments
+    ADD COLUMN IF NOT EXISTS student_id text,
+    ADD COLUMN IF NOT EXISTS course_id text,
+    ADD COLUMN IF NOT EXISTS enrolled_at timestamptz,
+    ADD COLUMN IF NOT EXISTS status text,
+    ADD COLUMN IF NOT EXISTS progress bigint,
+    ADD COLUMN IF NOT EXISTS last_accessed timestamptz,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_enrollments_deleted_at ON enrollments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS forum_posts (
+    id text PRIMARY KEY,
+    forum_id text,
+    author_id text,
+    author_name text,
+    title text,
+    content text,
+    is_pinned boolean,
+    parent_id text,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+ALTER TABLE forum_posts
+    ADD COLUMN IF NOT EXISTS forum_id text,
+    ADD COLUMN IF NOT EXISTS author_id text,
+    ADD COLUMN IF NOT EXISTS author_name text,
+    ADD COLUMN IF NOT EXISTS title text,
+    ADD COLUMN IF NOT EXISTS content text,
+    ADD COLUMN IF NOT EXISTS is_pinned boolean,
+    ADD COLUMN IF NOT EXISTS parent_id text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_forum_posts_deleted_at ON forum_posts (deleted_at);
+
+CREATE TABLE IF NOT EXISTS api_users (
+    id text PRIMARY KEY,
+    name text,
+    email text,
+    role text,
+    avatar text,
+    bio text,
+    department text,
+    phone text,
+    address text,
+    password_hash text,
+    created_at timestamptz,
+    last_login timestamptz,
+    updated_at timestamptz
+);
+ALTER TABLE api_users
+    ADD COLUMN IF NOT EXISTS name text,
+    ADD COLUMN IF NOT EXISTS email text,
+    ADD COLUMN IF NOT EXISTS role text,
+    ADD COLUMN IF NOT EXISTS avatar text,
+    ADD COLUMN IF NOT EXISTS bio text,
+    ADD COLUMN IF NOT EXISTS department text,
+    ADD COLUMN IF NOT EXISTS phone text,
+    ADD COLUMN IF NOT EXISTS address text,
+    ADD COLUMN IF NOT EXISTS password_hash text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS last_login timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_users_email ON api_users (email);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id text PRIMARY KEY,
+    actor_id text,
+    actor_role text,
+    action text,
+    entity_type text,
+    entity_id text,
+    method text,
+    path text,
+    status bigint,
+    ip_address text,
+    before text,
+    after text,
+    changes text,
+    created_at timestamptz
+);
+ALTER TABLE audit_logs
+    ADD COLUMN IF NOT EXISTS actor_id text,
+    ADD COLUMN IF NOT EXISTS actor_role text,
+    ADD COLUMN IF NOT EXISTS action text,
+    ADD COLUMN IF NOT EXISTS entity_type text,
+    ADD COLUMN IF NOT EXISTS entity_id text,
+    ADD COLUMN IF NOT EXISTS method text,
+    ADD COLUMN IF NOT EXISTS path text,
+    ADD COLUMN IF NOT EXISTS status bigint,
+    ADD COLUMN IF NOT EXISTS ip_address text,
+    ADD COLUMN IF NOT EXISTS before text,
+    ADD COLUMN IF NOT EXISTS after text,
+    ADD COLUMN IF NOT EXISTS changes text,
+    ADD COLUMN IF NOT EXISTS created_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs (entity_type,entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
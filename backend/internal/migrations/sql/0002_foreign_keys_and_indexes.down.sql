@@ -0,0 +1,112 @@
+DROP INDEX IF EXISTS idx_resources_course_id;
+DROP INDEX IF EXISTS idx_sections_course_id;
+DROP INDEX IF EXISTS idx_activities_section_id;
+DROP INDEX IF EXISTS idx_assignments_course_id;
+DROP INDEX IF EXISTS idx_submissions_assignment_id;
+DROP INDEX IF EXISTS idx_submissions_student_id;
+DROP INDEX IF EXISTS idx_quizzes_assignment_id;
+DROP INDEX IF EXISTS idx_attachments_assignment_id;
+DROP INDEX IF EXISTS idx_attachments_submission_id;
+DROP INDEX IF EXISTS idx_generative_tasks_activity_id;
+DROP INDEX IF EXISTS idx_generative_tasks_student_id;
+DROP INDEX IF EXISTS idx_generative_task_submissions_task_id;
+DROP INDEX IF EXISTS idx_generative_task_submissions_student_id;
+DROP INDEX IF EXISTS idx_test_cases_submission_id;
+DROP INDEX IF EXISTS idx_grades_student_id;
+DROP INDEX IF EXISTS idx_grades_course_id;
+DROP INDEX IF EXISTS idx_grades_assignment_id;
+DROP INDEX IF EXISTS idx_enrollments_course_id;
+DROP INDEX IF EXISTS idx_enrollments_student_id;
+DROP INDEX IF EXISTS idx_forum_posts_forum_id;
+DROP INDEX IF EXISTS idx_forum_posts_parent_id;
+
+ALTER TABLE forum_posts
+    DROP CONSTRAINT IF EXISTS fk_forum_posts_forum,
+    DROP CONSTRAINT IF EXISTS fk_forum_posts_parent;
+ALTER TABLE enrollments
+    DROP CONSTRAINT IF EXISTS fk_enrollments_course,
+    DROP CONSTRAINT IF EXISTS fk_enrollments_student;
+ALTER TABLE grading_schemes
+    DROP CONSTRAINT IF EXISTS fk_grading_schemes_course;
+ALTER TABLE test_cases
+    DROP CONSTRAINT IF EXISTS fk_test_cases_submission;
+ALTER TABLE generative_task_submissions
+    DROP CONSTRAINT IF EXISTS fk_generative_task_submissions_task,
+    DROP CONSTRAINT IF EXISTS fk_generative_task_submissions_student;
+ALTER TABLE generative_tasks
+    DROP CONSTRAINT IF EXISTS fk_generative_tasks_activity,
+    DROP CONSTRAINT IF EXISTS fk_generative_tasks_student;
+ALTER TABLE attachments
+    DROP CONSTRAINT IF EXISTS fk_attachments_assignment,
+    DROP CONSTRAINT IF EXISTS fk_attachments_submission;
+ALTER TABLE quiz_attempts
+    DROP CONSTRAINT IF EXISTS fk_quiz_attempts_quiz,
+    DROP CONSTRAINT IF EXISTS fk_quiz_attempts_student,
+    DROP CONSTRAINT IF EXISTS fk_quiz_attempts_submission;
+ALTER TABLE quizzes
+    DROP CONSTRAINT IF EXISTS fk_quizzes_course,
+    DROP CONSTRAINT IF EXISTS fk_quizzes_assignment,
+    DROP CONSTRAINT IF EXISTS fk_quizzes_activity;
+ALTER TABLE questions
+    DROP CONSTRAINT IF EXISTS fk_questions_course;
+ALTER TABLE peer_reviews
+    DROP CONSTRAINT IF EXISTS fk_peer_reviews_assignment,
+    DROP CONSTRAINT IF EXISTS fk_peer_reviews_submission,
+    DROP CONSTRAINT IF EXISTS fk_peer_reviews_reviewer;
+ALTER TABLE rubric_assessments
+    DROP CONSTRAINT IF EXISTS fk_rubric_assessments_submission,
+    DROP CONSTRAINT IF EXISTS fk_rubric_assessments_rubric;
+ALTER TABLE submissions
+    DROP CONSTRAINT IF EXISTS fk_submissions_assignment,
+    DROP CONSTRAINT IF EXISTS fk_submissions_student;
+ALTER TABLE rubrics
+    DROP CONSTRAINT IF EXISTS fk_rubrics_assignment;
+ALTER TABLE assignment_extensions
+    DROP CONSTRAINT IF EXISTS fk_assignment_extensions_assignment,
+    DROP CONSTRAINT IF EXISTS fk_assignment_extensions_student;
+ALTER TABLE assignments
+    DROP CONSTRAINT IF EXISTS fk_assignments_course;
+ALTER TABLE activity_completions
+    DROP CONSTRAINT IF EXISTS fk_activity_completions_activity,
+    DROP CONSTRAINT IF EXISTS fk_activity_completions_student,
+    DROP CONSTRAINT IF EXISTS fk_activity_completions_course;
+ALTER TABLE activities
+    DROP CONSTRAINT IF EXISTS fk_activities_section;
+ALTER TABLE sections
+    DROP CONSTRAINT IF EXISTS fk_sections_course;
+ALTER TABLE resources
+    DROP CONSTRAINT IF EXISTS fk_resources_course;
+
+-- The rows that are still quarantined go back where they were
+INSERT INTO resources SELECT * FROM quarantine.resources;
+INSERT INTO sections SELECT * FROM quarantine.sections;
+INSERT INTO activities SELECT * FROM quarantine.activities;
+INSERT INTO activity_completions SELECT * FROM quarantine.activity_completions;
+INSERT INTO assignments SELECT * FROM quarantine.assignments;
+INSERT INTO assignment_extensions SELECT * FROM quarantine.assignment_extensions;
+INSERT INTO rubrics SELECT * FROM quarantine.rubrics;
+INSERT INTO submissions SELECT * FROM quarantine.submissions;
+INSERT INTO rubric_assessments SELECT * FROM quarantine.rubric_assessments;
+INSERT INTO peer_reviews SELECT * FROM quarantine.peer_reviews;
+INSERT INTO questions SELECT * FROM quarantine.questions;
+INSERT INTO quizzes SELECT * FROM quarantine.quizzes;
+INSERT INTO quiz_attempts SELECT * FROM quarantine.quiz_attempts;
+INSERT INTO generative_tasks SELECT * FROM quarantine.generative_tasks;
+INSERT INTO generative_task_submissions SELECT * FROM quarantine.generative_task_submissions;
+INSERT INTO test_cases SELECT * FROM quarantine.test_cases;
+INSERT INTO grading_schemes SELECT * FROM quarantine.grading_schemes;
+INSERT INTO enrollments SELECT * FROM quarantine.enrollments;
+INSERT INTO forum_posts SELECT * FROM quarantine.forum_posts;
+UPDATE quizzes SET activity_id = r.value
+FROM quarantine.cleared_references AS r
+WHERE r.table_name = 'quizzes' AND r.column_name = 'activity_id' AND r.row_id = quizzes.id;
+UPDATE quiz_attempts SET submission_id = r.value
+FROM quarantine.cleared_references AS r
+WHERE r.table_name = 'quiz_attempts' AND r.column_name = 'submission_id' AND r.row_id = quiz_attempts.id;
+UPDATE attachments SET assignment_id = r.value
+FROM quarantine.cleared_references AS r
+WHERE r.table_name = 'attachments' AND r.column_name = 'assignment_id' AND r.row_id = attachments.id;
+UPDATE attachments SET submission_id = r.value
+FROM quarantine.cleared_references AS r
+WHERE r.table_name = 'attachments' AND r.column_name = 'submission_id' AND r.row_id = attachments.id;
+DROP SCHEMA quarantine CASCADE;
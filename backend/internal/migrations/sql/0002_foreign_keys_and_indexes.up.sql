@@ -0,0 +1,290 @@
+-- Replies used to be stored without their post's forum
+UPDATE forum_posts AS reply
+SET forum_id = post.forum_id
+FROM forum_posts AS post
+WHERE reply.parent_id = post.id AND (reply.forum_id IS NULL OR reply.forum_id = '');
+
+-- Foreign keys AutoMigrate derived from associations, replaced below
+ALTER TABLE resources DROP CONSTRAINT IF EXISTS fk_courses_resources;
+ALTER TABLE activities DROP CONSTRAINT IF EXISTS fk_sections_activities;
+ALTER TABLE rubric_assessments DROP CONSTRAINT IF EXISTS fk_submissions_rubric;
+ALTER TABLE attachments DROP CONSTRAINT IF EXISTS fk_submissions_attachments;
+ALTER TABLE attachments DROP CONSTRAINT IF EXISTS fk_assignments_attachments;
+ALTER TABLE submissions DROP CONSTRAINT IF EXISTS fk_assignments_submissions;
+ALTER TABLE test_cases DROP CONSTRAINT IF EXISTS fk_generative_task_submissions_test_cases;
+ALTER TABLE forum_posts DROP CONSTRAINT IF EXISTS fk_forum_posts_replies;
+
+-- Rows that point at parents which are gone, such as the content of courses
+-- that used to be hard-deleted, forum posts whose forum_id named no activity
+-- and work of students that never existed, cannot satisfy the constraints.
+-- They are moved to tables of the same name in the quarantine schema, parents
+-- first, so that what belonged to a moved row follows it. Optional references
+-- to missing rows are cleared, and the values they had are kept in
+-- quarantine.cleared_references.
+CREATE SCHEMA quarantine;
+CREATE TABLE quarantine.resources (LIKE resources);
+CREATE TABLE quarantine.sections (LIKE sections);
+CREATE TABLE quarantine.activities (LIKE activities);
+CREATE TABLE quarantine.activity_completions (LIKE activity_completions);
+CREATE TABLE quarantine.assignments (LIKE assignments);
+CREATE TABLE quarantine.assignment_extensions (LIKE assignment_extensions);
+CREATE TABLE quarantine.rubrics (LIKE rubrics);
+CREATE TABLE quarantine.submissions (LIKE submissions);
+CREATE TABLE quarantine.rubric_assessments (LIKE rubric_assessments);
+CREATE TABLE quarantine.peer_reviews (LIKE peer_reviews);
+CREATE TABLE quarantine.questions (LIKE questions);
+CREATE TABLE quarantine.quizzes (LIKE quizzes);
+CREATE TABLE quarantine.quiz_attempts (LIKE quiz_attempts);
+CREATE TABLE quarantine.generative_tasks (LIKE generative_tasks);
+CREATE TABLE quarantine.generative_task_submissions (LIKE generative_task_submissions);
+CREATE TABLE quarantine.test_cases (LIKE test_cases);
+CREATE TABLE quarantine.grading_schemes (LIKE grading_schemes);
+CREATE TABLE quarantine.enrollments (LIKE enrollments);
+CREATE TABLE quarantine.forum_posts (LIKE forum_posts);
+CREATE TABLE quarantine.cleared_references (
+    table_name  TEXT NOT NULL,
+    row_id      TEXT NOT NULL,
+    column_name TEXT NOT NULL,
+    value       TEXT NOT NULL
+);
+
+WITH moved AS (
+    DELETE FROM resources AS r
+    WHERE NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = r.course_id)
+    RETURNING r.*
+)
+INSERT INTO quarantine.resources SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM sections AS s
+    WHERE NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = s.course_id)
+    RETURNING s.*
+)
+INSERT INTO quarantine.sections SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM activities AS a
+    WHERE NOT EXISTS (SELECT 1 FROM sections WHERE sections.id = a.section_id)
+    RETURNING a.*
+)
+INSERT INTO quarantine.activities SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM activity_completions AS c
+    WHERE NOT EXISTS (SELECT 1 FROM activities WHERE activities.id = c.activity_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = c.student_id)
+       OR NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = c.course_id)
+    RETURNING c.*
+)
+INSERT INTO quarantine.activity_completions SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM assignments AS a
+    WHERE NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = a.course_id)
+    RETURNING a.*
+)
+INSERT INTO quarantine.assignments SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM assignment_extensions AS e
+    WHERE NOT EXISTS (SELECT 1 FROM assignments WHERE assignments.id = e.assignment_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = e.student_id)
+    RETURNING e.*
+)
+INSERT INTO quarantine.assignment_extensions SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM rubrics AS r
+    WHERE NOT EXISTS (SELECT 1 FROM assignments WHERE assignments.id = r.assignment_id)
+    RETURNING r.*
+)
+INSERT INTO quarantine.rubrics SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM submissions AS s
+    WHERE NOT EXISTS (SELECT 1 FROM assignments WHERE assignments.id = s.assignment_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = s.student_id)
+    RETURNING s.*
+)
+INSERT INTO quarantine.submissions SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM rubric_assessments AS a
+    WHERE NOT EXISTS (SELECT 1 FROM submissions WHERE submissions.id = a.submission_id)
+       OR NOT EXISTS (SELECT 1 FROM rubrics WHERE rubrics.id = a.rubric_id)
+    RETURNING a.*
+)
+INSERT INTO quarantine.rubric_assessments SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM peer_reviews AS r
+    WHERE NOT EXISTS (SELECT 1 FROM assignments WHERE assignments.id = r.assignment_id)
+       OR NOT EXISTS (SELECT 1 FROM submissions WHERE submissions.id = r.submission_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = r.reviewer_id)
+    RETURNING r.*
+)
+INSERT INTO quarantine.peer_reviews SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM questions AS q
+    WHERE NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = q.course_id)
+    RETURNING q.*
+)
+INSERT INTO quarantine.questions SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM quizzes AS q
+    WHERE NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = q.course_id)
+       OR NOT EXISTS (SELECT 1 FROM assignments WHERE assignments.id = q.assignment_id)
+    RETURNING q.*
+)
+INSERT INTO quarantine.quizzes SELECT * FROM moved;
+INSERT INTO quarantine.cleared_references (table_name, row_id, column_name, value)
+SELECT 'quizzes', q.id, 'activity_id', q.activity_id FROM quizzes AS q
+WHERE activity_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM activities WHERE activities.id = q.activity_id);
+UPDATE quizzes AS q SET activity_id = NULL
+WHERE activity_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM activities WHERE activities.id = q.activity_id);
+WITH moved AS (
+    DELETE FROM quiz_attempts AS a
+    WHERE NOT EXISTS (SELECT 1 FROM quizzes WHERE quizzes.id = a.quiz_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = a.student_id)
+    RETURNING a.*
+)
+INSERT INTO quarantine.quiz_attempts SELECT * FROM moved;
+INSERT INTO quarantine.cleared_references (table_name, row_id, column_name, value)
+SELECT 'quiz_attempts', a.id, 'submission_id', a.submission_id FROM quiz_attempts AS a
+WHERE submission_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM submissions WHERE submissions.id = a.submission_id);
+UPDATE quiz_attempts AS a SET submission_id = NULL
+WHERE submission_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM submissions WHERE submissions.id = a.submission_id);
+INSERT INTO quarantine.cleared_references (table_name, row_id, column_name, value)
+SELECT 'attachments', a.id, 'assignment_id', a.assignment_id FROM attachments AS a
+WHERE assignment_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM assignments WHERE assignments.id = a.assignment_id);
+UPDATE attachments AS a SET assignment_id = NULL
+WHERE assignment_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM assignments WHERE assignments.id = a.assignment_id);
+INSERT INTO quarantine.cleared_references (table_name, row_id, column_name, value)
+SELECT 'attachments', a.id, 'submission_id', a.submission_id FROM attachments AS a
+WHERE submission_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM submissions WHERE submissions.id = a.submission_id);
+UPDATE attachments AS a SET submission_id = NULL
+WHERE submission_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM submissions WHERE submissions.id = a.submission_id);
+WITH moved AS (
+    DELETE FROM generative_tasks AS t
+    WHERE NOT EXISTS (SELECT 1 FROM activities WHERE activities.id = t.activity_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = t.student_id)
+    RETURNING t.*
+)
+INSERT INTO quarantine.generative_tasks SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM generative_task_submissions AS s
+    WHERE NOT EXISTS (SELECT 1 FROM generative_tasks WHERE generative_tasks.id = s.task_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = s.student_id)
+    RETURNING s.*
+)
+INSERT INTO quarantine.generative_task_submissions SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM test_cases AS c
+    WHERE NOT EXISTS (SELECT 1 FROM generative_task_submissions AS s WHERE s.id = c.submission_id)
+    RETURNING c.*
+)
+INSERT INTO quarantine.test_cases SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM grading_schemes AS g
+    WHERE NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = g.course_id)
+    RETURNING g.*
+)
+INSERT INTO quarantine.grading_schemes SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM enrollments AS e
+    WHERE NOT EXISTS (SELECT 1 FROM courses WHERE courses.id = e.course_id)
+       OR NOT EXISTS (SELECT 1 FROM api_users WHERE api_users.id = e.student_id)
+    RETURNING e.*
+)
+INSERT INTO quarantine.enrollments SELECT * FROM moved;
+WITH moved AS (
+    DELETE FROM forum_posts AS p
+    WHERE NOT EXISTS (SELECT 1 FROM activities WHERE activities.id = p.forum_id)
+    RETURNING p.*
+)
+INSERT INTO quarantine.forum_posts SELECT * FROM moved;
+WITH RECURSIVE orphaned AS (
+    SELECT p.id FROM forum_posts AS p
+    WHERE p.parent_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM forum_posts AS parent WHERE parent.id = p.parent_id)
+    UNION
+    SELECT reply.id FROM forum_posts AS reply JOIN orphaned ON reply.parent_id = orphaned.id
+),
+moved AS (
+    DELETE FROM forum_posts WHERE id IN (SELECT id FROM orphaned)
+    RETURNING forum_posts.*
+)
+INSERT INTO quarantine.forum_posts SELECT * FROM moved;
+
+-- Course content goes with its course. Grades keep their own copy of the
+-- names they refer to and outlive what they grade, so they have no foreign
+-- keys. Uploaded attachments outlive their assignments and submissions.
+ALTER TABLE resources
+    ADD CONSTRAINT fk_resources_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE;
+ALTER TABLE sections
+    ADD CONSTRAINT fk_sections_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE;
+ALTER TABLE activities
+    ADD CONSTRAINT fk_activities_section FOREIGN KEY (section_id) REFERENCES sections (id) ON DELETE CASCADE;
+ALTER TABLE activity_completions
+    ADD CONSTRAINT fk_activity_completions_activity FOREIGN KEY (activity_id) REFERENCES activities (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_activity_completions_student FOREIGN KEY (student_id) REFERENCES api_users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_activity_completions_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE;
+ALTER TABLE assignments
+    ADD CONSTRAINT fk_assignments_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE;
+ALTER TABLE assignment_extensions
+    ADD CONSTRAINT fk_assignment_extensions_assignment FOREIGN KEY (assignment_id) REFERENCES assignments (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_assignment_extensions_student FOREIGN KEY (student_id) REFERENCES api_users (id) ON DELETE CASCADE;
+ALTER TABLE rubrics
+    ADD CONSTRAINT fk_rubrics_assignment FOREIGN KEY (assignment_id) REFERENCES assignments (id) ON DELETE CASCADE;
+ALTER TABLE submissions
+    ADD CONSTRAINT fk_submissions_assignment FOREIGN KEY (assignment_id) REFERENCES assignments (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_submissions_student FOREIGN KEY (student_id) REFERENCES api_users (id) ON DELETE CASCADE;
+ALTER TABLE rubric_assessments
+    ADD CONSTRAINT fk_rubric_assessments_submission FOREIGN KEY (submission_id) REFERENCES submissions (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_rubric_assessments_rubric FOREIGN KEY (rubric_id) REFERENCES rubrics (id) ON DELETE CASCADE;
+ALTER TABLE peer_reviews
+    ADD CONSTRAINT fk_peer_reviews_assignment FOREIGN KEY (assignment_id) REFERENCES assignments (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_peer_reviews_submission FOREIGN KEY (submission_id) REFERENCES submissions (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_peer_reviews_reviewer FOREIGN KEY (reviewer_id) REFERENCES api_users (id) ON DELETE CASCADE;
+ALTER TABLE questions
+    ADD CONSTRAINT fk_questions_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE;
+ALTER TABLE quizzes
+    ADD CONSTRAINT fk_quizzes_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_quizzes_assignment FOREIGN KEY (assignment_id) REFERENCES assignments (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_quizzes_activity FOREIGN KEY (activity_id) REFERENCES activities (id) ON DELETE SET NULL;
+ALTER TABLE quiz_attempts
+    ADD CONSTRAINT fk_quiz_attempts_quiz FOREIGN KEY (quiz_id) REFERENCES quizzes (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_quiz_attempts_student FOREIGN KEY (student_id) REFERENCES api_users (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_quiz_attempts_submission FOREIGN KEY (submission_id) REFERENCES submissions (id) ON DELETE SET NULL;
+ALTER TABLE attachments
+    ADD CONSTRAINT fk_attachments_assignment FOREIGN KEY (assignment_id) REFERENCES assignments (id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_attachments_submission FOREIGN KEY (submission_id) REFERENCES submissions (id) ON DELETE SET NULL;
+ALTER TABLE generative_tasks
+    ADD CONSTRAINT fk_generative_tasks_activity FOREIGN KEY (activity_id) REFERENCES activities (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_generative_tasks_student FOREIGN KEY (student_id) REFERENCES api_users (id) ON DELETE CASCADE;
+ALTER TABLE generative_task_submissions
+    ADD CONSTRAINT fk_generative_task_submissions_task FOREIGN KEY (task_id) REFERENCES generative_tasks (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_generative_task_submissions_student FOREIGN KEY (student_id) REFERENCES api_users (id) ON DELETE CASCADE;
+ALTER TABLE test_cases
+    ADD CONSTRAINT fk_test_cases_submission FOREIGN KEY (submission_id) REFERENCES generative_task_submissions (id) ON DELETE CASCADE;
+ALTER TABLE grading_schemes
+    ADD CONSTRAINT fk_grading_schemes_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE;
+ALTER TABLE enrollments
+    ADD CONSTRAINT fk_enrollments_course FOREIGN KEY (course_id) REFERENCES courses (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_enrollments_student FOREIGN KEY (student_id) REFERENCES api_users (id) ON DELETE CASCADE;
+ALTER TABLE forum_posts
+    ADD CONSTRAINT fk_forum_posts_forum FOREIGN KEY (forum_id) REFERENCES activities (id) ON DELETE CASCADE,
+    ADD CONSTRAINT fk_forum_posts_parent FOREIGN KEY (parent_id) REFERENCES forum_posts (id) ON DELETE CASCADE;
+
+-- Foreign key columns the queries filter by
+CREATE INDEX IF NOT EXISTS idx_resources_course_id ON resources (course_id);
+CREATE INDEX IF NOT EXISTS idx_sections_course_id ON sections (course_id);
+CREATE INDEX IF NOT EXISTS idx_activities_section_id ON activities (section_id);
+CREATE INDEX IF NOT EXISTS idx_assignments_course_id ON assignments (course_id);
+CREATE INDEX IF NOT EXISTS idx_submissions_assignment_id ON submissions (assignment_id);
+CREATE INDEX IF NOT EXISTS idx_submissions_student_id ON submissions (student_id);
+CREATE INDEX IF NOT EXISTS idx_quizzes_assignment_id ON quizzes (assignment_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_assignment_id ON attachments (assignment_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_submission_id ON attachments (submission_id);
+CREATE INDEX IF NOT EXISTS idx_generative_tasks_activity_id ON generative_tasks (activity_id);
+CREATE INDEX IF NOT EXISTS idx_generative_tasks_student_id ON generative_tasks (student_id);
+CREATE INDEX IF NOT EXISTS idx_generative_task_submissions_task_id ON generative_task_submissions (task_id);
+CREATE INDEX IF NOT EXISTS idx_generative_task_submissions_student_id ON generative_task_submissions (student_id);
+CREATE INDEX IF NOT EXISTS idx_test_cases_submission_id ON test_cases (submission_id);
+CREATE INDEX IF NOT EXISTS idx_grades_student_id ON grades (student_id);
+CREATE INDEX IF NOT EXISTS idx_grades_course_id ON grades (course_id);
+CREATE INDEX IF NOT EXISTS idx_grades_assignment_id ON grades (assignment_id);
+CREATE INDEX IF NOT EXISTS idx_enrollments_course_id ON enrollments (course_id);
+CREATE INDEX IF NOT EXISTS idx_enrollments_student_id ON enrollments (student_id);
+CREATE INDEX IF NOT EXISTS idx_forum_posts_forum_id ON forum_posts (forum_id);
+CREATE INDEX IF NOT EXISTS idx_forum_posts_parent_id ON forum_posts (parent_id);
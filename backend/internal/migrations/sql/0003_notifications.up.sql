@@ -0,0 +1,27 @@
+CREATE TABLE notifications (
+    id text PRIMARY KEY,
+    user_id text NOT NULL REFERENCES api_users (id) ON DELETE CASCADE,
+    type text NOT NULL,
+    title text NOT NULL,
+    body text NOT NULL DEFAULT '',
+    entity_type text NOT NULL DEFAULT '',
+    entity_id text NOT NULL DEFAULT '',
+    in_app boolean NOT NULL,
+    email_pending boolean NOT NULL,
+    read_at timestamptz,
+    emailed_at timestamptz,
+    created_at timestamptz NOT NULL
+);
+CREATE INDEX idx_notifications_inbox ON notifications (user_id, created_at) WHERE in_app;
+CREATE INDEX idx_notifications_unread ON notifications (user_id) WHERE in_app AND read_at IS NULL;
+CREATE INDEX idx_notifications_email_pending ON notifications (user_id, created_at) WHERE email_pending;
+CREATE INDEX idx_notifications_entity ON notifications (type, entity_id);
+
+CREATE TABLE notification_preferences (
+    user_id text NOT NULL REFERENCES api_users (id) ON DELETE CASCADE,
+    type text NOT NULL,
+    in_app boolean NOT NULL,
+    email boolean NOT NULL,
+    updated_at timestamptz NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
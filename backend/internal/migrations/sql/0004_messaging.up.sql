@@ -0,0 +1,31 @@
+CREATE TABLE conversations (
+    id text PRIMARY KEY,
+    type text NOT NULL,
+    title text NOT NULL DEFAULT '',
+    course_id text REFERENCES courses (id) ON DELETE CASCADE,
+    created_by text NOT NULL,
+    last_activity_at timestamptz NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX idx_conversations_course_announcements ON conversations (course_id) WHERE type = 'announcement';
+
+CREATE TABLE conversation_participants (
+    conversation_id text NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    user_id text NOT NULL REFERENCES api_users (id) ON DELETE CASCADE,
+    user_name text NOT NULL DEFAULT '',
+    last_read_at timestamptz,
+    joined_at timestamptz NOT NULL,
+    PRIMARY KEY (conversation_id, user_id)
+);
+CREATE INDEX idx_conversation_participants_user_id ON conversation_participants (user_id);
+
+CREATE TABLE messages (
+    id text PRIMARY KEY,
+    conversation_id text NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    sender_id text NOT NULL,
+    sender_name text NOT NULL DEFAULT '',
+    content text NOT NULL,
+    created_at timestamptz NOT NULL
+);
+CREATE INDEX idx_messages_conversation_created_at ON messages (conversation_id, created_at);
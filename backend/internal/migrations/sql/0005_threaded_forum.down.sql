@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS forum_post_revisions;
+
+ALTER TABLE forum_posts
+    DROP CONSTRAINT IF EXISTS fk_forum_posts_thread,
+    ALTER COLUMN is_pinned DROP NOT NULL,
+    ALTER COLUMN is_pinned DROP DEFAULT,
+    DROP COLUMN IF EXISTS edited_at,
+    DROP COLUMN IF EXISTS is_locked,
+    DROP COLUMN IF EXISTS thread_id;
@@ -0,0 +1,33 @@
+ALTER TABLE forum_posts
+    ADD COLUMN thread_id text,
+    ADD COLUMN is_locked boolean NOT NULL DEFAULT false,
+    ADD COLUMN edited_at timestamptz;
+
+-- Every post belongs to the thread its topmost ancestor starts
+WITH RECURSIVE threads AS (
+    SELECT id, id AS thread_id FROM forum_posts WHERE parent_id IS NULL
+    UNION ALL
+    SELECT reply.id, threads.thread_id FROM forum_posts AS reply JOIN threads ON reply.parent_id = threads.id
+)
+UPDATE forum_posts SET thread_id = threads.thread_id
+FROM threads
+WHERE forum_posts.id = threads.id;
+
+UPDATE forum_posts SET is_pinned = false WHERE is_pinned IS NULL;
+
+ALTER TABLE forum_posts
+    ALTER COLUMN thread_id SET NOT NULL,
+    ALTER COLUMN is_pinned SET NOT NULL,
+    ALTER COLUMN is_pinned SET DEFAULT false,
+    ADD CONSTRAINT fk_forum_posts_thread FOREIGN KEY (thread_id) REFERENCES forum_posts (id) ON DELETE CASCADE;
+CREATE INDEX idx_forum_posts_thread_id ON forum_posts (thread_id);
+
+CREATE TABLE forum_post_revisions (
+    id text PRIMARY KEY,
+    post_id text NOT NULL REFERENCES forum_posts (id) ON DELETE CASCADE,
+    title text NOT NULL DEFAULT '',
+    content text NOT NULL DEFAULT '',
+    edited_by text NOT NULL,
+    edited_at timestamptz NOT NULL
+);
+CREATE INDEX idx_forum_post_revisions_post_id ON forum_post_revisions (post_id, edited_at);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS forum_post_votes;
+
+DROP INDEX IF EXISTS idx_forum_posts_unanswered;
+ALTER TABLE forum_posts
+    DROP CONSTRAINT IF EXISTS fk_forum_posts_accepted_answer,
+    DROP COLUMN IF EXISTS last_activity_at,
+    DROP COLUMN IF EXISTS vote_count,
+    DROP COLUMN IF EXISTS accepted_answer_id;
@@ -0,0 +1,23 @@
+ALTER TABLE forum_posts
+    ADD COLUMN accepted_answer_id text,
+    ADD COLUMN vote_count integer NOT NULL DEFAULT 0,
+    ADD COLUMN last_activity_at timestamptz;
+
+-- A thread was last active when its newest post was made
+UPDATE forum_posts SET last_activity_at = latest.created_at
+FROM (SELECT thread_id, max(created_at) AS created_at FROM forum_posts GROUP BY thread_id) AS latest
+WHERE forum_posts.id = latest.thread_id;
+UPDATE forum_posts SET last_activity_at = created_at WHERE last_activity_at IS NULL;
+
+ALTER TABLE forum_posts
+    ALTER COLUMN last_activity_at SET NOT NULL,
+    ADD CONSTRAINT fk_forum_posts_accepted_answer FOREIGN KEY (accepted_answer_id) REFERENCES forum_posts (id) ON DELETE SET NULL;
+CREATE INDEX idx_forum_posts_unanswered ON forum_posts (forum_id) WHERE parent_id IS NULL AND accepted_answer_id IS NULL;
+
+CREATE TABLE forum_post_votes (
+    post_id text NOT NULL REFERENCES forum_posts (id) ON DELETE CASCADE,
+    user_id text NOT NULL REFERENCES api_users (id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (post_id, user_id)
+);
+CREATE INDEX idx_forum_post_votes_user_id ON forum_post_votes (user_id);
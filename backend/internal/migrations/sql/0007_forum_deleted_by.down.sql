@@ -0,0 +1 @@
+ALTER TABLE forum_posts DROP COLUMN IF EXISTS deleted_by;
@@ -0,0 +1,2 @@
+-- Who deleted a post, so that authors cannot restore what a moderator removed
+ALTER TABLE forum_posts ADD COLUMN deleted_by text;
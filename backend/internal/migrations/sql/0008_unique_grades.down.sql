@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_grade_student_assignment;
@@ -0,0 +1,7 @@
+-- A student has one grade per assignment; of duplicates the latest stays
+DELETE FROM grades AS g
+USING grades AS newer
+WHERE g.student_id = newer.student_id
+  AND g.assignment_id = newer.assignment_id
+  AND (COALESCE(g.updated_at, '-infinity'), g.id) < (COALESCE(newer.updated_at, '-infinity'), newer.id);
+CREATE UNIQUE INDEX idx_grade_student_assignment ON grades (student_id, assignment_id);
@@ -0,0 +1,10 @@
+-- A student has at most one active or waitlisted enrollment per course;
+-- older duplicates are dropped
+UPDATE enrollments AS e SET status = 'dropped'
+FROM enrollments AS newer
+WHERE e.student_id = newer.student_id
+  AND e.course_id = newer.course_id
+  AND e.status IN ('active', 'waitlisted')
+  AND newer.status IN ('active', 'waitlisted')
+  AND (COALESCE(e.enrolled_at, '-infinity'), e.id) < (COALESCE(newer.enrolled_at, '-infinity'), newer.id);
+CREATE UNIQUE INDEX idx_enrollment_current ON enrollments (student_id, course_id) WHERE status IN ('active', 'waitlisted');
@@ -0,0 +1,82 @@
+// Package qti reads and writes IMS QTI 2.1 content packages: zip archives
+// with an imsmanifest.xml that lists assessment items and, optionally, an
+// assessment test that orders them.
+package qti
+
+import "errors"
+
+const (
+	manifestFile = "imsmanifest.xml"
+
+	itemResourceType = "imsqti_item_xmlv2p1"
+	testResourceType = "imsqti_test_xmlv2p1"
+
+	// maxEntrySize bounds how much of a single package file is read
+	maxEntrySize = 8 << 20
+	// maxItems bounds the number of items read from one package
+	maxItems = 2000
+)
+
+var ErrInvalidPackage = errors.New("invalid QTI package")
+
+// Item types supported by the package reader and writer
+const (
+	SingleChoice   = "single_choice"
+	MultipleChoice = "multiple_choice"
+	TrueFalse      = "true_false"
+	TextEntry      = "text_entry"
+	Numeric        = "numeric"
+	Match          = "match"
+)
+
+// Choice is a choice of a choice or match interaction
+type Choice struct {
+	ID   string
+	Text string
+}
+
+// Item is an assessment item with a single interaction.
+//
+// Correct holds the correct choice identifiers of choice items, "true" or
+// "false" for true/false items and the accepted answers of text entry items.
+// Numeric items use Number and Tolerance, and match items map each choice to
+// its target in Pairs.
+type Item struct {
+	Identifier string
+	Title      string
+	Type       string
+	Prompt     string
+	Choices    []Choice
+	Targets    []Choice
+	Correct    []string
+	Number     float64
+	Tolerance  float64
+	Pairs      map[string]string
+	Points     float64
+}
+
+// Package is the content of a QTI package
+type Package struct {
+	Title string
+	Items []Item
+}
+
+// Issue is a problem with one file or item of an imported package
+type Issue struct {
+	File       string `json:"file,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Report summarizes an import: how many items the package held, how many were
+// imported and why the others were not
+type Report struct {
+	Total    int     `json:"total"`
+	Imported int     `json:"imported"`
+	Skipped  []Issue `json:"skipped"`
+}
+
+// Skip records that an item was not imported
+func (r *Report) Skip(file string, identifier string, message string) {
+	r.Skipped = append(r.Skipped, Issue{File: file, Identifier: identifier, Message: message})
+}
@@ -0,0 +1,214 @@
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		item Item
+	}{
+		{
+			name: "single choice",
+			item: Item{
+				Identifier: "capital",
+				Title:      "Capital",
+				Type:       SingleChoice,
+				Prompt:     "What is the capital of France?",
+				Choices:    []Choice{{ID: "a", Text: "Paris"}, {ID: "b", Text: "Lyon"}, {ID: "c", Text: "Nice"}},
+				Correct:    []string{"a"},
+				Points:     2,
+			},
+		},
+		{
+			name: "multiple choice",
+			item: Item{
+				Identifier: "primes",
+				Title:      "Primes",
+				Type:       MultipleChoice,
+				Prompt:     "Which numbers are prime?",
+				Choices:    []Choice{{ID: "two", Text: "2"}, {ID: "four", Text: "4"}, {ID: "five", Text: "5"}},
+				Correct:    []string{"two", "five"},
+				Points:     3,
+			},
+		},
+		{
+			name: "true false",
+			item: Item{
+				Identifier: "earth",
+				Title:      "Earth",
+				Type:       TrueFalse,
+				Prompt:     "The earth is round.",
+				Correct:    []string{"true"},
+				Points:     1,
+			},
+		},
+		{
+			name: "text entry with several answers",
+			item: Item{
+				Identifier: "color",
+				Title:      "Color",
+				Type:       TextEntry,
+				Prompt:     "Name the color of the sky.",
+				Correct:    []string{"blue", "azure"},
+				Points:     1,
+			},
+		},
+		{
+			name: "numeric with tolerance",
+			item: Item{
+				Identifier: "pi",
+				Title:      "Pi",
+				Type:       Numeric,
+				Prompt:     "Give pi to two decimals.",
+				Number:     3.14,
+				Tolerance:  0.01,
+				Points:     1,
+			},
+		},
+		{
+			name: "match",
+			item: Item{
+				Identifier: "pairs",
+				Title:      "Pairs",
+				Type:       Match,
+				Prompt:     "Match the countries to their capitals.",
+				Choices:    []Choice{{ID: "fr", Text: "France"}, {ID: "de", Text: "Germany"}},
+				Targets:    []Choice{{ID: "paris", Text: "Paris"}, {ID: "berlin", Text: "Berlin"}},
+				Pairs:      map[string]string{"fr": "paris", "de": "berlin"},
+				Points:     2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, &Package{Title: "Round trip", Items: []Item{tt.item}})
+			if err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			pkg, report, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			if report.Total != 1 || report.Imported != 1 || len(report.Skipped) != 0 {
+				t.Fatalf("report = %+v, want one imported item", report)
+			}
+			if pkg.Title != "Round trip" {
+				t.Errorf("title = %q, want %q", pkg.Title, "Round trip")
+			}
+			if !reflect.DeepEqual(pkg.Items[0], tt.item) {
+				t.Errorf("item = %+v, want %+v", pkg.Items[0], tt.item)
+			}
+		})
+	}
+}
+
+func TestWriteReadKeepsOrderAndRenamesIdentifiers(t *testing.T) {
+	items := []Item{
+		{Identifier: "2nd item", Type: TrueFalse, Prompt: "First", Correct: []string{"false"}, Points: 1},
+		{Identifier: "", Type: SingleChoice, Prompt: "Second",
+			Choices: []Choice{{ID: "1", Text: "One"}, {ID: "#", Text: "Hash"}}, Correct: []string{"#"}, Points: 1},
+	}
+
+	var buf bytes.Buffer
+	err := Write(&buf, &Package{Title: "Order", Items: items})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	pkg, _, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if len(pkg.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(pkg.Items))
+	}
+	if pkg.Items[0].Prompt != "First" || pkg.Items[1].Prompt != "Second" {
+		t.Errorf("prompts = %q, %q, want the order of the package", pkg.Items[0].Prompt, pkg.Items[1].Prompt)
+	}
+	if pkg.Items[0].Identifier != "_2nditem" {
+		t.Errorf("identifier = %q, want %q", pkg.Items[0].Identifier, "_2nditem")
+	}
+
+	second := pkg.Items[1]
+	if len(second.Correct) != 1 {
+		t.Fatalf("correct = %v, want one choice", second.Correct)
+	}
+	for _, choice := range second.Choices {
+		if choice.ID == second.Correct[0] && choice.Text != "Hash" {
+			t.Errorf("correct choice is %q, want %q", choice.Text, "Hash")
+		}
+	}
+}
+
+func TestReadRejectsInvalidPackages(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "not a zip archive"},
+		{name: "no manifest", files: map[string]string{"item.xml": "<assessmentItem/>"}},
+		{name: "broken manifest", files: map[string]string{manifestFile: "<manifest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte("not a zip archive")
+			if tt.files != nil {
+				data = zipFiles(t, tt.files)
+			}
+			_, _, err := Read(bytes.NewReader(data), int64(len(data)))
+			if !errors.Is(err, ErrInvalidPackage) {
+				t.Errorf("err = %v, want ErrInvalidPackage", err)
+			}
+		})
+	}
+}
+
+func TestReadReportsSkippedItems(t *testing.T) {
+	data := zipFiles(t, map[string]string{
+		manifestFile: `<manifest xmlns="http://www.imsglobal.org/xsd/imscp_v1p1"><resources>
+			<resource identifier="OLD" type="imsqti_xmlv1p2" href="old.xml"/>
+			<resource identifier="MISSING" type="imsqti_item_xmlv2p1" href="missing.xml"/>
+		</resources></manifest>`,
+	})
+
+	pkg, report, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(pkg.Items) != 0 {
+		t.Errorf("got %d items, want none", len(pkg.Items))
+	}
+	if report.Total != 1 || report.Imported != 0 || len(report.Skipped) != 2 {
+		t.Errorf("report = %+v, want one of one item and the QTI 1.x resource skipped", report)
+	}
+}
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		entry, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = entry.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
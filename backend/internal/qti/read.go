@@ -0,0 +1,632 @@
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type manifest struct {
+	Resources []manifestResource `xml:"resources>resource"`
+}
+
+type manifestResource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+}
+
+type assessmentTest struct {
+	XMLName  xml.Name  `xml:"assessmentTest"`
+	Title    string    `xml:"title,attr"`
+	ItemRefs []itemRef `xml:",any"`
+}
+
+// itemRef collects assessmentItemRef elements at any depth of a test
+type itemRef struct {
+	XMLName xml.Name
+	Href    string    `xml:"href,attr"`
+	Nested  []itemRef `xml:",any"`
+}
+
+type assessmentItem struct {
+	XMLName    xml.Name              `xml:"assessmentItem"`
+	Identifier string                `xml:"identifier,attr"`
+	Title      string                `xml:"title,attr"`
+	Responses  []responseDeclaration `xml:"responseDeclaration"`
+	Outcomes   []outcomeDeclaration  `xml:"outcomeDeclaration"`
+	Body       innerXML              `xml:"itemBody"`
+	Processing innerXML              `xml:"responseProcessing"`
+}
+
+type innerXML struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+type responseDeclaration struct {
+	Identifier  string   `xml:"identifier,attr"`
+	Cardinality string   `xml:"cardinality,attr"`
+	BaseType    string   `xml:"baseType,attr"`
+	Correct     []string `xml:"correctResponse>value"`
+	Mapping     *mapping `xml:"mapping"`
+}
+
+type mapping struct {
+	UpperBound string     `xml:"upperBound,attr"`
+	Entries    []mapEntry `xml:"mapEntry"`
+}
+
+type mapEntry struct {
+	Key   string  `xml:"mapKey,attr"`
+	Value float64 `xml:"mappedValue,attr"`
+}
+
+type outcomeDeclaration struct {
+	Identifier    string   `xml:"identifier,attr"`
+	NormalMaximum string   `xml:"normalMaximum,attr"`
+	Default       []string `xml:"defaultValue>value"`
+}
+
+type simpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	Inner      []byte `xml:",innerxml"`
+}
+
+type choiceInteraction struct {
+	ResponseIdentifier string         `xml:"responseIdentifier,attr"`
+	MaxChoices         string         `xml:"maxChoices,attr"`
+	Prompt             innerXML       `xml:"prompt"`
+	Choices            []simpleChoice `xml:"simpleChoice"`
+}
+
+type matchInteraction struct {
+	ResponseIdentifier string   `xml:"responseIdentifier,attr"`
+	Prompt             innerXML `xml:"prompt"`
+	Sets               []struct {
+		Choices []simpleChoice `xml:"simpleAssociableChoice"`
+	} `xml:"simpleMatchSet"`
+}
+
+type textEntryInteraction struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+}
+
+type toleranceCheck struct {
+	Mode      string `xml:"toleranceMode,attr"`
+	Tolerance string `xml:"tolerance,attr"`
+}
+
+// blank stands in for a text entry inside the prompt
+const blank = "____"
+
+// interaction is the one interaction of an item body
+type interaction struct {
+	kind     string
+	response string
+	single   bool
+	prompt   string
+	choices  []Choice
+	targets  []Choice
+}
+
+// blockElements start a new line of prompt text
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "blockquote": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "tr": true,
+}
+
+// skippedElements hold feedback and templates that are not part of the prompt
+var skippedElements = map[string]bool{
+	"rubricBlock": true, "feedbackBlock": true, "feedbackInline": true,
+	"modalFeedback": true, "templateBlock": true, "templateInline": true,
+}
+
+// Read parses a QTI 2.1 package. Items are returned in the order of the
+// package's assessment test, followed by any items only the manifest lists.
+// Items that cannot be represented are left out and listed in the report.
+func Read(r io.ReaderAt, size int64) (*Package, *Report, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[path.Clean(file.Name)] = file
+	}
+
+	data, err := readEntry(files, manifestFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	var m manifest
+	if err := unmarshal(data, &m); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidPackage, manifestFile, err)
+	}
+
+	pkg := &Package{}
+	report := &Report{Skipped: []Issue{}}
+	hrefs := []string{}
+	listed := map[string]bool{}
+	add := func(href string) {
+		if !listed[href] {
+			listed[href] = true
+			hrefs = append(hrefs, href)
+		}
+	}
+
+	// The test decides the order, so read it before the item resources
+	for _, resource := range m.Resources {
+		if !strings.HasPrefix(resource.Type, "imsqti_test_xmlv2") {
+			continue
+		}
+		title, refs, err := readTest(files, resource.Href)
+		if err != nil {
+			report.Skip(resource.Href, resource.Identifier, err.Error())
+			continue
+		}
+		if pkg.Title == "" {
+			pkg.Title = title
+		}
+		for _, ref := range refs {
+			add(ref)
+		}
+	}
+	for _, resource := range m.Resources {
+		switch {
+		case strings.HasPrefix(resource.Type, "imsqti_item_xmlv2"):
+			add(resolve("", resource.Href))
+		case strings.HasPrefix(resource.Type, "imsqti_xmlv1"):
+			report.Skip(resource.Href, resource.Identifier, "QTI 1.x content is not supported")
+		}
+	}
+	if len(hrefs) > maxItems {
+		return nil, nil, fmt.Errorf("%w: more than %d items", ErrInvalidPackage, maxItems)
+	}
+
+	for _, href := range hrefs {
+		report.Total++
+		data, err := readEntry(files, href)
+		if err != nil {
+			report.Skip(href, "", err.Error())
+			continue
+		}
+		item, err := parseItem(data)
+		if err != nil {
+			identifier := ""
+			if item != nil {
+				identifier = item.Identifier
+			}
+			report.Skip(href, identifier, err.Error())
+			continue
+		}
+		pkg.Items = append(pkg.Items, *item)
+	}
+
+	report.Imported = len(pkg.Items)
+	return pkg, report, nil
+}
+
+// readTest returns the title of the test and the package paths of the items
+// it references
+func readTest(files map[string]*zip.File, href string) (string, []string, error) {
+	testPath := resolve("", href)
+	data, err := readEntry(files, testPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var test assessmentTest
+	if err := unmarshal(data, &test); err != nil {
+		return "", nil, err
+	}
+
+	refs := []string{}
+	var collect func(nodes []itemRef)
+	collect = func(nodes []itemRef) {
+		for _, node := range nodes {
+			if node.XMLName.Local == "assessmentItemRef" && node.Href != "" {
+				refs = append(refs, resolve(path.Dir(testPath), node.Href))
+			}
+			collect(node.Nested)
+		}
+	}
+	collect(test.ItemRefs)
+
+	return test.Title, refs, nil
+}
+
+func readEntry(files map[string]*zip.File, name string) ([]byte, error) {
+	file, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing from the package", name)
+	}
+	if file.UncompressedSize64 > maxEntrySize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxEntrySize)
+	}
+
+	body, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, maxEntrySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxEntrySize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxEntrySize)
+	}
+	return data, nil
+}
+
+// resolve turns an href relative to dir into a package path
+func resolve(dir string, href string) string {
+	href = strings.SplitN(href, "#", 2)[0]
+	return strings.TrimPrefix(path.Clean(path.Join(dir, href)), "/")
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+func unmarshal(data []byte, v interface{}) error {
+	return newDecoder(data).Decode(v)
+}
+
+// parseItem reads an assessment item. The item is returned alongside an
+// error when its identifier could be read.
+func parseItem(data []byte) (*Item, error) {
+	var doc assessmentItem
+	if err := unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	item := &Item{Identifier: doc.Identifier, Title: doc.Title}
+	err := item.fill(&doc)
+	if err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+func (item *Item) fill(doc *assessmentItem) error {
+	prompt, interactions, err := readBody(doc.Body.Inner)
+	if err != nil {
+		return err
+	}
+	if len(interactions) == 0 {
+		return errors.New("item has no interaction")
+	}
+	if len(interactions) > 1 {
+		return errors.New("items with more than one interaction are not supported")
+	}
+	in := interactions[0]
+
+	var decl *responseDeclaration
+	for i := range doc.Responses {
+		if doc.Responses[i].Identifier == in.response {
+			decl = &doc.Responses[i]
+		}
+	}
+	if decl == nil {
+		return fmt.Errorf("no response declaration for %q", in.response)
+	}
+
+	// A text entry on a line of its own leaves nothing worth keeping
+	lines := []string{}
+	for _, line := range strings.Split(joinText(prompt, in.prompt), "\n") {
+		if line != blank {
+			lines = append(lines, line)
+		}
+	}
+	item.Prompt = strings.Join(lines, "\n")
+	item.Points = maxScore(doc, decl)
+
+	correct := decl.Correct
+	if len(correct) == 0 && decl.Mapping != nil {
+		for _, entry := range decl.Mapping.Entries {
+			if entry.Value > 0 {
+				correct = append(correct, entry.Key)
+			}
+		}
+	}
+	for i := range correct {
+		correct[i] = strings.TrimSpace(correct[i])
+	}
+	if len(correct) == 0 {
+		return errors.New("item has no correct response")
+	}
+
+	switch in.kind {
+	case "choiceInteraction":
+		item.Choices = in.choices
+		item.Correct = correct
+		item.Type = MultipleChoice
+		if in.single || decl.Cardinality == "single" {
+			item.Type = SingleChoice
+			if value, ok := trueFalseAnswer(in.choices, correct[0]); ok {
+				item.Type = TrueFalse
+				item.Choices = nil
+				item.Correct = []string{value}
+			}
+		}
+	case "matchInteraction":
+		item.Type = Match
+		item.Choices = in.choices
+		item.Targets = in.targets
+		item.Pairs = map[string]string{}
+		for _, value := range correct {
+			pair := strings.Fields(value)
+			if len(pair) != 2 {
+				return fmt.Errorf("invalid directed pair %q", value)
+			}
+			item.Pairs[pair[0]] = pair[1]
+		}
+	case "textEntryInteraction":
+		if decl.BaseType == "float" || decl.BaseType == "integer" {
+			item.Type = Numeric
+			item.Number, err = strconv.ParseFloat(correct[0], 64)
+			if err != nil {
+				return fmt.Errorf("invalid numeric response %q", correct[0])
+			}
+			item.Tolerance = tolerance(doc.Processing.Inner, item.Number)
+		} else {
+			// Alternative answers are given as mapping entries
+			item.Type = TextEntry
+			if decl.Mapping != nil {
+				for _, entry := range decl.Mapping.Entries {
+					if entry.Value > 0 {
+						correct = append(correct, strings.TrimSpace(entry.Key))
+					}
+				}
+			}
+			item.Correct = unique(correct)
+		}
+	}
+
+	return nil
+}
+
+// readBody returns the prompt text of an item body and its interactions
+func readBody(inner []byte) (string, []interaction, error) {
+	var text strings.Builder
+	interactions := []interaction{}
+
+	d := newDecoder(inner)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			switch {
+			case name == "choiceInteraction":
+				var ci choiceInteraction
+				if err := d.DecodeElement(&ci, &t); err != nil {
+					return "", nil, err
+				}
+				interactions = append(interactions, interaction{
+					kind:     name,
+					response: ci.ResponseIdentifier,
+					single:   ci.MaxChoices == "" || ci.MaxChoices == "1",
+					prompt:   plainText(ci.Prompt.Inner),
+					choices:  choices(ci.Choices),
+				})
+			case name == "matchInteraction":
+				var mi matchInteraction
+				if err := d.DecodeElement(&mi, &t); err != nil {
+					return "", nil, err
+				}
+				if len(mi.Sets) != 2 {
+					return "", nil, errors.New("match interactions need exactly two match sets")
+				}
+				interactions = append(interactions, interaction{
+					kind:     name,
+					response: mi.ResponseIdentifier,
+					prompt:   plainText(mi.Prompt.Inner),
+					choices:  choices(mi.Sets[0].Choices),
+					targets:  choices(mi.Sets[1].Choices),
+				})
+			case name == "textEntryInteraction":
+				var te textEntryInteraction
+				if err := d.DecodeElement(&te, &t); err != nil {
+					return "", nil, err
+				}
+				interactions = append(interactions, interaction{kind: name, response: te.ResponseIdentifier})
+				text.WriteString(" " + blank + " ")
+			case strings.HasSuffix(name, "Interaction"):
+				return "", nil, fmt.Errorf("unsupported interaction type %s", name)
+			case skippedElements[name]:
+				if err := d.Skip(); err != nil {
+					return "", nil, err
+				}
+			case blockElements[name]:
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			if blockElements[t.Name.Local] {
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			text.Write(t)
+		}
+	}
+
+	return normalizeText(text.String()), interactions, nil
+}
+
+func choices(simple []simpleChoice) []Choice {
+	result := make([]Choice, len(simple))
+	for i, choice := range simple {
+		result[i] = Choice{ID: choice.Identifier, Text: plainText(choice.Inner)}
+	}
+	return result
+}
+
+// plainText returns the text content of an XML fragment
+func plainText(inner []byte) string {
+	if len(inner) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	d := newDecoder(inner)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			if blockElements[t.Name.Local] {
+				text.WriteString("\n")
+			}
+		}
+	}
+	return normalizeText(text.String())
+}
+
+// normalizeText collapses runs of spaces and drops empty lines
+func normalizeText(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinText(parts ...string) string {
+	nonEmpty := []string{}
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+// trueFalseAnswer reports whether the choices are exactly "true" and "false",
+// by identifier or text, and returns the correct one
+func trueFalseAnswer(choices []Choice, correct string) (string, bool) {
+	if len(choices) != 2 {
+		return "", false
+	}
+
+	values := map[string]string{}
+	for _, choice := range choices {
+		value := strings.ToLower(choice.Text)
+		if value != "true" && value != "false" {
+			value = strings.ToLower(choice.ID)
+		}
+		if value != "true" && value != "false" {
+			return "", false
+		}
+		values[choice.ID] = value
+	}
+	if values[choices[0].ID] == values[choices[1].ID] {
+		return "", false
+	}
+
+	value, ok := values[correct]
+	return value, ok
+}
+
+// maxScore returns the points an item is worth: its MAXSCORE outcome, the
+// normal maximum of SCORE, the upper bound of the response mapping, or 1
+func maxScore(doc *assessmentItem, decl *responseDeclaration) float64 {
+	candidates := []string{}
+	for _, outcome := range doc.Outcomes {
+		if outcome.Identifier == "MAXSCORE" && len(outcome.Default) > 0 {
+			candidates = append(candidates, outcome.Default[0])
+		}
+	}
+	for _, outcome := range doc.Outcomes {
+		if outcome.Identifier == "SCORE" {
+			candidates = append(candidates, outcome.NormalMaximum)
+		}
+	}
+	if decl.Mapping != nil {
+		candidates = append(candidates, decl.Mapping.UpperBound)
+	}
+
+	for _, candidate := range candidates {
+		points, err := strconv.ParseFloat(strings.TrimSpace(candidate), 64)
+		if err == nil && points > 0 {
+			return points
+		}
+	}
+	return 1
+}
+
+// tolerance reads the tolerance of the first equal check in the response
+// processing, converting relative tolerances to absolute ones
+func tolerance(processing []byte, value float64) float64 {
+	d := newDecoder(processing)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return 0
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "equal" {
+			continue
+		}
+
+		var check toleranceCheck
+		if err := d.DecodeElement(&check, &start); err != nil {
+			return 0
+		}
+		fields := strings.Fields(check.Tolerance)
+		if len(fields) == 0 {
+			return 0
+		}
+		t, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || t < 0 {
+			return 0
+		}
+		switch check.Mode {
+		case "absolute":
+			return t
+		case "relative":
+			if value < 0 {
+				value = -value
+			}
+			return value * t / 100
+		default:
+			return 0
+		}
+	}
+}
+
+func unique(values []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,310 @@
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	qtiNamespace = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	cpNamespace  = "http://www.imsglobal.org/xsd/imscp_v1p1"
+
+	matchCorrectTemplate = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	mapResponseTemplate  = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/map_response"
+
+	testFile = "assessment.xml"
+)
+
+// Write stores the package as a zip archive with a manifest, one file per
+// item and an assessment test that keeps the items in order
+func Write(w io.Writer, pkg *Package) error {
+	archive := zip.NewWriter(w)
+
+	ids := newIdentifiers()
+	hrefs := make([]string, len(pkg.Items))
+	identifiers := make([]string, len(pkg.Items))
+	for i := range pkg.Items {
+		item := &pkg.Items[i]
+		identifiers[i] = ids.get(item.Identifier, fmt.Sprintf("item%d", i+1))
+		hrefs[i] = "items/" + identifiers[i] + ".xml"
+
+		data, err := itemXML(item, identifiers[i])
+		if err != nil {
+			return err
+		}
+		if err := writeEntry(archive, hrefs[i], data); err != nil {
+			return err
+		}
+	}
+
+	if err := writeEntry(archive, testFile, testXML(pkg.Title, identifiers, hrefs)); err != nil {
+		return err
+	}
+	if err := writeEntry(archive, manifestFile, manifestXML(identifiers, hrefs)); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeEntry(archive *zip.Writer, name string, data []byte) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
+
+func manifestXML(identifiers []string, hrefs []string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, "<manifest xmlns=%q identifier=\"MANIFEST\">\n", cpNamespace)
+	b.WriteString("  <metadata>\n    <schema>QTIv2.1 Package</schema>\n    <schemaversion>1.0.0</schemaversion>\n  </metadata>\n")
+	b.WriteString("  <organizations/>\n  <resources>\n")
+
+	fmt.Fprintf(&b, "    <resource identifier=\"TEST\" type=%q href=%q>\n", testResourceType, testFile)
+	fmt.Fprintf(&b, "      <file href=%q/>\n", testFile)
+	for _, identifier := range identifiers {
+		fmt.Fprintf(&b, "      <dependency identifierref=%q/>\n", "RES-"+identifier)
+	}
+	b.WriteString("    </resource>\n")
+
+	for i, identifier := range identifiers {
+		fmt.Fprintf(&b, "    <resource identifier=%q type=%q href=%q>\n", "RES-"+identifier, itemResourceType, hrefs[i])
+		fmt.Fprintf(&b, "      <file href=%q/>\n", hrefs[i])
+		b.WriteString("    </resource>\n")
+	}
+
+	b.WriteString("  </resources>\n</manifest>\n")
+	return b.Bytes()
+}
+
+func testXML(title string, identifiers []string, hrefs []string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, "<assessmentTest xmlns=%q identifier=\"TEST\" title=\"%s\">\n", qtiNamespace, escape(title))
+	b.WriteString("  <testPart identifier=\"PART1\" navigationMode=\"nonlinear\" submissionMode=\"simultaneous\">\n")
+	fmt.Fprintf(&b, "    <assessmentSection identifier=\"SECTION1\" title=\"%s\" visible=\"true\">\n", escape(title))
+	for i, identifier := range identifiers {
+		fmt.Fprintf(&b, "      <assessmentItemRef identifier=%q href=%q/>\n", identifier, hrefs[i])
+	}
+	b.WriteString("    </assessmentSection>\n  </testPart>\n</assessmentTest>\n")
+	return b.Bytes()
+}
+
+func itemXML(item *Item, identifier string) ([]byte, error) {
+	// Choice identifiers must be XML names, so they are renamed on the way out
+	choiceIDs := newIdentifiers()
+	choiceID := func(id string) string { return choiceIDs.get(id, "C") }
+	for _, choice := range item.Choices {
+		choiceID(choice.ID)
+	}
+	for _, target := range item.Targets {
+		choiceID(target.ID)
+	}
+
+	points := item.Points
+	if points <= 0 {
+		points = 1
+	}
+
+	var (
+		cardinality = "single"
+		baseType    = "identifier"
+		correct     []string
+		entries     []string
+		template    = matchCorrectTemplate
+		processing  string
+		body        string
+	)
+
+	switch item.Type {
+	case SingleChoice, MultipleChoice:
+		maxChoices := 1
+		if item.Type == MultipleChoice {
+			cardinality, maxChoices = "multiple", 0
+			template = mapResponseTemplate
+			share := formatFloat(points / float64(max(1, len(item.Correct))))
+			isCorrect := map[string]bool{}
+			for _, id := range item.Correct {
+				isCorrect[id] = true
+			}
+			for _, choice := range item.Choices {
+				value := "-" + share
+				if isCorrect[choice.ID] {
+					value = share
+				}
+				entries = append(entries, fmt.Sprintf("<mapEntry mapKey=%q mappedValue=%q/>", choiceID(choice.ID), value))
+			}
+		}
+		for _, id := range item.Correct {
+			correct = append(correct, choiceID(id))
+		}
+		body = choiceBody(item.Choices, maxChoices, choiceID)
+	case TrueFalse:
+		correct = item.Correct
+		body = choiceBody([]Choice{{ID: "true", Text: "True"}, {ID: "false", Text: "False"}}, 1, func(id string) string { return id })
+	case TextEntry:
+		baseType = "string"
+		correct = item.Correct[:min(1, len(item.Correct))]
+		if len(item.Correct) > 1 {
+			template = mapResponseTemplate
+			for _, answer := range item.Correct {
+				entries = append(entries, fmt.Sprintf("<mapEntry mapKey=\"%s\" mappedValue=%q caseSensitive=\"false\"/>", escape(answer), formatFloat(points)))
+			}
+		}
+		body = "<p><textEntryInteraction responseIdentifier=\"RESPONSE\" expectedLength=\"20\"/></p>"
+	case Numeric:
+		baseType = "float"
+		correct = []string{formatFloat(item.Number)}
+		if item.Tolerance > 0 {
+			tolerance := formatFloat(item.Tolerance)
+			template = ""
+			processing = fmt.Sprintf("<responseProcessing><responseCondition><responseIf>"+
+				"<equal toleranceMode=\"absolute\" tolerance=\"%s %s\"><variable identifier=\"RESPONSE\"/><correct identifier=\"RESPONSE\"/></equal>"+
+				"<setOutcomeValue identifier=\"SCORE\"><variable identifier=\"MAXSCORE\"/></setOutcomeValue>"+
+				"</responseIf></responseCondition></responseProcessing>", tolerance, tolerance)
+		}
+		body = "<p><textEntryInteraction responseIdentifier=\"RESPONSE\" expectedLength=\"10\"/></p>"
+	case Match:
+		cardinality, baseType = "multiple", "directedPair"
+		template = mapResponseTemplate
+		share := formatFloat(points / float64(max(1, len(item.Pairs))))
+		for _, choice := range item.Choices {
+			target, ok := item.Pairs[choice.ID]
+			if !ok {
+				continue
+			}
+			pair := choiceID(choice.ID) + " " + choiceID(target)
+			correct = append(correct, pair)
+			entries = append(entries, fmt.Sprintf("<mapEntry mapKey=%q mappedValue=%q/>", pair, share))
+		}
+		body = matchBody(item.Choices, item.Targets, choiceID)
+	default:
+		return nil, fmt.Errorf("unsupported item type %q", item.Type)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, "<assessmentItem xmlns=%q identifier=%q title=\"%s\" adaptive=\"false\" timeDependent=\"false\">\n",
+		qtiNamespace, identifier, escape(item.Title))
+
+	fmt.Fprintf(&b, "  <responseDeclaration identifier=\"RESPONSE\" cardinality=%q baseType=%q>\n", cardinality, baseType)
+	b.WriteString("    <correctResponse>\n")
+	for _, value := range correct {
+		fmt.Fprintf(&b, "      <value>%s</value>\n", escape(value))
+	}
+	b.WriteString("    </correctResponse>\n")
+	if len(entries) > 0 {
+		fmt.Fprintf(&b, "    <mapping lowerBound=\"0\" upperBound=%q defaultValue=\"0\">\n", formatFloat(points))
+		for _, entry := range entries {
+			fmt.Fprintf(&b, "      %s\n", entry)
+		}
+		b.WriteString("    </mapping>\n")
+	}
+	b.WriteString("  </responseDeclaration>\n")
+
+	b.WriteString("  <outcomeDeclaration identifier=\"SCORE\" cardinality=\"single\" baseType=\"float\">\n")
+	b.WriteString("    <defaultValue><value>0</value></defaultValue>\n  </outcomeDeclaration>\n")
+	b.WriteString("  <outcomeDeclaration identifier=\"MAXSCORE\" cardinality=\"single\" baseType=\"float\">\n")
+	fmt.Fprintf(&b, "    <defaultValue><value>%s</value></defaultValue>\n  </outcomeDeclaration>\n", formatFloat(points))
+
+	b.WriteString("  <itemBody>\n")
+	for _, line := range strings.Split(item.Prompt, "\n") {
+		if line != "" {
+			fmt.Fprintf(&b, "    <p>%s</p>\n", escape(line))
+		}
+	}
+	fmt.Fprintf(&b, "    %s\n", body)
+	b.WriteString("  </itemBody>\n")
+
+	if template != "" {
+		fmt.Fprintf(&b, "  <responseProcessing template=%q/>\n", template)
+	} else {
+		fmt.Fprintf(&b, "  %s\n", processing)
+	}
+	b.WriteString("</assessmentItem>\n")
+
+	return b.Bytes(), nil
+}
+
+func choiceBody(choices []Choice, maxChoices int, id func(string) string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<choiceInteraction responseIdentifier=\"RESPONSE\" shuffle=\"false\" maxChoices=\"%d\">", maxChoices)
+	for _, choice := range choices {
+		fmt.Fprintf(&b, "<simpleChoice identifier=%q>%s</simpleChoice>", id(choice.ID), escape(choice.Text))
+	}
+	b.WriteString("</choiceInteraction>")
+	return b.String()
+}
+
+func matchBody(choices []Choice, targets []Choice, id func(string) string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<matchInteraction responseIdentifier=\"RESPONSE\" shuffle=\"false\" maxAssociations=\"%d\">", len(choices))
+	b.WriteString("<simpleMatchSet>")
+	for _, choice := range choices {
+		fmt.Fprintf(&b, "<simpleAssociableChoice identifier=%q matchMax=\"1\">%s</simpleAssociableChoice>", id(choice.ID), escape(choice.Text))
+	}
+	b.WriteString("</simpleMatchSet><simpleMatchSet>")
+	for _, target := range targets {
+		fmt.Fprintf(&b, "<simpleAssociableChoice identifier=%q matchMax=\"%d\">%s</simpleAssociableChoice>", id(target.ID), len(choices), escape(target.Text))
+	}
+	b.WriteString("</simpleMatchSet></matchInteraction>")
+	return b.String()
+}
+
+// identifiers turns arbitrary IDs into unique XML names, giving the same
+// name to the same ID
+type identifiers struct {
+	names map[string]string
+	taken map[string]bool
+}
+
+func newIdentifiers() *identifiers {
+	return &identifiers{names: map[string]string{}, taken: map[string]bool{}}
+}
+
+func (ids *identifiers) get(id string, fallback string) string {
+	if name, ok := ids.names[id]; ok {
+		return name
+	}
+
+	var b strings.Builder
+	for _, r := range id {
+		if r < 128 && (r == '_' || r == '-' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" {
+		name = fallback
+	}
+	if c := name[0]; !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+		name = "_" + name
+	}
+
+	base := name
+	for n := 2; ids.taken[name]; n++ {
+		name = base + "_" + strconv.Itoa(n)
+	}
+
+	ids.names[id] = name
+	ids.taken[name] = true
+	return name
+}
+
+func escape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
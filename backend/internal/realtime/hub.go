@@ -0,0 +1,161 @@
+// Package realtime pushes messages to connected clients by topic.
+//
+// Services publish messages to a Hub, which hands them to a Broker. The
+// broker delivers every message back to the hubs of all API instances, and
+// each hub passes it on to its own subscribers of the message's topic.
+// LocalBroker serves a single instance; a broker backed by Postgres
+// LISTEN/NOTIFY can take its place to fan messages out across instances.
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// bufferSize is how many messages a subscriber may fall behind before
+// further messages to it are dropped
+const bufferSize = 64
+
+// Topics clients can subscribe to
+func UserTopic(userID string) string     { return "user:" + userID }
+func CourseTopic(courseID string) string { return "course:" + courseID }
+func ForumTopic(forumID string) string   { return "forum:" + forumID }
+
+// Message is an event for the subscribers of a topic. Its data is already
+// encoded so that brokers can carry it between instances as it is.
+type Message struct {
+	Topic      string          `json:"topic"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data"`
+	OccurredAt time.Time       `json:"occurredAt"`
+}
+
+// Broker carries published messages to the hubs of every API instance
+type Broker interface {
+	// Publish sends the message to every instance, including this one
+	Publish(msg Message) error
+	// Listen hands every message published through the broker to deliver
+	Listen(deliver func(msg Message)) error
+}
+
+// LocalBroker delivers messages within the instance that publishes them
+type LocalBroker struct {
+	mu      sync.RWMutex
+	deliver func(msg Message)
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{}
+}
+
+func (b *LocalBroker) Publish(msg Message) error {
+	b.mu.RLock()
+	deliver := b.deliver
+	b.mu.RUnlock()
+
+	if deliver != nil {
+		deliver(msg)
+	}
+	return nil
+}
+
+func (b *LocalBroker) Listen(deliver func(msg Message)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deliver = deliver
+	return nil
+}
+
+// Hub tracks the subscriptions of the clients connected to this instance
+type Hub struct {
+	broker      Broker
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+// NewHub starts listening to the broker
+func NewHub(broker Broker) (*Hub, error) {
+	hub := &Hub{broker: broker, subscribers: map[string]map[*Subscription]struct{}{}}
+	err := broker.Listen(hub.deliver)
+	if err != nil {
+		return nil, err
+	}
+	return hub, nil
+}
+
+// Publish sends an event with the given data to the subscribers of the topic
+// on every instance
+func (h *Hub) Publish(topic string, eventType string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return h.broker.Publish(Message{Topic: topic, Type: eventType, Data: encoded, OccurredAt: time.Now()})
+}
+
+// Subscribe returns a subscription to the topics, which must be closed when
+// the client goes away
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	subscription := &Subscription{hub: h, topics: topics, messages: make(chan Message, bufferSize)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, topic := range topics {
+		if h.subscribers[topic] == nil {
+			h.subscribers[topic] = map[*Subscription]struct{}{}
+		}
+		h.subscribers[topic][subscription] = struct{}{}
+	}
+	return subscription
+}
+
+// deliver passes a message from the broker to the topic's subscribers. A
+// subscriber whose buffer is full misses the message rather than holding up
+// everyone else.
+func (h *Hub) deliver(msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for subscription := range h.subscribers[msg.Topic] {
+		select {
+		case subscription.messages <- msg:
+		default:
+			log.Printf("Dropped %s message on %s for a subscriber that is falling behind", msg.Type, msg.Topic)
+		}
+	}
+}
+
+// Subscription receives the messages of the topics it was opened with
+type Subscription struct {
+	hub      *Hub
+	topics   []string
+	messages chan Message
+	once     sync.Once
+}
+
+// Topics returns the topics the subscription receives
+func (s *Subscription) Topics() []string {
+	return s.topics
+}
+
+// Messages returns the channel messages arrive on. It is closed when the
+// subscription is.
+func (s *Subscription) Messages() <-chan Message {
+	return s.messages
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+		for _, topic := range s.topics {
+			delete(s.hub.subscribers[topic], s)
+			if len(s.hub.subscribers[topic]) == 0 {
+				delete(s.hub.subscribers, topic)
+			}
+		}
+		close(s.messages)
+	})
+}
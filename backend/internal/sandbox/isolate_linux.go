@@ -0,0 +1,260 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// scratchDir is the program's working directory and the only writable place
+// in the sandbox
+const scratchDir = "/sandbox"
+
+// devices are the device nodes a program may use
+var devices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// isolate starts the init process in its own process group, so the whole tree
+// can be killed on timeout, and in new mount, PID, IPC and UTS namespaces.
+// With network isolation it also gets a network namespace with nothing but an
+// unconfigured loopback.
+func isolate(cmd *exec.Cmd, isolateNetwork bool) error {
+	flags := uintptr(syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS)
+	if isolateNetwork {
+		flags |= syscall.CLONE_NEWNET
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:    true,
+		Pdeathsig:  syscall.SIGKILL,
+		Cloneflags: flags,
+	}
+
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	return nil
+}
+
+// enter builds the sandbox from the spec the server sends, drops to the
+// run's UID and execs the program. It only returns on failure.
+func enter() error {
+	// The no-new-privileges flag and exec must happen on the same thread
+	runtime.LockOSThread()
+	unix.CloseOnExec(errorFD)
+
+	specFile := os.NewFile(specFD, "sandbox-spec")
+	var s spec
+	err := json.NewDecoder(specFile).Decode(&s)
+	specFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read sandbox spec: %w", err)
+	}
+
+	err = buildRoot(&s)
+	if err != nil {
+		return err
+	}
+	err = dropPrivileges(&s)
+	if err != nil {
+		return err
+	}
+	if s.Probe {
+		os.Exit(0)
+	}
+
+	env := []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=" + scratchDir, "TMPDIR=" + scratchDir, "LANG=C.UTF-8"}
+	os.Setenv("PATH", "/usr/local/bin:/usr/bin:/bin")
+	path, err := exec.LookPath(s.Command[0])
+	if err != nil {
+		return fmt.Errorf("failed to find %s in the sandbox: %w", s.Command[0], err)
+	}
+	return unix.Exec(path, s.Command, env)
+}
+
+// buildRoot makes the spec's empty root directory the root of the mount
+// namespace: a read-only tmpfs holding read-only binds of the system paths,
+// a few device nodes, the sandbox's own /proc and a size-limited scratch
+// tmpfs with the program in it
+func buildRoot(s *spec) error {
+	// Nothing mounted from here on may leak back to the host
+	err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, "")
+	if err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	err = unix.Mount("tmpfs", s.Root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=1m,mode=0755")
+	if err != nil {
+		return fmt.Errorf("failed to mount sandbox root: %w", err)
+	}
+
+	for _, path := range s.ReadOnlyPaths {
+		err = bindReadOnly(path, filepath.Join(s.Root, path))
+		if err != nil {
+			return err
+		}
+	}
+	for _, device := range devices {
+		target := filepath.Join(s.Root, device)
+		err = touch(target)
+		if err == nil {
+			err = unix.Mount(device, target, "", unix.MS_BIND, "")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to bind %s: %w", device, err)
+		}
+	}
+
+	proc := filepath.Join(s.Root, "proc")
+	err = os.Mkdir(proc, 0o555)
+	if err == nil {
+		err = unix.Mount("proc", proc, "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, "")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mount /proc: %w", err)
+	}
+
+	scratch := filepath.Join(s.Root, scratchDir)
+	err = os.Mkdir(scratch, 0o700)
+	if err == nil {
+		options := fmt.Sprintf("size=%dk,mode=0700,uid=%d,gid=%d", s.ScratchSizeKB, s.UID, s.UID)
+		err = unix.Mount("tmpfs", scratch, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, options)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mount scratch directory: %w", err)
+	}
+	if s.FileName != "" {
+		err = os.WriteFile(filepath.Join(scratch, s.FileName), []byte(s.Code), 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to write program: %w", err)
+		}
+	}
+	err = os.Symlink(scratchDir, filepath.Join(s.Root, "tmp"))
+	if err != nil {
+		return err
+	}
+
+	oldRoot := filepath.Join(s.Root, ".old-root")
+	err = os.Mkdir(oldRoot, 0o700)
+	if err == nil {
+		err = unix.PivotRoot(s.Root, oldRoot)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to switch to sandbox root: %w", err)
+	}
+	err = unix.Chdir("/")
+	if err == nil {
+		err = unix.Unmount("/.old-root", unix.MNT_DETACH)
+	}
+	if err == nil {
+		err = os.Remove("/.old-root")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to detach host root: %w", err)
+	}
+
+	err = unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, "")
+	if err != nil {
+		return fmt.Errorf("failed to make sandbox root read-only: %w", err)
+	}
+	return unix.Chdir(scratchDir)
+}
+
+// bindReadOnly makes the host path visible read-only at target. Paths the
+// host lacks are skipped, and symlinks such as /bin -> usr/bin are copied.
+func bindReadOnly(path string, target string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(target), 0o755)
+		}
+		if err == nil {
+			err = os.Symlink(link, target)
+		}
+		return err
+	case info.IsDir():
+		err = os.MkdirAll(target, 0o755)
+	default:
+		err = touch(target)
+	}
+	if err == nil {
+		err = unix.Mount(path, target, "", unix.MS_BIND|unix.MS_REC, "")
+	}
+	if err == nil {
+		err = unix.Mount("", target, "", unix.MS_BIND|unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, "")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bind %s read-only: %w", path, err)
+	}
+	return nil
+}
+
+// touch creates an empty file to mount over, with its parent directories
+func touch(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// dropPrivileges switches to the run's UID, which may never gain privileges
+// again, and applies the resource limits. The process limit counts only this
+// run's processes because no one else uses the UID.
+func dropPrivileges(s *spec) error {
+	err := syscall.Setgroups([]int{})
+	if err == nil {
+		err = syscall.Setgid(s.UID)
+	}
+	if err == nil {
+		err = syscall.Setuid(s.UID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to switch to UID %d: %w", s.UID, err)
+	}
+	err = unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to forbid new privileges: %w", err)
+	}
+
+	limits := []struct {
+		resource int
+		value    uint64
+	}{
+		{unix.RLIMIT_DATA, uint64(s.MemoryLimitMB) << 20},
+		{unix.RLIMIT_CPU, uint64(s.CPUSeconds)},
+		{unix.RLIMIT_FSIZE, uint64(s.MaxFileSizeKB) << 10},
+		{unix.RLIMIT_NPROC, uint64(s.MaxProcesses)},
+		{unix.RLIMIT_CORE, 0},
+	}
+	for _, limit := range limits {
+		if limit.value == 0 && limit.resource != unix.RLIMIT_CORE {
+			continue
+		}
+		err = unix.Setrlimit(limit.resource, &unix.Rlimit{Cur: limit.value, Max: limit.value})
+		if err != nil {
+			return fmt.Errorf("failed to set resource limit %d: %w", limit.resource, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// isolate refuses to run untrusted code on platforms that lack the Linux
+// namespaces the sandbox is built from
+func isolate(cmd *exec.Cmd, isolateNetwork bool) error {
+	return fmt.Errorf("%w: sandboxing is only supported on linux", ErrUnavailable)
+}
+
+// enter is never reached, since no init process is started on these platforms
+func enter() error {
+	return fmt.Errorf("%w: sandboxing is only supported on linux", ErrUnavailable)
+}
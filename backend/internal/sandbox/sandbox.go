@@ -0,0 +1,293 @@
+// Package sandbox runs untrusted code in a short-lived, resource-limited
+// child process.
+//
+// The server starts each run as a copy of its own binary, which Init turns
+// into the run's init process: in new mount, PID, IPC, UTS and (optionally)
+// network namespaces it builds a minimal root from read-only binds of the
+// host's system directories and a small scratch tmpfs, switches to a UID of
+// its own and only then execs the interpreter. The program therefore never
+// sees the server's files, and per-UID limits such as the process count
+// apply to that run alone. Setting up the namespaces takes CAP_SYS_ADMIN,
+// CAP_SETUID and CAP_SETGID; without them runs fail with ErrUnavailable.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/config"
+)
+
+var (
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+	ErrUnavailable         = errors.New("code sandbox is unavailable")
+)
+
+// Language describes how to run a program written in a given language
+type Language struct {
+	FileName string
+	Command  []string
+}
+
+// Languages lists the languages the sandbox can run, keyed by name
+var Languages = map[string]Language{
+	"python":     {FileName: "main.py", Command: []string{"python3", "-I", "main.py"}},
+	"javascript": {FileName: "main.js", Command: []string{"node", "main.js"}},
+}
+
+// Result is the outcome of a single sandboxed run
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+	TimedOut bool
+}
+
+const (
+	// initArg is the name the server's binary is started under to become a
+	// sandbox's init process
+	initArg = "skillspace-sandbox-init"
+	// specFD and errorFD are the init process's pipes for reading its spec
+	// and reporting why the sandbox could not be set up
+	specFD  = 3
+	errorFD = 4
+	// initFailed is the init process's exit code when setup fails
+	initFailed = 125
+)
+
+// spec tells the init process how to set up a run
+type spec struct {
+	Root          string   `json:"root"`
+	ReadOnlyPaths []string `json:"readOnlyPaths"`
+	UID           int      `json:"uid"`
+	ScratchSizeKB int      `json:"scratchSizeKb"`
+	MemoryLimitMB int      `json:"memoryLimitMb"`
+	CPUSeconds    int      `json:"cpuSeconds"`
+	MaxProcesses  int      `json:"maxProcesses"`
+	MaxFileSizeKB int      `json:"maxFileSizeKb"`
+	FileName      string   `json:"fileName"`
+	Code          string   `json:"code"`
+	Command       []string `json:"command"`
+	// Probe sets the sandbox up and exits without running anything
+	Probe bool `json:"probe"`
+}
+
+// Init turns the process into a sandbox's init process if the server started
+// it as one, and never returns in that case. It must be the first thing main
+// does.
+func Init() {
+	if len(os.Args) == 0 || os.Args[0] != initArg {
+		return
+	}
+	err := enter()
+	fmt.Fprint(os.NewFile(errorFD, "sandbox-errors"), err)
+	os.Exit(initFailed)
+}
+
+// Runner executes programs under the configured limits. Every concurrent run
+// takes a UID of its own from a pool, used as its GID as well, and the size
+// of the pool caps how many programs run at once.
+type Runner struct {
+	cfg  *config.Sandbox
+	uids chan int
+}
+
+func NewRunner(cfg *config.Sandbox) *Runner {
+	parallel := max(cfg.MaxParallel, 1)
+	uids := make(chan int, parallel)
+	for i := range parallel {
+		uids <- cfg.UIDBase + i
+	}
+	return &Runner{cfg: cfg, uids: uids}
+}
+
+// Check sets up an empty sandbox, so that a host that cannot isolate runs is
+// reported at startup rather than on the first submission
+func (r *Runner) Check(ctx context.Context) error {
+	uid, err := r.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.release(uid)
+
+	s := r.spec(uid)
+	s.Probe = true
+	stderr := &limitedBuffer{limit: 4096}
+	err = r.run(ctx, s, nil, io.Discard, stderr)
+	if err != nil && !errors.Is(err, ErrUnavailable) {
+		return fmt.Errorf("%w: %v %s", ErrUnavailable, err, stderr.String())
+	}
+	return err
+}
+
+// Run runs code with input on stdin in a fresh sandbox. It waits while the
+// maximum number of programs are running. A non-zero exit or a timeout is
+// reported in the Result, not as an error.
+func (r *Runner) Run(ctx context.Context, language string, code string, input string) (*Result, error) {
+	lang, ok := Languages[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+
+	uid, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.release(uid)
+
+	s := r.spec(uid)
+	s.FileName = lang.FileName
+	s.Code = code
+	s.Command = lang.Command
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.TimeLimit)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: r.cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: r.cfg.MaxOutputBytes}
+	start := time.Now()
+	err = r.run(ctx, s, bytes.NewBufferString(input), stdout, stderr)
+	result := &Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrUnavailable):
+		return nil, err
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut:
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run program: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *Runner) spec(uid int) *spec {
+	return &spec{
+		ReadOnlyPaths: r.cfg.ReadOnlyPaths,
+		UID:           uid,
+		ScratchSizeKB: r.cfg.ScratchSizeKB,
+		MemoryLimitMB: r.cfg.MemoryLimitMB,
+		CPUSeconds:    int(r.cfg.TimeLimit.Seconds()) + 1,
+		MaxProcesses:  r.cfg.MaxProcesses,
+		MaxFileSizeKB: r.cfg.MaxFileSizeKB,
+	}
+}
+
+// run starts an init process for the spec and waits for it to exit
+func (r *Runner) run(ctx context.Context, s *spec, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	root, err := os.MkdirTemp(r.cfg.WorkDir, "sandbox-")
+	if err != nil {
+		return fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	defer os.RemoveAll(root)
+	s.Root = root
+
+	encoded, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	specReader, specWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create spec pipe: %w", err)
+	}
+	defer specWriter.Close()
+	errorReader, errorWriter, err := os.Pipe()
+	if err != nil {
+		specReader.Close()
+		return fmt.Errorf("failed to create error pipe: %w", err)
+	}
+	defer errorReader.Close()
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe")
+	cmd.Args = []string{initArg}
+	cmd.Env = []string{}
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = []*os.File{specReader, errorWriter}
+
+	err = isolate(cmd, r.cfg.IsolateNetwork)
+	if err != nil {
+		specReader.Close()
+		errorWriter.Close()
+		return err
+	}
+	err = cmd.Start()
+	specReader.Close()
+	errorWriter.Close()
+	if err != nil {
+		return fmt.Errorf("%w: failed to create namespaces: %v", ErrUnavailable, err)
+	}
+
+	// A failed write shows up as the init process's setup error
+	specWriter.Write(encoded)
+	specWriter.Close()
+
+	waitErr := cmd.Wait()
+	setupErr, _ := io.ReadAll(errorReader)
+	if len(setupErr) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnavailable, setupErr)
+	}
+	return waitErr
+}
+
+func (r *Runner) acquire(ctx context.Context) (int, error) {
+	select {
+	case uid := <-r.uids:
+		return uid, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (r *Runner) release(uid int) {
+	r.uids <- uid
+}
+
+// limitedBuffer keeps at most limit bytes and silently drops the rest, so a
+// runaway program cannot exhaust the server's memory through its output
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buf.Len()
+	if remaining <= 0 {
+		b.truncated = true
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n... output truncated after " + strconv.Itoa(b.limit) + " bytes"
+	}
+	return b.buf.String()
+}
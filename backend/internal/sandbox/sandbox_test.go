@@ -0,0 +1,189 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/config"
+)
+
+// TestMain lets the test binary act as a sandbox's init process, as the
+// server's binary does
+func TestMain(m *testing.M) {
+	Init()
+	os.Exit(m.Run())
+}
+
+// testUID and testParallel make the pool of UIDs runs take
+const (
+	testUID      = 60000
+	testParallel = 2
+)
+
+// newTestRunner returns a runner with small limits, skipping the test where
+// runs cannot be isolated, such as without root
+func newTestRunner(t *testing.T) *Runner {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("sandboxed runs need root")
+	}
+
+	runner := NewRunner(&config.Sandbox{
+		TimeLimit:      2 * time.Second,
+		MemoryLimitMB:  128,
+		MaxProcesses:   8,
+		MaxFileSizeKB:  1024,
+		MaxOutputBytes: 4096,
+		IsolateNetwork: true,
+		ScratchSizeKB:  4096,
+		MaxParallel:    testParallel,
+		UIDBase:        testUID,
+		ReadOnlyPaths:  []string{"/usr", "/bin", "/lib", "/lib32", "/lib64", "/libx32", "/etc/alternatives", "/etc/ld.so.cache"},
+	})
+	err := runner.Check(context.Background())
+	if errors.Is(err, ErrUnavailable) {
+		t.Skipf("sandbox unavailable: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	return runner
+}
+
+func TestRunIsolation(t *testing.T) {
+	runner := newTestRunner(t)
+
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	err := os.WriteFile(secret, []byte("server secret"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{
+			name: "runs as a user of the pool",
+			code: fmt.Sprintf("import os\nuid = os.getuid()\nprint(%d <= uid < %d, os.getgid() == uid, os.getgroups())", testUID, testUID+testParallel),
+			want: "True True []",
+		},
+		{
+			name: "cannot become root again",
+			code: "import os\ntry:\n    os.setuid(0)\n    print('root')\nexcept PermissionError:\n    print('denied')",
+			want: "denied",
+		},
+		{
+			name: "cannot gain privileges",
+			code: "print([l.split()[1] for l in open('/proc/self/status') if l.startswith('NoNewPrivs')][0])",
+			want: "1",
+		},
+		{
+			name: "server files are not visible",
+			code: "import os\nprint([os.path.exists(p) for p in [" + quote(secret) + ", '/etc/passwd', '/root', '/home', '/var']])",
+			want: "[False, False, False, False, False]",
+		},
+		{
+			name: "system paths are read-only",
+			code: "for path in ['/usr/escape', '/bin/escape', '/escape']:\n    try:\n        open(path, 'w')\n        print('written')\n    except OSError:\n        print('denied')",
+			want: "denied\ndenied\ndenied",
+		},
+		{
+			name: "scratch directory is writable",
+			code: "import os\nopen('out.txt', 'w').write('kept')\nprint(os.getcwd(), open('/tmp/out.txt').read())",
+			want: "/sandbox kept",
+		},
+		{
+			name: "own process namespace",
+			code: "import os\nprint(os.getpid(), [p for p in os.listdir('/proc') if p.isdigit()])",
+			want: "1 ['1']",
+		},
+		{
+			name: "no network",
+			code: "import socket\nprint([name for _, name in socket.if_nameindex()])\ntry:\n    socket.create_connection(('1.1.1.1', 53), timeout=1)\n    print('connected')\nexcept OSError:\n    print('denied')",
+			want: "['lo']\ndenied",
+		},
+		{
+			name: "process count is limited",
+			code: "import os, time\nchildren = 0\ntry:\n    for _ in range(32):\n        if os.fork() == 0:\n            time.sleep(1)\n            os._exit(0)\n        children += 1\n    print('unlimited')\nexcept OSError:\n    print('limited', children < 8)",
+			want: "limited True",
+		},
+		{
+			name: "file size is limited",
+			code: "try:\n    open('big', 'wb').write(b'x' * (2 << 20))\n    print('written')\nexcept OSError:\n    print('limited')",
+			want: "limited",
+		},
+		{
+			name: "memory is limited",
+			code: "try:\n    data = bytearray(512 << 20)\n    print('allocated')\nexcept MemoryError:\n    print('limited')",
+			want: "limited",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runner.Run(context.Background(), "python", tt.code, "")
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if result.ExitCode != 0 {
+				t.Fatalf("exit code %d, stderr: %s", result.ExitCode, result.Stderr)
+			}
+			if got := strings.TrimSpace(result.Stdout); got != tt.want {
+				t.Errorf("stdout = %q, want %q (stderr: %s)", got, tt.want, result.Stderr)
+			}
+		})
+	}
+}
+
+func TestRunLimits(t *testing.T) {
+	runner := newTestRunner(t)
+
+	t.Run("time limit", func(t *testing.T) {
+		result, err := runner.Run(context.Background(), "python", "while True:\n    pass", "")
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if !result.TimedOut || result.ExitCode == 0 {
+			t.Errorf("result = %+v, want a timeout", result)
+		}
+	})
+
+	t.Run("output limit", func(t *testing.T) {
+		result, err := runner.Run(context.Background(), "python", "print('x' * 100000)", "")
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if !strings.HasSuffix(result.Stdout, "output truncated after 4096 bytes") {
+			t.Errorf("stdout of %d bytes was not truncated", len(result.Stdout))
+		}
+	})
+
+	t.Run("input and exit code", func(t *testing.T) {
+		result, err := runner.Run(context.Background(), "python", "import sys\nprint(sys.stdin.read().upper())\nsys.exit(3)", "hello")
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if result.ExitCode != 3 || strings.TrimSpace(result.Stdout) != "HELLO" {
+			t.Errorf("result = %+v, want HELLO and exit code 3", result)
+		}
+	})
+
+	t.Run("unsupported language", func(t *testing.T) {
+		_, err := runner.Run(context.Background(), "cobol", "", "")
+		if !errors.Is(err, ErrUnsupportedLanguage) {
+			t.Errorf("err = %v, want ErrUnsupportedLanguage", err)
+		}
+	})
+}
+
+func quote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", "\\'") + "'"
+}
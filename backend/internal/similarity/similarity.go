@@ -0,0 +1,192 @@
+// Package similarity finds passages two documents share. Code is fingerprinted
+// by winnowing hashed k-grams of normalized tokens, so renamed identifiers and
+// changed literals or layout do not hide copying; prose is fingerprinted by
+// shingling its lowercased words.
+package similarity
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// Mode selects how a document is tokenized and fingerprinted
+type Mode string
+
+const (
+	Text Mode = "text"
+	Code Mode = "code"
+)
+
+const (
+	// textShingle is the number of words in a shingle
+	textShingle = 5
+	// codeGram is the number of tokens in a hashed k-gram, and codeWindow the
+	// number of consecutive k-grams winnowing picks one fingerprint from. Any
+	// match of codeGram+codeWindow-1 tokens is guaranteed to be found.
+	codeGram   = 8
+	codeWindow = 4
+	// maxDocumentSize bounds how much of a document is fingerprinted
+	maxDocumentSize = 1 << 20
+)
+
+// Span is a range of a document in byte offsets, with its 1-based lines
+type Span struct {
+	Start     int `json:"start"`
+	End       int `json:"end"`
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// Fingerprint is the set of hashes selected from a document, each with the
+// part of the document it covers
+type Fingerprint struct {
+	content string
+	hashes  []hash
+	unique  map[uint64]bool
+}
+
+type hash struct {
+	value      uint64
+	start, end int
+}
+
+type token struct {
+	text       string
+	start, end int
+}
+
+// Size returns the number of distinct hashes in the fingerprint. Documents
+// too short for a single k-gram have none and never match.
+func (f *Fingerprint) Size() int {
+	return len(f.unique)
+}
+
+// New fingerprints the document
+func New(content string, mode Mode) *Fingerprint {
+	if len(content) > maxDocumentSize {
+		content = content[:maxDocumentSize]
+	}
+
+	var hashes []hash
+	if mode == Code {
+		hashes = winnow(grams(codeTokens(content), codeGram), codeWindow)
+	} else {
+		hashes = grams(textTokens(content), textShingle)
+	}
+
+	unique := map[uint64]bool{}
+	for _, h := range hashes {
+		unique[h.value] = true
+	}
+	return &Fingerprint{content: content, hashes: hashes, unique: unique}
+}
+
+// Match is what two documents share
+type Match struct {
+	// Score is the share of a's fingerprint found in b, and OtherScore the
+	// share of b's found in a, both between 0 and 1
+	Score      float64
+	OtherScore float64
+	// Spans are the shared passages in a, and OtherSpans those in b
+	Spans      []Span
+	OtherSpans []Span
+}
+
+// Compare returns what a and b share
+func Compare(a *Fingerprint, b *Fingerprint) *Match {
+	match := &Match{Spans: []Span{}, OtherSpans: []Span{}}
+	if a.Size() == 0 || b.Size() == 0 {
+		return match
+	}
+
+	shared := map[uint64]bool{}
+	for value := range a.unique {
+		if b.unique[value] {
+			shared[value] = true
+		}
+	}
+	if len(shared) == 0 {
+		return match
+	}
+
+	match.Score = float64(len(shared)) / float64(a.Size())
+	match.OtherScore = float64(len(shared)) / float64(b.Size())
+	match.Spans = a.spans(shared)
+	match.OtherSpans = b.spans(shared)
+	return match
+}
+
+// spans merges the ranges of the given hashes into passages of the document
+func (f *Fingerprint) spans(values map[uint64]bool) []Span {
+	ranges := [][2]int{}
+	for _, h := range f.hashes {
+		if values[h.value] {
+			ranges = append(ranges, [2]int{h.start, h.end})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	merged := [][2]int{}
+	for _, r := range ranges {
+		last := len(merged) - 1
+		if last >= 0 && r[0] <= merged[last][1] {
+			merged[last][1] = max(merged[last][1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	spans := make([]Span, len(merged))
+	for i, r := range merged {
+		spans[i] = Span{
+			Start:     r[0],
+			End:       r[1],
+			StartLine: strings.Count(f.content[:r[0]], "\n") + 1,
+			EndLine:   strings.Count(f.content[:r[1]], "\n") + 1,
+		}
+	}
+	return spans
+}
+
+// grams hashes every run of k consecutive tokens
+func grams(tokens []token, k int) []hash {
+	if len(tokens) < k {
+		return nil
+	}
+
+	hashes := make([]hash, 0, len(tokens)-k+1)
+	for i := 0; i+k <= len(tokens); i++ {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+k] {
+			h.Write([]byte(t.text))
+			h.Write([]byte{0})
+		}
+		hashes = append(hashes, hash{value: h.Sum64(), start: tokens[i].start, end: tokens[i+k-1].end})
+	}
+	return hashes
+}
+
+// winnow picks the smallest hash of every window of w consecutive hashes,
+// preferring the rightmost on ties, and keeps each pick once
+func winnow(hashes []hash, w int) []hash {
+	if len(hashes) <= w {
+		return hashes
+	}
+
+	selected := []hash{}
+	last := -1
+	for start := 0; start+w <= len(hashes); start++ {
+		smallest := start
+		for i := start + 1; i < start+w; i++ {
+			if hashes[i].value <= hashes[smallest].value {
+				smallest = i
+			}
+		}
+		if smallest != last {
+			selected = append(selected, hashes[smallest])
+			last = smallest
+		}
+	}
+	return selected
+}
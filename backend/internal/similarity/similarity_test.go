@@ -0,0 +1,146 @@
+package similarity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWinnow(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []uint64
+		window int
+		want   []int
+	}{
+		{name: "fewer hashes than a window", values: []uint64{5, 3}, window: 3, want: []int{0, 1}},
+		{name: "exactly one window", values: []uint64{5, 3, 8}, window: 3, want: []int{0, 1, 2}},
+		{name: "smallest of each window", values: []uint64{5, 3, 8, 6, 7, 1, 9}, window: 3, want: []int{1, 3, 5}},
+		{name: "rightmost on ties", values: []uint64{5, 3, 8, 3, 7}, window: 3, want: []int{1, 3}},
+		{name: "a pick is kept once", values: []uint64{9, 1, 9, 9, 9}, window: 3, want: []int{1, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashes := make([]hash, len(tt.values))
+			for i, value := range tt.values {
+				hashes[i] = hash{value: value, start: i, end: i + 1}
+			}
+
+			got := []int{}
+			for _, h := range winnow(hashes, tt.window) {
+				got = append(got, h.start)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("winnow picked %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareScores(t *testing.T) {
+	const program = `def total(items):
+    result = 0
+    for item in items:
+        if item > 0:
+            result = result + item
+    return result
+`
+	const renamed = `def sum_positive(values):
+    # add up the positive values
+    acc = 100
+    for v in values:
+        if v > 42:
+            acc = acc + v
+    return acc
+`
+	const unrelated = `class Stack:
+    def __init__(self):
+        self.items = []
+    def push(self, value):
+        self.items.append(value)
+`
+
+	tests := []struct {
+		name      string
+		a, b      string
+		mode      Mode
+		wantScore float64
+		wantOther float64
+	}{
+		{name: "identical code", a: program, b: program, mode: Code, wantScore: 1, wantOther: 1},
+		{name: "renamed identifiers, literals and comments", a: program, b: renamed, mode: Code, wantScore: 1, wantOther: 1},
+		{name: "unrelated code", a: program, b: unrelated, mode: Code},
+		{name: "too short to fingerprint", a: "x = 1", b: "x = 1", mode: Code},
+		{name: "identical prose", a: "The quick brown fox jumps over the lazy dog", b: "the quick brown fox jumps over the lazy dog", mode: Text, wantScore: 1, wantOther: 1},
+		{name: "prose sharing a prefix", a: "one two three four five six", b: "one two three four five", mode: Text, wantScore: 0.5, wantOther: 1},
+		{name: "unrelated prose", a: "one two three four five", b: "six seven eight nine ten", mode: Text},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := Compare(New(tt.a, tt.mode), New(tt.b, tt.mode))
+			if match.Score != tt.wantScore || match.OtherScore != tt.wantOther {
+				t.Errorf("scores = %v, %v, want %v, %v", match.Score, match.OtherScore, tt.wantScore, tt.wantOther)
+			}
+			if (match.Score == 0) != (len(match.Spans) == 0) {
+				t.Errorf("score %v with spans %v", match.Score, match.Spans)
+			}
+		})
+	}
+}
+
+func TestCompareSpans(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string
+		wantSpans []Span
+		wantOther []Span
+	}{
+		{
+			name:      "shared prefix",
+			a:         "one two three four five\nsix",
+			b:         "one two three four five",
+			wantSpans: []Span{{Start: 0, End: 23, StartLine: 1, EndLine: 1}},
+			wantOther: []Span{{Start: 0, End: 23, StartLine: 1, EndLine: 1}},
+		},
+		{
+			name:      "passage on later lines",
+			a:         "intro\none two three\nfour five",
+			b:         "one two three four five",
+			wantSpans: []Span{{Start: 6, End: 29, StartLine: 2, EndLine: 3}},
+			wantOther: []Span{{Start: 0, End: 23, StartLine: 1, EndLine: 1}},
+		},
+		{
+			name:      "overlapping shingles merge",
+			a:         "one two three four five six seven",
+			b:         "zero one two three four five six seven eight",
+			wantSpans: []Span{{Start: 0, End: 33, StartLine: 1, EndLine: 1}},
+			wantOther: []Span{{Start: 5, End: 38, StartLine: 1, EndLine: 1}},
+		},
+		{
+			name: "separate passages",
+			a:    "one two three four five\nx y\nsix seven eight nine ten",
+			b:    "one two three four five six seven eight nine ten",
+			wantSpans: []Span{
+				{Start: 0, End: 23, StartLine: 1, EndLine: 1},
+				{Start: 28, End: 52, StartLine: 3, EndLine: 3},
+			},
+			wantOther: []Span{
+				{Start: 0, End: 23, StartLine: 1, EndLine: 1},
+				{Start: 24, End: 48, StartLine: 1, EndLine: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := Compare(New(tt.a, Text), New(tt.b, Text))
+			if !reflect.DeepEqual(match.Spans, tt.wantSpans) {
+				t.Errorf("spans = %+v, want %+v", match.Spans, tt.wantSpans)
+			}
+			if !reflect.DeepEqual(match.OtherSpans, tt.wantOther) {
+				t.Errorf("other spans = %+v, want %+v", match.OtherSpans, tt.wantOther)
+			}
+		})
+	}
+}
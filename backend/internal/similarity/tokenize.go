@@ -0,0 +1,130 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// keywords are kept as they are when code is normalized; every other
+// identifier becomes the same token. The list covers the languages the
+// sandbox runs.
+var keywords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		and as assert async await break case catch class const continue def default
+		del delete do elif else except extends finally for from func function go
+		if import in interface is lambda let map new nil none not null or package
+		pass private protected public raise range return self static struct super
+		switch this throw try type var void while with yield true false
+		int long float double char bool boolean string str list dict set print
+	`) {
+		keywords[word] = true
+	}
+}
+
+// textTokens splits prose into lowercased words
+func textTokens(content string) []token {
+	tokens := []token{}
+	start := -1
+	for i, r := range content {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			tokens = append(tokens, token{text: strings.ToLower(content[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{text: strings.ToLower(content[start:]), start: start, end: len(content)})
+	}
+	return tokens
+}
+
+// codeTokens splits source code into normalized tokens: comments and
+// whitespace are dropped, identifiers other than keywords become "id",
+// numbers "0" and string literals "\"\"". Operators and punctuation are kept
+// one character at a time.
+func codeTokens(content string) []token {
+	tokens := []token{}
+	i := 0
+	for i < len(content) {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case strings.HasPrefix(content[i:], "//") || r == '#':
+			i = lineEnd(content, i)
+			continue
+		case strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 4
+			}
+			continue
+		case r == '"' || r == '\'' || r == '`':
+			i = stringEnd(content, i, r)
+			tokens = append(tokens, token{text: `""`, start: start, end: i})
+		case unicode.IsDigit(r):
+			i = scan(content, i, func(r rune) bool { return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '.' || r == '_' })
+			tokens = append(tokens, token{text: "0", start: start, end: i})
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			i = scan(content, i, func(r rune) bool { return unicode.IsDigit(r) || unicode.IsLetter(r) || r == '_' || r == '$' })
+			text := content[start:i]
+			if !keywords[strings.ToLower(text)] {
+				text = "id"
+			}
+			tokens = append(tokens, token{text: text, start: start, end: i})
+		default:
+			i += size
+			tokens = append(tokens, token{text: content[start:i], start: start, end: i})
+		}
+	}
+	return tokens
+}
+
+// scan returns the offset of the first rune from i on that does not satisfy ok
+func scan(content string, i int, ok func(rune) bool) int {
+	for i < len(content) {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		if !ok(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+func lineEnd(content string, i int) int {
+	end := strings.IndexByte(content[i:], '\n')
+	if end < 0 {
+		return len(content)
+	}
+	return i + end
+}
+
+// stringEnd returns the offset after the string literal starting at i,
+// skipping escaped quotes. Unterminated literals end at the end of the line,
+// or of the content for backquoted ones.
+func stringEnd(content string, i int, quote rune) int {
+	for j := i + 1; j < len(content); j++ {
+		switch content[j] {
+		case '\\':
+			j++
+		case '\n':
+			if quote != '`' {
+				return j
+			}
+		case byte(quote):
+			return j + 1
+		}
+	}
+	return len(content)
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// extensionTypes covers common course file types that are missing from the
+// platform's MIME table on minimal images
+var extensionTypes = map[string]string{
+	".txt":   "text/plain",
+	".md":    "text/markdown",
+	".csv":   "text/csv",
+	".py":    "text/x-python",
+	".js":    "text/javascript",
+	".json":  "application/json",
+	".ipynb": "application/x-ipynb+json",
+	".pdf":   "application/pdf",
+	".zip":   "application/zip",
+	".docx":  "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".pptx":  "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".xlsx":  "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// DetectContentType determines a file's MIME type from its leading bytes,
+// using the file name only to refine generic results: plain text may be
+// more specific text, and a zip archive may be an Office document. A client
+// cannot make a binary file pass as text by renaming it.
+func DetectContentType(head []byte, filename string) string {
+	sniffed := baseType(http.DetectContentType(head))
+	byName := typeByExtension(filename)
+	if byName == "" {
+		return sniffed
+	}
+
+	switch sniffed {
+	case "text/plain":
+		if strings.HasPrefix(byName, "text/") || strings.HasSuffix(byName, "json") {
+			return byName
+		}
+	case "application/zip":
+		if strings.Contains(byName, "openxmlformats") {
+			return byName
+		}
+	}
+	return sniffed
+}
+
+// Allowed reports whether the content type matches one of the patterns, which
+// are either exact types or wildcards such as "image/*"
+func Allowed(contentType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == contentType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeByExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if known, ok := extensionTypes[ext]; ok {
+		return known
+	}
+	return baseType(mime.TypeByExtension(ext))
+}
+
+func baseType(contentType string) string {
+	base, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(base)
+}
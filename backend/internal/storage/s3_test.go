@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/config"
+)
+
+const (
+	testBucket    = "uploads"
+	testRegion    = "eu-west-1"
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+// fakeS3 is an S3 stand-in that keeps objects in memory and rejects requests
+// whose signature does not match what it received
+type fakeS3 struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	body        []byte
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f.verify(r); err != nil {
+		f.t.Errorf("%s %s: %v", r.Method, r.URL.EscapedPath(), err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+testBucket+"/")
+	if !ok {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if int64(len(body)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = fakeObject{body: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Write(object.body)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the request's signature from the path, host and headers
+// the server received, as S3 does
+func (f *fakeS3) verify(r *http.Request) error {
+	var credential, signedHeaders, signature string
+	for _, field := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), s3Algorithm+" "), ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		switch name {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			signedHeaders = value
+		case "Signature":
+			signature = value
+		}
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	date := strings.SplitN(amzDate, "T", 2)[0]
+	scope := date + "/" + testRegion + "/s3/aws4_request"
+	if credential != testAccessKey+"/"+scope {
+		return fmt.Errorf("credential = %q, want %q", credential, testAccessKey+"/"+scope)
+	}
+
+	var headers strings.Builder
+	names := strings.Split(signedHeaders, ";")
+	if !sort.StringsAreSorted(names) {
+		return fmt.Errorf("signed headers %q are not sorted", signedHeaders)
+	}
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		canonicalPath(r.URL.Path),
+		r.URL.RawQuery,
+		headers.String(),
+		signedHeaders,
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hexSHA256(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+testSecretKey), date)
+	key = hmacSHA256(key, testRegion)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	if want := hex.EncodeToString(hmacSHA256(key, stringToSign)); signature != want {
+		return fmt.Errorf("signature = %q, want %q", signature, want)
+	}
+	return nil
+}
+
+// canonicalPath encodes the path the way S3 does before checking a
+// signature: every byte but unreserved characters and slashes
+func canonicalPath(path string) string {
+	var encoded strings.Builder
+	for _, c := range []byte(path) {
+		if strings.IndexByte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.~/", c) >= 0 {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+// newTestS3 returns an S3 storage backed by a fake bucket
+func newTestS3(t *testing.T) (*S3Storage, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{t: t, objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Storage(&config.S3{
+		Endpoint:     server.URL,
+		Region:       testRegion,
+		Bucket:       testBucket,
+		AccessKey:    testAccessKey,
+		SecretKey:    testSecretKey,
+		UsePathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+	store.now = func() time.Time { return time.Date(2024, 3, 9, 23, 59, 30, 0, time.UTC) }
+	return store, fake
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		contentType string
+	}{
+		{name: "plain key", key: "attachments/report.pdf", contentType: "application/pdf"},
+		{name: "key to escape", key: "attachments/final report (v2)+ü.txt", contentType: "text/plain"},
+		{name: "no content type", key: "resources/data.bin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, fake := newTestS3(t)
+			ctx := context.Background()
+			content := "contents of " + tt.key
+
+			err := store.Put(ctx, tt.key, strings.NewReader(content), int64(len(content)), tt.contentType)
+			if err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+			if got := fake.objects[tt.key].contentType; got != tt.contentType {
+				t.Errorf("stored content type = %q, want %q", got, tt.contentType)
+			}
+
+			body, err := store.Get(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			got, err := io.ReadAll(body)
+			body.Close()
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(got) != content {
+				t.Errorf("Get = %q, want %q", got, content)
+			}
+
+			err = store.Delete(ctx, tt.key)
+			if err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, ok := fake.objects[tt.key]; ok {
+				t.Error("object still stored after Delete")
+			}
+		})
+	}
+}
+
+func TestS3MissingKey(t *testing.T) {
+	store, _ := newTestS3(t)
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "attachments/missing.txt")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get = %v, want ErrNotFound", err)
+	}
+	err = store.Delete(ctx, "attachments/missing.txt")
+	if err != nil {
+		t.Errorf("Delete = %v, want nil for a missing key", err)
+	}
+}
+
+func TestS3ErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+	store, err := NewS3Storage(&config.S3{
+		Endpoint:     server.URL,
+		Bucket:       testBucket,
+		AccessKey:    testAccessKey,
+		SecretKey:    testSecretKey,
+		UsePathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Storage: %v", err)
+	}
+
+	err = store.Put(context.Background(), "attachments/a.txt", strings.NewReader("a"), 1, "text/plain")
+	if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "returned 403: AccessDenied") {
+		t.Errorf("Put = %v, want the 403 and its message", err)
+	}
+}
+
+func TestS3InvalidKey(t *testing.T) {
+	store, _ := newTestS3(t)
+	for _, key := range []string{"", "/etc/passwd", "a/../b", "a//b"} {
+		_, err := store.Get(context.Background(), key)
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Get(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package storage keeps uploaded files in a pluggable object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/TheApostroff/skill-space/internal/config"
+)
+
+var (
+	ErrNotFound   = errors.New("stored object not found")
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+// Storage stores and retrieves objects by key
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the storage backend selected by the configuration
+func New(cfg *config.Storage) (Storage, error) {
+	switch cfg.Provider {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalDir)
+	case "s3":
+		return NewS3Storage(&cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage provider %q", cfg.Provider)
+	}
+}
+
+// validateKey rejects keys that are empty, absolute or climb out of the
+// storage root
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
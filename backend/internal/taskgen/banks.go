@@ -0,0 +1,200 @@
+package taskgen
+
+import "github.com/TheApostroff/skill-space/internal/api/models"
+
+// Every built-in task reads its input from stdin and prints its answer to
+// stdout, so the same tests apply whichever language the student uses.
+
+type builtinBank struct {
+	keywords []string
+	bank     Bank
+}
+
+var builtinOrder = []string{"strings", "arrays", "math"}
+
+var builtinBanks = map[string]builtinBank{
+	"strings": {
+		keywords: []string{"string", "text", "character", "palindrome"},
+		bank: Bank{
+			"easy": {{
+				Title:       "{{.Activity.Title}}: Reverse a String",
+				Description: "As practice for \"{{.Activity.Title}}\", read a single line of text and print it reversed.",
+				Requirements: []string{
+					"Read one line from standard input",
+					"Print the characters in reverse order",
+					"An empty line prints an empty line",
+				},
+				Hints: []string{
+					"Strip the trailing newline before reversing",
+					"Most languages can reverse a sequence with slicing or a built-in",
+				},
+				EstimatedTime: 10,
+				Tests: []models.TaskTest{
+					{Name: "Single word", Input: "hello\n", ExpectedOutput: "olleh"},
+					{Name: "Sentence with spaces", Input: "Skill Space\n", ExpectedOutput: "ecapS llikS"},
+					{Name: "Empty input", Input: "\n", ExpectedOutput: ""},
+				},
+			}},
+			"medium": {{
+				Title:       "{{.Activity.Title}}: Palindrome Check",
+				Description: "Building on \"{{.Activity.Title}}\", decide whether a line of text is a palindrome when case and non-alphanumeric characters are ignored.",
+				Requirements: []string{
+					"Read one line from standard input",
+					"Ignore letter case, spaces and punctuation",
+					"Print true if the text is a palindrome and false otherwise",
+				},
+				Hints: []string{
+					"Normalize the text first, then compare it with its reverse",
+					"Two indexes moving towards each other avoid building a copy",
+				},
+				EstimatedTime: 20,
+				Tests: []models.TaskTest{
+					{Name: "Classic palindrome", Input: "A man, a plan, a canal: Panama\n", ExpectedOutput: "true"},
+					{Name: "Not a palindrome", Input: "hello\n", ExpectedOutput: "false"},
+					{Name: "Mixed case", Input: "No lemon, no melon\n", ExpectedOutput: "true"},
+				},
+			}},
+			"hard": {{
+				Title:       "{{.Activity.Title}}: Run-Length Encoding",
+				Description: "Apply the ideas from \"{{.Activity.Title}}\" to compress a string with run-length encoding: each run of a repeated character becomes the character followed by the run length.",
+				Requirements: []string{
+					"Read one line of lowercase letters from standard input",
+					"Print every run as the character followed by its length, e.g. aaab becomes a3b1",
+					"Runs longer than nine characters print their full length",
+				},
+				Hints: []string{
+					"Track the current character and how many times you have seen it in a row",
+					"Remember to emit the final run after the loop ends",
+				},
+				EstimatedTime: 30,
+				Tests: []models.TaskTest{
+					{Name: "Mixed runs", Input: "aaabccdddd\n", ExpectedOutput: "a3b1c2d4"},
+					{Name: "No repeats", Input: "abc\n", ExpectedOutput: "a1b1c1"},
+					{Name: "Long run", Input: "zzzzzzzzzzzz\n", ExpectedOutput: "z12"},
+				},
+			}},
+		},
+	},
+	"arrays": {
+		keywords: []string{"array", "list", "loop", "iteration", "collection", "sequence"},
+		bank: Bank{
+			"easy": {{
+				Title:       "{{.Activity.Title}}: Sum of Numbers",
+				Description: "As practice for \"{{.Activity.Title}}\", read a list of integers and print their sum.",
+				Requirements: []string{
+					"Read one line of space-separated integers from standard input",
+					"Print the sum of the integers",
+					"Handle negative numbers",
+				},
+				Hints: []string{
+					"Split the line on whitespace and convert each part to an integer",
+					"Keep a running total in a loop",
+				},
+				EstimatedTime: 10,
+				Tests: []models.TaskTest{
+					{Name: "Positive numbers", Input: "1 2 3 4\n", ExpectedOutput: "10"},
+					{Name: "Negative numbers", Input: "-5 5 10\n", ExpectedOutput: "10"},
+					{Name: "Single number", Input: "42\n", ExpectedOutput: "42"},
+				},
+			}},
+			"medium": {{
+				Title:       "{{.Activity.Title}}: Second Largest Value",
+				Description: "Building on \"{{.Activity.Title}}\", find the second largest distinct value in a list of integers.",
+				Requirements: []string{
+					"Read one line of space-separated integers from standard input",
+					"Print the second largest distinct value",
+					"The input always contains at least two distinct values",
+				},
+				Hints: []string{
+					"Duplicates of the largest value do not count as the second largest",
+					"You can solve it in a single pass by tracking the two largest values",
+				},
+				EstimatedTime: 20,
+				Tests: []models.TaskTest{
+					{Name: "Duplicate maximum", Input: "4 1 7 7 3\n", ExpectedOutput: "4"},
+					{Name: "Two values", Input: "10 9\n", ExpectedOutput: "9"},
+					{Name: "Many duplicates", Input: "5 5 5 2\n", ExpectedOutput: "2"},
+				},
+			}},
+			"hard": {{
+				Title:       "{{.Activity.Title}}: Longest Increasing Subsequence",
+				Description: "Apply the ideas from \"{{.Activity.Title}}\" to find the length of the longest strictly increasing subsequence of a list of integers.",
+				Requirements: []string{
+					"Read one line of space-separated integers from standard input",
+					"Print the length of the longest strictly increasing subsequence",
+					"Aim for better than quadratic time on long inputs",
+				},
+				Hints: []string{
+					"A subsequence does not have to be contiguous",
+					"Keep, for each length, the smallest possible tail value and use binary search",
+				},
+				EstimatedTime: 40,
+				Tests: []models.TaskTest{
+					{Name: "Mixed values", Input: "10 9 2 5 3 7 101 18\n", ExpectedOutput: "4"},
+					{Name: "Repeated dips", Input: "0 1 0 3 2 3\n", ExpectedOutput: "4"},
+					{Name: "All equal", Input: "7 7 7 7\n", ExpectedOutput: "1"},
+				},
+			}},
+		},
+	},
+	"math": {
+		keywords: []string{"math", "number", "arithmetic", "prime", "recursion", "algorithm"},
+		bank: Bank{
+			"easy": {{
+				Title:       "{{.Activity.Title}}: FizzBuzz",
+				Description: "As practice for \"{{.Activity.Title}}\", print the numbers from 1 to n, replacing multiples of 3 with Fizz, multiples of 5 with Buzz and multiples of both with FizzBuzz.",
+				Requirements: []string{
+					"Read n from standard input",
+					"Print one value per line from 1 to n",
+					"Check for multiples of both 3 and 5 first",
+				},
+				Hints: []string{
+					"The modulo operator tells you whether a number divides another",
+				},
+				EstimatedTime: 10,
+				Tests: []models.TaskTest{
+					{Name: "Up to five", Input: "5\n", ExpectedOutput: "1\n2\nFizz\n4\nBuzz"},
+					{Name: "Up to fifteen", Input: "15\n", ExpectedOutput: "1\n2\nFizz\n4\nBuzz\nFizz\n7\n8\nFizz\nBuzz\n11\nFizz\n13\n14\nFizzBuzz"},
+					{Name: "Only one", Input: "1\n", ExpectedOutput: "1"},
+				},
+			}},
+			"medium": {{
+				Title:       "{{.Activity.Title}}: Primes up to N",
+				Description: "Building on \"{{.Activity.Title}}\", print every prime number less than or equal to n.",
+				Requirements: []string{
+					"Read n (at least 2) from standard input",
+					"Print the primes in increasing order separated by single spaces",
+				},
+				Hints: []string{
+					"The Sieve of Eratosthenes finds all primes up to n efficiently",
+					"You only need to cross out multiples of numbers up to the square root of n",
+				},
+				EstimatedTime: 20,
+				Tests: []models.TaskTest{
+					{Name: "Up to ten", Input: "10\n", ExpectedOutput: "2 3 5 7"},
+					{Name: "Smallest prime", Input: "2\n", ExpectedOutput: "2"},
+					{Name: "Up to thirty", Input: "30\n", ExpectedOutput: "2 3 5 7 11 13 17 19 23 29"},
+				},
+			}},
+			"hard": {{
+				Title:       "{{.Activity.Title}}: Huge Fibonacci Numbers",
+				Description: "Apply the ideas from \"{{.Activity.Title}}\" to compute the n-th Fibonacci number modulo 1000000007 for n as large as 10^18.",
+				Requirements: []string{
+					"Read n from standard input, where F(0) = 0 and F(1) = 1",
+					"Print F(n) modulo 1000000007",
+					"Run in logarithmic time in n",
+				},
+				Hints: []string{
+					"A simple loop is far too slow for n = 10^18",
+					"Look up fast doubling or matrix exponentiation",
+				},
+				EstimatedTime: 45,
+				Tests: []models.TaskTest{
+					{Name: "Small n", Input: "10\n", ExpectedOutput: "55"},
+					{Name: "Beyond 64 bits", Input: "100\n", ExpectedOutput: "687995182"},
+					{Name: "Huge n", Input: "1000000000000000000\n", ExpectedOutput: "209783453"},
+				},
+			}},
+		},
+	},
+}
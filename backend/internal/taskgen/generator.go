@@ -0,0 +1,63 @@
+// Package taskgen produces generative tasks for course activities.
+package taskgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+	"github.com/TheApostroff/skill-space/internal/config"
+	"github.com/TheApostroff/skill-space/internal/sandbox"
+)
+
+var ErrInvalidTask = errors.New("generator returned an invalid task")
+
+// Request describes the task to generate
+type Request struct {
+	Activity   *models.Activity
+	Difficulty string
+	Language   string
+	StudentID  string
+}
+
+// Task is a generated task before it is assigned an ID and stored
+type Task struct {
+	Title         string            `json:"title"`
+	Description   string            `json:"description"`
+	Requirements  []string          `json:"requirements"`
+	Hints         []string          `json:"hints"`
+	Language      string            `json:"language"`
+	EstimatedTime int               `json:"estimatedTime"`
+	Tests         []models.TaskTest `json:"tests"`
+}
+
+// Generator creates a task for an activity at the requested difficulty
+type Generator interface {
+	Generate(ctx context.Context, req *Request) (*Task, error)
+}
+
+// New returns the generator selected by the configuration
+func New(cfg *config.Generator) (Generator, error) {
+	switch cfg.Provider {
+	case "", "template":
+		return NewTemplateGenerator(), nil
+	case "http":
+		return NewHTTPGenerator(cfg.Endpoint, cfg.APIKey, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown task generator provider %q", cfg.Provider)
+	}
+}
+
+func (t *Task) validate() error {
+	if t.Title == "" || t.Description == "" {
+		return fmt.Errorf("%w: title and description are required", ErrInvalidTask)
+	}
+	if len(t.Tests) == 0 {
+		return fmt.Errorf("%w: at least one test is required", ErrInvalidTask)
+	}
+	if _, ok := sandbox.Languages[t.Language]; !ok {
+		return fmt.Errorf("%w: unsupported language %q", ErrInvalidTask, t.Language)
+	}
+	return nil
+}
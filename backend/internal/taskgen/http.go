@@ -0,0 +1,96 @@
+package taskgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+)
+
+// HTTPGenerator asks an external LLM service to write the task. The service
+// receives the activity and difficulty as JSON and must answer with a Task.
+type HTTPGenerator struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func NewHTTPGenerator(endpoint string, apiKey string, timeout time.Duration) *HTTPGenerator {
+	return &HTTPGenerator{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+type httpActivity struct {
+	ID          string                  `json:"id"`
+	Title       string                  `json:"title"`
+	Description string                  `json:"description"`
+	Type        string                  `json:"type"`
+	Metadata    models.ActivityMetadata `json:"metadata"`
+}
+
+type httpRequest struct {
+	Activity   httpActivity `json:"activity"`
+	Difficulty string       `json:"difficulty"`
+	Language   string       `json:"language"`
+}
+
+func (g *HTTPGenerator) Generate(ctx context.Context, req *Request) (*Task, error) {
+	body, err := json.Marshal(httpRequest{
+		Activity: httpActivity{
+			ID:          req.Activity.ID,
+			Title:       req.Activity.Title,
+			Description: req.Activity.Description,
+			Type:        req.Activity.Type,
+			Metadata:    req.Activity.Metadata,
+		},
+		Difficulty: req.Difficulty,
+		Language:   req.Language,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if g.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+g.apiKey)
+	}
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("task generator request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("task generator returned %s: %s", resp.Status, message)
+	}
+
+	var task Task
+	err = json.NewDecoder(resp.Body).Decode(&task)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTask, err)
+	}
+	if task.Language == "" {
+		task.Language = req.Language
+	}
+
+	err = task.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
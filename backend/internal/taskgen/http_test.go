@@ -0,0 +1,135 @@
+package taskgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+)
+
+func testRequest() *Request {
+	return &Request{
+		Activity: &models.Activity{
+			ID:          "activity-1",
+			Title:       "Recursion",
+			Description: "Functions that call themselves",
+			Type:        models.ActivityTypeGenerativeTask,
+		},
+		Difficulty: "medium",
+		Language:   "python",
+		StudentID:  "student-1",
+	}
+}
+
+func TestHTTPGeneratorRequest(t *testing.T) {
+	var got httpRequest
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Write([]byte(`{"title": "Factorial", "description": "Compute n!", "tests": [{"name": "Zero", "input": "0\n", "expectedOutput": "1"}]}`))
+	}))
+	defer server.Close()
+
+	task, err := NewHTTPGenerator(server.URL, "secret", time.Second).Generate(context.Background(), testRequest())
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if ct := header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if auth := header.Get("Authorization"); auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Bearer secret")
+	}
+	if got.Activity.ID != "activity-1" || got.Activity.Title != "Recursion" || got.Difficulty != "medium" || got.Language != "python" {
+		t.Errorf("request = %+v, want the activity, difficulty and language", got)
+	}
+
+	if task.Title != "Factorial" || len(task.Tests) != 1 || task.Tests[0].ExpectedOutput != "1" {
+		t.Errorf("task = %+v, want the service's task", task)
+	}
+	if task.Language != "python" {
+		t.Errorf("language = %q, want the requested language when the service leaves it out", task.Language)
+	}
+}
+
+func TestHTTPGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		handler  http.HandlerFunc
+		wantIs   error
+		wantText string
+	}{
+		{
+			name: "non-2xx status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "model overloaded", http.StatusBadGateway)
+			},
+			wantText: "502 Bad Gateway: model overloaded",
+		},
+		{
+			name:    "timeout",
+			timeout: 50 * time.Millisecond,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				// Once the request is read, the server notices the client
+				// giving up and cancels the request's context
+				io.Copy(io.Discard, r.Body)
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			},
+			wantText: "task generator request failed",
+		},
+		{
+			name: "malformed JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"title": "Factorial",`))
+			},
+			wantIs: ErrInvalidTask,
+		},
+		{
+			name: "task without tests",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"title": "Factorial", "description": "Compute n!", "tests": []}`))
+			},
+			wantIs: ErrInvalidTask,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = time.Second
+			}
+			task, err := NewHTTPGenerator(server.URL, "", timeout).Generate(context.Background(), testRequest())
+			if err == nil {
+				t.Fatalf("Generate = %+v, want an error", task)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("err = %v, want %v", err, tt.wantIs)
+			}
+			if tt.wantText != "" && !strings.Contains(err.Error(), tt.wantText) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package taskgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"text/template"
+
+	"github.com/TheApostroff/skill-space/internal/api/models"
+)
+
+// Template is a task definition whose text fields may reference the activity,
+// e.g. {{.Activity.Title}}, using text/template syntax
+type Template struct {
+	Title         string            `json:"title"`
+	Description   string            `json:"description"`
+	Requirements  []string          `json:"requirements"`
+	Hints         []string          `json:"hints"`
+	EstimatedTime int               `json:"estimatedTime"`
+	Tests         []models.TaskTest `json:"tests"`
+}
+
+// Bank groups task templates by difficulty
+type Bank map[string][]Template
+
+// templateData is what task templates are rendered with
+type templateData struct {
+	Activity   *models.Activity
+	Difficulty string
+	Topic      string
+}
+
+// TemplateGenerator builds tasks offline from parametrized task banks. An
+// activity can carry its own bank in Metadata["taskBank"]; otherwise a built-in
+// bank is chosen by Metadata["topic"] or by keywords in the activity's title
+// and description.
+type TemplateGenerator struct{}
+
+func NewTemplateGenerator() *TemplateGenerator {
+	return &TemplateGenerator{}
+}
+
+func (g *TemplateGenerator) Generate(ctx context.Context, req *Request) (*Task, error) {
+	bank, topic, err := selectBank(req.Activity)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := bank.forDifficulty(req.Difficulty)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: task bank has no templates", ErrInvalidTask)
+	}
+	chosen := candidates[rand.IntN(len(candidates))]
+
+	data := templateData{
+		Activity:   req.Activity,
+		Difficulty: req.Difficulty,
+		Topic:      topic,
+	}
+
+	task := &Task{
+		Language:      req.Language,
+		EstimatedTime: chosen.EstimatedTime,
+		Tests:         chosen.Tests,
+	}
+	task.Title, err = render(chosen.Title, data)
+	if err != nil {
+		return nil, err
+	}
+	task.Description, err = render(chosen.Description, data)
+	if err != nil {
+		return nil, err
+	}
+	task.Requirements, err = renderAll(chosen.Requirements, data)
+	if err != nil {
+		return nil, err
+	}
+	task.Hints, err = renderAll(chosen.Hints, data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = task.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// selectBank picks the bank for an activity and reports the topic it covers
+func selectBank(activity *models.Activity) (Bank, string, error) {
+	if raw, ok := activity.Metadata["taskBank"]; ok {
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, "", err
+		}
+		var bank Bank
+		err = json.Unmarshal(encoded, &bank)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: activity task bank is malformed: %v", ErrInvalidTask, err)
+		}
+		return bank, activity.Title, nil
+	}
+
+	if topic, ok := activity.Metadata["topic"].(string); ok {
+		if builtin, ok := builtinBanks[strings.ToLower(topic)]; ok {
+			return builtin.bank, strings.ToLower(topic), nil
+		}
+	}
+
+	text := strings.ToLower(activity.Title + " " + activity.Description)
+	for _, name := range builtinOrder {
+		for _, keyword := range builtinBanks[name].keywords {
+			if strings.Contains(text, keyword) {
+				return builtinBanks[name].bank, name, nil
+			}
+		}
+	}
+
+	merged := Bank{}
+	for _, name := range builtinOrder {
+		for difficulty, templates := range builtinBanks[name].bank {
+			merged[difficulty] = append(merged[difficulty], templates...)
+		}
+	}
+	return merged, "general programming", nil
+}
+
+// difficultyFallbacks lists, for each difficulty, the bank levels to try in order
+var difficultyFallbacks = map[string][]string{
+	"beginner": {"beginner", "easy", "medium", "hard"},
+	"easy":     {"easy", "beginner", "medium", "hard"},
+	"medium":   {"medium", "easy", "hard"},
+	"hard":     {"hard", "expert", "medium"},
+	"expert":   {"expert", "hard", "medium"},
+}
+
+func (b Bank) forDifficulty(difficulty string) []Template {
+	levels, ok := difficultyFallbacks[difficulty]
+	if !ok {
+		levels = []string{difficulty, "medium"}
+	}
+	for _, level := range levels {
+		if templates := b[level]; len(templates) > 0 {
+			return templates
+		}
+	}
+	for _, templates := range b {
+		if len(templates) > 0 {
+			return templates
+		}
+	}
+	return nil
+}
+
+func render(text string, data templateData) (string, error) {
+	tmpl, err := template.New("task").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTask, err)
+	}
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTask, err)
+	}
+	return out.String(), nil
+}
+
+func renderAll(texts []string, data templateData) ([]string, error) {
+	rendered := make([]string, 0, len(texts))
+	for _, text := range texts {
+		value, err := render(text, data)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, value)
+	}
+	return rendered, nil
+}
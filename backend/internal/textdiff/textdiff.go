@@ -0,0 +1,126 @@
+// Package textdiff computes line-based differences between two texts.
+package textdiff
+
+import "strings"
+
+// maxCells bounds the size of the comparison table. Larger inputs are
+// reported as a full replacement instead of a minimal diff.
+const maxCells = 4_000_000
+
+// Op is the kind of change a line represents
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Line is one line of a diff
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Diff is the line-by-line difference between two texts
+type Diff struct {
+	Lines   []Line `json:"lines"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+// Lines returns the changes that turn a into b, based on their longest
+// common subsequence of lines
+func Lines(a string, b string) *Diff {
+	before, after := split(a), split(b)
+
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	diff := &Diff{Lines: []Line{}}
+	for _, text := range before[:prefix] {
+		diff.add(Equal, text)
+	}
+	diff.middle(before[prefix:len(before)-suffix], after[prefix:len(after)-suffix])
+	for _, text := range before[len(before)-suffix:] {
+		diff.add(Equal, text)
+	}
+
+	return diff
+}
+
+// middle diffs the part of the texts between their common prefix and suffix
+func (d *Diff) middle(before []string, after []string) {
+	if len(before)*len(after) > maxCells {
+		for _, text := range before {
+			d.add(Delete, text)
+		}
+		for _, text := range after {
+			d.add(Insert, text)
+		}
+		return
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			d.add(Equal, before[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			d.add(Delete, before[i])
+			i++
+		default:
+			d.add(Insert, after[j])
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		d.add(Delete, before[i])
+	}
+	for ; j < len(after); j++ {
+		d.add(Insert, after[j])
+	}
+}
+
+func (d *Diff) add(op Op, text string) {
+	d.Lines = append(d.Lines, Line{Op: op, Text: text})
+	switch op {
+	case Insert:
+		d.Added++
+	case Delete:
+		d.Removed++
+	}
+}
+
+func split(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,94 @@
+package textdiff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		a, b        string
+		want        []Line
+		wantAdded   int
+		wantRemoved int
+	}{
+		{name: "both empty", want: []Line{}},
+		{
+			name: "identical",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo",
+			want: []Line{{Equal, "one"}, {Equal, "two"}},
+		},
+		{
+			name:      "from empty",
+			b:         "one\ntwo",
+			want:      []Line{{Insert, "one"}, {Insert, "two"}},
+			wantAdded: 2,
+		},
+		{
+			name:        "to empty",
+			a:           "one",
+			want:        []Line{{Delete, "one"}},
+			wantRemoved: 1,
+		},
+		{
+			name:      "line inserted in the middle",
+			a:         "one\nthree",
+			b:         "one\ntwo\nthree",
+			want:      []Line{{Equal, "one"}, {Insert, "two"}, {Equal, "three"}},
+			wantAdded: 1,
+		},
+		{
+			name:        "line changed",
+			a:           "one\ntwo\nthree",
+			b:           "one\n2\nthree",
+			want:        []Line{{Equal, "one"}, {Delete, "two"}, {Insert, "2"}, {Equal, "three"}},
+			wantAdded:   1,
+			wantRemoved: 1,
+		},
+		{
+			name:        "common lines kept between changes",
+			a:           "a\nb\nc\nd",
+			b:           "b\nx\nd\ny",
+			want:        []Line{{Delete, "a"}, {Equal, "b"}, {Delete, "c"}, {Insert, "x"}, {Equal, "d"}, {Insert, "y"}},
+			wantAdded:   2,
+			wantRemoved: 2,
+		},
+		{
+			name: "windows line endings",
+			a:    "one\r\ntwo\r\n",
+			b:    "one\ntwo\n",
+			want: []Line{{Equal, "one"}, {Equal, "two"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Lines(tt.a, tt.b)
+			if !reflect.DeepEqual(diff.Lines, tt.want) {
+				t.Errorf("lines = %v, want %v", diff.Lines, tt.want)
+			}
+			if diff.Added != tt.wantAdded || diff.Removed != tt.wantRemoved {
+				t.Errorf("added, removed = %d, %d, want %d, %d", diff.Added, diff.Removed, tt.wantAdded, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestLinesTooLargeIsReplaced(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 2100; i++ {
+		a.WriteString("a\n")
+		b.WriteString("b\n")
+	}
+
+	diff := Lines("same\n"+a.String(), "same\n"+b.String())
+	if diff.Added != 2100 || diff.Removed != 2100 {
+		t.Fatalf("added, removed = %d, %d, want 2100, 2100", diff.Added, diff.Removed)
+	}
+	if diff.Lines[0] != (Line{Equal, "same"}) || diff.Lines[1].Op != Delete || diff.Lines[len(diff.Lines)-1].Op != Insert {
+		t.Errorf("want the common prefix, then every old line deleted and every new line inserted")
+	}
+}